@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"net"
 	"os"
@@ -10,11 +11,12 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/kiribu/jwt-practice/config"
+	"github.com/kiribu/jwt-practice/internal/analytics/consumer"
 	analyticsgrpc "github.com/kiribu/jwt-practice/internal/analytics/grpc"
 	"github.com/kiribu/jwt-practice/internal/analytics/grpc/pb"
-	"github.com/kiribu/jwt-practice/internal/analytics/kafka"
 	"github.com/kiribu/jwt-practice/internal/analytics/service"
 	"github.com/kiribu/jwt-practice/internal/analytics/storage"
+	"github.com/kiribu/jwt-practice/pkg/broker"
 	"github.com/kiribu/jwt-practice/pkg/logger"
 	"google.golang.org/grpc"
 )
@@ -43,12 +45,26 @@ func main() {
 	analyticsServer := analyticsgrpc.NewAnalyticsServer(analyticsService)
 
 	brokersEnv := getEnv("KAFKA_BROKERS", "kafka:9092")
-	brokers := strings.Split(brokersEnv, ",")
+	brokerConfig := broker.Config{
+		Driver:        getEnv("BROKER_DRIVER", broker.DriverKafka),
+		KafkaBrokers:  strings.Split(brokersEnv, ","),
+		RedisAddr:     getEnv("REDIS_ADDR", "redis:6379"),
+		RedisPassword: os.Getenv("REDIS_PASSWORD"),
+	}
 	lifecycleTopic := getEnv("KAFKA_TOPIC_LIFECYCLE", "reminder_lifecycle")
 
-	consumer := kafka.NewConsumer(brokers, lifecycleTopic, analyticsService)
-	go consumer.Start()
-	defer consumer.Close()
+	subscriber, err := broker.NewSubscriber(brokerConfig, lifecycleTopic, "analytics-service-group")
+	if err != nil {
+		slog.Error("Failed to subscribe to lifecycle topic", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lifecycleConsumer := consumer.NewConsumer(subscriber, analyticsService)
+	go lifecycleConsumer.Start(ctx)
+	defer lifecycleConsumer.Close()
 
 	grpcPort := getEnv("ANALYTICS_GRPC_PORT", "50053")
 	listener, err := net.Listen("tcp", ":"+grpcPort)
@@ -74,6 +90,7 @@ func main() {
 	<-quit
 
 	slog.Info("Shutting down Analytics Service...")
+	cancel()
 	grpcServer.GracefulStop()
 }
 
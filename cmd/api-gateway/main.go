@@ -12,7 +12,11 @@ import (
 	"github.com/kiribu/jwt-practice/internal/gateway/client"
 	"github.com/kiribu/jwt-practice/internal/gateway/handlers"
 	customMiddleware "github.com/kiribu/jwt-practice/internal/gateway/middleware"
+	"github.com/kiribu/jwt-practice/internal/gateway/tokens"
+	"github.com/kiribu/jwt-practice/pkg/live"
 	"github.com/kiribu/jwt-practice/pkg/logger"
+	"github.com/kiribu/jwt-practice/pkg/redis"
+	"github.com/kiribu/jwt-practice/pkg/revocation"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -55,24 +59,82 @@ func main() {
 	defer analyticsClient.Close()
 	slog.Info("API Gateway: Connected to Analytics Service", "addr", analyticsServiceAddr)
 
-	authHandler := handlers.NewAuthHandler(authClient)
+	// Connect to Notification Service
+	notificationServiceAddr := getEnv("NOTIFICATION_SERVICE_ADDR", "notification-service:50054")
+	notificationClient, err := client.NewNotificationClient(notificationServiceAddr)
+	if err != nil {
+		slog.Error("Failed to connect to Notification Service", "error", err)
+		os.Exit(1)
+	}
+	defer notificationClient.Close()
+	slog.Info("API Gateway: Connected to Notification Service", "addr", notificationServiceAddr)
+
 	reminderHandler := handlers.NewReminderHandler(reminderClient)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsClient)
+	webhookHandler := handlers.NewWebhookHandler(notificationClient)
+	// Signed-in users get responses in the language of their preferences
+	locales := client.NewLocales(notificationClient, time.Minute)
+	preferencesHandler := handlers.NewPreferencesHandler(notificationClient, locales)
+	inboxHandler := handlers.NewInboxHandler(notificationClient)
+
+	// Live pushes reach whichever replica holds the user's connection
+	redisClient, err := redis.NewRedisClient(getEnv("REDIS_ADDR", "redis:6379"), os.Getenv("REDIS_PASSWORD"))
+	if err != nil {
+		slog.Error("Failed to connect to Redis", "error", err)
+		os.Exit(1)
+	}
+	defer redisClient.Close()
+
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	hub := live.NewHub(redisClient)
+	go hub.Run(bgCtx)
+
+	// Tokens are verified here unless the gateway cannot be sure, in which
+	// case the auth service is asked
+	var verifier *tokens.Verifier
+	if getEnv("LOCAL_TOKEN_VERIFICATION", "true") == "true" {
+		revoked := revocation.NewList(redisClient)
+		go revoked.Run(bgCtx)
+		verifier = tokens.NewVerifier(authClient, revoked)
+		go verifier.Run(bgCtx)
+	}
+	authHandler := handlers.NewAuthHandler(authClient, verifier)
+	streamHandler := handlers.NewStreamHandler(hub, authHandler)
 
 	e := echo.New()
 	e.HideBanner = true
 
 	e.Use(customMiddleware.SlogLogger)
 	e.Use(middleware.Recover())
+	e.Use(customMiddleware.Locale)
 
 	e.POST("/auth/register", authHandler.Register)
 	e.POST("/auth/login", authHandler.Login)
+	e.POST("/auth/login/mfa", authHandler.VerifyMFA)
 	e.POST("/auth/refresh", authHandler.Refresh)
+	e.GET("/auth/email/verify", authHandler.VerifyEmail)
+	e.POST("/auth/email/verify", authHandler.VerifyEmail)
+	e.POST("/auth/password/forgot", authHandler.RequestPasswordReset)
+	e.POST("/auth/password/reset", authHandler.ResetPassword)
+	e.GET("/.well-known/jwks.json", authHandler.JWKS)
 
 	protected := e.Group("")
 	protected.Use(authHandler.AuthMiddleware)
+	protected.Use(customMiddleware.UserLocale(locales))
 	protected.POST("/auth/logout", authHandler.Logout)
 	protected.GET("/auth/profile", authHandler.Profile)
+	protected.PATCH("/auth/profile", authHandler.UpdateProfile)
+	protected.PUT("/auth/email", authHandler.SetEmail)
+	protected.POST("/auth/password", authHandler.ChangePassword)
+	protected.POST("/auth/mfa/totp", authHandler.EnrollTOTP)
+	protected.POST("/auth/mfa/totp/confirm", authHandler.ConfirmTOTP)
+	protected.POST("/auth/mfa/totp/disable", authHandler.DisableTOTP)
+	protected.POST("/auth/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+	protected.GET("/auth/sessions", authHandler.ListSessions)
+	protected.DELETE("/auth/sessions", authHandler.RevokeAllSessions)
+	protected.DELETE("/auth/sessions/:id", authHandler.RevokeSession)
 
 	protected.POST("/reminders", reminderHandler.Create)
 	protected.GET("/reminders", reminderHandler.List)
@@ -82,6 +144,22 @@ func main() {
 
 	protected.GET("/analytics/me", analyticsHandler.GetStats)
 
+	protected.POST("/webhooks", webhookHandler.Create)
+	protected.GET("/webhooks", webhookHandler.List)
+	protected.DELETE("/webhooks/:id", webhookHandler.Delete)
+	protected.POST("/webhooks/:id/enable", webhookHandler.Enable)
+	protected.GET("/webhooks/:id/deliveries", webhookHandler.Deliveries)
+	protected.POST("/webhooks/:id/test", webhookHandler.SendTest)
+
+	protected.GET("/me/notification-preferences", preferencesHandler.Get)
+	protected.PUT("/me/notification-preferences", preferencesHandler.Update)
+
+	e.GET("/inbox/stream", streamHandler.Stream, authHandler.StreamAuthMiddleware, customMiddleware.UserLocale(locales))
+	protected.GET("/inbox", inboxHandler.List)
+	protected.POST("/inbox/read-all", inboxHandler.MarkAllRead)
+	protected.POST("/inbox/:id/read", inboxHandler.MarkRead)
+	protected.DELETE("/inbox/:id", inboxHandler.Delete)
+
 	e.GET("/health", func(c echo.Context) error {
 		return c.String(200, "OK")
 	})
@@ -95,12 +173,41 @@ func main() {
 		"POST   /refresh",
 		"POST   /auth/logout",
 		"GET    /profile",
+		"PATCH  /auth/profile",
+		"PUT    /auth/email",
+		"GET    /auth/email/verify",
+		"POST   /auth/email/verify",
+		"GET    /auth/sessions",
+		"DELETE /auth/sessions",
+		"DELETE /auth/sessions/:id",
+		"POST   /auth/login/mfa",
+		"POST   /auth/mfa/totp",
+		"POST   /auth/mfa/totp/confirm",
+		"POST   /auth/mfa/totp/disable",
+		"POST   /auth/mfa/recovery-codes",
+		"POST   /auth/password",
+		"POST   /auth/password/forgot",
+		"POST   /auth/password/reset",
+		"GET    /.well-known/jwks.json",
 		"POST   /reminders",
 		"GET    /reminders",
 		"GET    /reminders/:id",
 		"PUT    /reminders/:id",
 		"DELETE /reminders/:id",
 		"GET    /analytics/me",
+		"POST   /webhooks",
+		"GET    /webhooks",
+		"DELETE /webhooks/:id",
+		"POST   /webhooks/:id/enable",
+		"GET    /webhooks/:id/deliveries",
+		"POST   /webhooks/:id/test",
+		"GET    /me/notification-preferences",
+		"PUT    /me/notification-preferences",
+		"GET    /inbox",
+		"GET    /inbox/stream",
+		"POST   /inbox/:id/read",
+		"POST   /inbox/read-all",
+		"DELETE /inbox/:id",
 		"GET    /health",
 	})
 
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kiribu/jwt-practice/config"
@@ -13,8 +17,14 @@ import (
 	"github.com/kiribu/jwt-practice/internal/auth/grpc/pb"
 	"github.com/kiribu/jwt-practice/internal/auth/service"
 	"github.com/kiribu/jwt-practice/internal/auth/storage"
+	"github.com/kiribu/jwt-practice/internal/auth/worker"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"github.com/kiribu/jwt-practice/pkg/jwtkeys"
 	"github.com/kiribu/jwt-practice/pkg/logger"
 	"github.com/kiribu/jwt-practice/pkg/redis"
+	"github.com/kiribu/jwt-practice/utils"
 	"google.golang.org/grpc"
 )
 
@@ -47,10 +57,48 @@ func main() {
 	defer redisClient.Close()
 	slog.Info("Auth Service: Successfully connected to Redis")
 
+	brokersEnv := getEnv("KAFKA_BROKERS", "kafka:9092")
+	publisher, err := broker.NewPublisher(broker.Config{
+		Driver:        getEnv("BROKER_DRIVER", broker.DriverKafka),
+		KafkaBrokers:  strings.Split(brokersEnv, ","),
+		RedisAddr:     redisAddr,
+		RedisPassword: redisPassword,
+	})
+	if err != nil {
+		slog.Error("Failed to create event publisher", "error", err)
+		os.Exit(1)
+	}
+	defer publisher.Close()
+
+	notificationTopic := getEnv("KAFKA_TOPIC_NOTIFICATIONS", "notifications")
+	contentType, err := events.ParseContentType(getEnv("EVENT_CONTENT_TYPE", events.ContentTypeJSON))
+	if err != nil {
+		slog.Error("Invalid EVENT_CONTENT_TYPE", "error", err)
+		os.Exit(1)
+	}
+
 	store := storage.NewPostgresStorage(db)
-	authService := service.NewAuthService(store, redisClient)
+
+	cleanupInterval, err := time.ParseDuration(getEnv("TOKEN_CLEANUP_INTERVAL", "1h"))
+	if err != nil {
+		slog.Error("Invalid TOKEN_CLEANUP_INTERVAL", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go worker.NewTokenCleanupWorker(store, cleanupInterval).Start(ctx)
+
+	keys, err := loadSigningKeys(ctx)
+	if err != nil {
+		slog.Error("Failed to load JWT signing keys", "error", err)
+		os.Exit(1)
+	}
+
+	authService := service.NewAuthService(store, redisClient, keys, publisher, notificationTopic, contentType)
 	authServer := authgrpc.NewAuthServer(authService)
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(i18n.UnaryServerInterceptor()))
 	pb.RegisterAuthServiceServer(grpcServer, authServer)
 
 	port := getEnv("GRPC_PORT", "50051")
@@ -74,9 +122,66 @@ func main() {
 	<-quit
 
 	slog.Info("Shutting down Auth Service...")
+	cancel()
 	grpcServer.GracefulStop()
 }
 
+// loadSigningKeys reads the access token keys from JWT_KEYS_DIR, which is
+// then watched and rotated in the background, or else from a single PEM key
+// in JWT_PRIVATE_KEY, which can only be changed by a restart.
+func loadSigningKeys(ctx context.Context) (*jwtkeys.Keyset, error) {
+	if pemKey := os.Getenv("JWT_PRIVATE_KEY"); pemKey != "" {
+		// Also accept the key on one line with escaped newlines
+		pemKey = strings.ReplaceAll(pemKey, `\n`, "\n")
+		key, err := jwtkeys.ParsePEM(os.Getenv("JWT_KEY_ID"), []byte(pemKey), time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY: %w", err)
+		}
+		slog.Info("Loaded JWT signing key from environment", "kid", key.ID, "alg", key.Algorithm)
+		return jwtkeys.NewKeyset(key), nil
+	}
+
+	config := worker.KeyRotationConfig{
+		Dir:       getEnv("JWT_KEYS_DIR", "keys"),
+		Algorithm: getEnv("JWT_ALGORITHM", jwtkeys.EdDSA),
+	}
+	durations := []struct {
+		name   string
+		value  string
+		target *time.Duration
+	}{
+		{"JWT_KEY_ROTATION_INTERVAL", "720h", &config.RotationInterval},
+		{"JWT_KEY_OVERLAP", "1h", &config.Overlap},
+		{"JWT_KEY_RELOAD_INTERVAL", "1m", &config.ReloadInterval},
+	}
+	for _, d := range durations {
+		value, err := time.ParseDuration(getEnv(d.name, d.value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+		*d.target = value
+	}
+	if config.Overlap < utils.AccessTokenDuration {
+		return nil, fmt.Errorf("JWT_KEY_OVERLAP must be at least the access token lifetime of %s", utils.AccessTokenDuration)
+	}
+	if config.Algorithm != jwtkeys.EdDSA && config.Algorithm != jwtkeys.RS256 {
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", config.Algorithm)
+	}
+
+	if err := os.MkdirAll(config.Dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	keys := jwtkeys.NewKeyset()
+	rotation := worker.NewKeyRotationWorker(keys, config)
+	if err := rotation.Reload(); err != nil {
+		return nil, err
+	}
+	go rotation.Start(ctx)
+
+	return keys, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
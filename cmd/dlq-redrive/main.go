@@ -0,0 +1,135 @@
+// Command dlq-redrive moves notifications from the dead letter topic back
+// to the topic they failed on, where they go through the retry tiers again.
+//
+// Only messages dead-lettered before the command started are considered, so
+// messages that fail again during the run are not picked up a second time.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/kiribu/jwt-practice/internal/notification/consumer"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"github.com/kiribu/jwt-practice/pkg/logger"
+)
+
+func init() {
+	_ = godotenv.Load(".env")
+}
+
+func main() {
+	logger.Setup(getEnv("APP_ENV", "local"))
+
+	topic := getEnv("KAFKA_TOPIC_NOTIFICATIONS", "notifications")
+	dlqTopic := flag.String("dlq", getEnv("KAFKA_TOPIC_NOTIFICATIONS_DLQ", topic+"-dlq"), "dead letter topic to drain")
+	groupID := flag.String("group", "notification-dlq-redrive", "consumer group used to read the dead letter topic")
+	limit := flag.Int("limit", 0, "maximum number of messages to re-drive, 0 for all")
+	includePoison := flag.Bool("include-poison", false, "also re-drive messages that were rejected as unprocessable")
+	dryRun := flag.Bool("dry-run", false, "only list the messages, without publishing or committing")
+	idle := flag.Duration("idle", 5*time.Second, "stop after waiting this long for the next message")
+	flag.Parse()
+
+	brokerConfig := broker.Config{
+		Driver:        getEnv("BROKER_DRIVER", broker.DriverKafka),
+		KafkaBrokers:  strings.Split(getEnv("KAFKA_BROKERS", "kafka:9092"), ","),
+		RedisAddr:     getEnv("REDIS_ADDR", "redis:6379"),
+		RedisPassword: os.Getenv("REDIS_PASSWORD"),
+	}
+
+	subscriber, err := broker.NewSubscriber(brokerConfig, *dlqTopic, *groupID)
+	if err != nil {
+		slog.Error("Failed to subscribe to dead letter topic", "error", err)
+		os.Exit(1)
+	}
+	defer subscriber.Close()
+
+	publisher, err := broker.NewPublisher(brokerConfig)
+	if err != nil {
+		slog.Error("Failed to create publisher", "error", err)
+		os.Exit(1)
+	}
+	defer publisher.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	started := time.Now()
+	var redriven, kept, skipped int
+
+	for *limit == 0 || redriven < *limit {
+		fetchCtx, cancel := context.WithTimeout(ctx, *idle)
+		m, err := subscriber.Fetch(fetchCtx)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				break
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			slog.Error("Failed to read dead letter", "error", err)
+			os.Exit(1)
+		}
+
+		// Left uncommitted, so a later run still sees it. Partitions are
+		// append-only, so nothing older follows on the same partition.
+		if m.Time.After(started) {
+			skipped++
+			continue
+		}
+
+		poison := m.Headers[consumer.HeaderDLQPoison] == "true"
+		out := consumer.Redrive(m, topic)
+		if poison && !*includePoison {
+			// Re-appended rather than left behind, so the offset can move on
+			out = m
+			out.Topic = *dlqTopic
+			out.Time = time.Now()
+		}
+
+		env, _ := events.Parse(m.Headers)
+		slog.Info("Dead letter",
+			"key", m.Key,
+			"event_id", env.ID,
+			"type", env.Type,
+			"reason", m.Headers[consumer.HeaderDLQReason],
+			"poison", poison,
+			"to", out.Topic)
+
+		if !*dryRun {
+			if err := publisher.Publish(ctx, out); err != nil {
+				slog.Error("Failed to publish message, stopping", "topic", out.Topic, "error", err)
+				break
+			}
+			if err := subscriber.Commit(ctx, m); err != nil {
+				slog.Error("Failed to commit dead letter, stopping", "error", err)
+				break
+			}
+		}
+
+		if out.Topic == *dlqTopic {
+			kept++
+		} else {
+			redriven++
+		}
+	}
+
+	slog.Info("DLQ re-drive finished", "redriven", redriven, "kept", kept, "skipped", skipped, "dry_run", *dryRun)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
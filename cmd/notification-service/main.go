@@ -2,15 +2,37 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
-	"github.com/kiribu/jwt-practice/internal/notification/kafka"
+	"github.com/kiribu/jwt-practice/config"
+	"github.com/kiribu/jwt-practice/internal/notification/channel"
+	"github.com/kiribu/jwt-practice/internal/notification/client"
+	"github.com/kiribu/jwt-practice/internal/notification/consumer"
+	"github.com/kiribu/jwt-practice/internal/notification/dedupe"
+	"github.com/kiribu/jwt-practice/internal/notification/delivery"
+	"github.com/kiribu/jwt-practice/internal/notification/digest"
+	notificationgrpc "github.com/kiribu/jwt-practice/internal/notification/grpc"
+	"github.com/kiribu/jwt-practice/internal/notification/grpc/pb"
+	"github.com/kiribu/jwt-practice/internal/notification/service"
+	"github.com/kiribu/jwt-practice/internal/notification/storage"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"github.com/kiribu/jwt-practice/pkg/live"
 	"github.com/kiribu/jwt-practice/pkg/logger"
+	"github.com/kiribu/jwt-practice/pkg/redis"
+	"google.golang.org/grpc"
 )
 
 func init() {
@@ -23,27 +45,268 @@ func main() {
 
 	slog.Info("Starting Notification Service...")
 
+	dbConfig := config.LoadDatabaseConfig()
+
+	db, err := config.ConnectDatabase(dbConfig)
+	if err != nil {
+		slog.Error("DB connection error", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+
 	brokersEnv := getEnv("KAFKA_BROKERS", "kafka:9092")
-	brokers := strings.Split(brokersEnv, ",")
+	brokerConfig := broker.Config{
+		Driver:        getEnv("BROKER_DRIVER", broker.DriverKafka),
+		KafkaBrokers:  strings.Split(brokersEnv, ","),
+		RedisAddr:     getEnv("REDIS_ADDR", "redis:6379"),
+		RedisPassword: os.Getenv("REDIS_PASSWORD"),
+	}
 
-	// Consumer for Notifications (for NotificationWorker)
+	// Failed notifications wait in the retry topics, then land in the DLQ
 	topic := getEnv("KAFKA_TOPIC_NOTIFICATIONS", "notifications")
 	groupID := getEnv("KAFKA_GROUP_ID", "notification-workers")
+	retryTiers := []consumer.RetryTier{
+		{Topic: getEnv("KAFKA_TOPIC_NOTIFICATIONS_RETRY_1M", topic+"-retry-1m"), Delay: time.Minute},
+		{Topic: getEnv("KAFKA_TOPIC_NOTIFICATIONS_RETRY_10M", topic+"-retry-10m"), Delay: 10 * time.Minute},
+	}
+	dlqTopic := getEnv("KAFKA_TOPIC_NOTIFICATIONS_DLQ", topic+"-dlq")
+
+	subscribers := make([]broker.Subscriber, 0, len(retryTiers)+1)
+	for _, t := range append([]string{topic}, retryTopics(retryTiers)...) {
+		subscriber, err := broker.NewSubscriber(brokerConfig, t, groupID)
+		if err != nil {
+			slog.Error("Failed to subscribe to notifications topic", "topic", t, "error", err)
+			os.Exit(1)
+		}
+		subscribers = append(subscribers, subscriber)
+	}
+
+	registry := channel.NewRegistry()
+	registry.Register(channel.NewConsoleChannel(os.Stdout))
+
+	if path := os.Getenv("NOTIFICATION_FILE_PATH"); path != "" {
+		fileChannel, err := channel.NewFileChannel(path)
+		if err != nil {
+			slog.Error("Failed to set up file channel", "error", err)
+			os.Exit(1)
+		}
+		defer fileChannel.Close()
+		registry.Register(fileChannel)
+	}
+
+	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+		sender, err := channel.NewSMTPSender(channel.SMTPConfig{
+			Host:               smtpHost,
+			Port:               getEnv("SMTP_PORT", "587"),
+			Username:           os.Getenv("SMTP_USERNAME"),
+			Password:           os.Getenv("SMTP_PASSWORD"),
+			From:               getEnv("SMTP_FROM", "reminders@localhost"),
+			TLSMode:            getEnv("SMTP_TLS_MODE", channel.SMTPTLSStartTLS),
+			InsecureSkipVerify: os.Getenv("SMTP_TLS_INSECURE_SKIP_VERIFY") == "true",
+		})
+		if err != nil {
+			slog.Error("Invalid SMTP configuration", "error", err)
+			os.Exit(1)
+		}
+		defer sender.Close()
+
+		templates, err := channel.LoadEmailTemplates(os.Getenv("EMAIL_TEMPLATES_DIR"))
+		if err != nil {
+			slog.Error("Failed to load email templates", "error", err)
+			os.Exit(1)
+		}
+
+		verifyURL := getEnv("EMAIL_VERIFY_URL", "http://localhost:8080/auth/email/verify")
+		resetURL := getEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password")
+		registry.Register(channel.NewEmailChannel(sender, templates, verifyURL, resetURL))
+	}
+
+	webhookChannel := channel.NewWebhookChannel(store, nil, channel.WebhookConfig{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxFailures:    5,
+	})
+	registry.Register(webhookChannel)
+
+	publisher, err := broker.NewPublisher(brokerConfig)
+	if err != nil {
+		slog.Error("Failed to create event publisher", "error", err)
+		os.Exit(1)
+	}
+	defer publisher.Close()
+
+	contentType, err := events.ParseContentType(getEnv("EVENT_CONTENT_TYPE", events.ContentTypeJSON))
+	if err != nil {
+		slog.Error("Invalid EVENT_CONTENT_TYPE", "error", err)
+		os.Exit(1)
+	}
+
+	// Every instance caches preferences, so every instance reads all updates
+	preferencesTopic := getEnv("KAFKA_TOPIC_PREFERENCES", "notification_preferences")
+	hostname, _ := os.Hostname()
+	preferencesSubscriber, err := broker.NewSubscriber(brokerConfig, preferencesTopic, "notification-preferences-"+hostname)
+	if err != nil {
+		slog.Error("Failed to subscribe to preferences topic", "error", err)
+		os.Exit(1)
+	}
+
+	lifecycleTopic := getEnv("KAFKA_TOPIC_LIFECYCLE", "reminder_lifecycle")
+	eventPublisher := service.NewEventPublisher(publisher, preferencesTopic, lifecycleTopic, contentType)
+	channels := strings.Split(getEnv("NOTIFICATION_CHANNELS", "console,webhook"), ",")
+	preferencesService := service.NewPreferencesService(store, eventPublisher, registry.Names(), channels)
+
+	authServiceAddr := getEnv("AUTH_SERVICE_ADDR", "auth-service:50051")
+	authClient, err := client.NewAuthClient(authServiceAddr)
+	if err != nil {
+		slog.Error("Failed to create Auth Service client", "error", err)
+		os.Exit(1)
+	}
+	defer authClient.Close()
+
+	dedupeWindow, err := time.ParseDuration(getEnv("DEDUPE_WINDOW", "24h"))
+	if err != nil {
+		slog.Error("Invalid DEDUPE_WINDOW", "error", err)
+		os.Exit(1)
+	}
+
+	// Redis carries live pushes to the gateways and, by default, delivery records
+	redisClient, err := redis.NewRedisClient(brokerConfig.RedisAddr, brokerConfig.RedisPassword)
+	if err != nil {
+		slog.Error("Failed to connect to Redis", "error", err)
+		os.Exit(1)
+	}
+	defer redisClient.Close()
+
+	var dedupeStore dedupe.Store
+	switch driver := getEnv("DEDUPE_DRIVER", dedupe.DriverRedis); driver {
+	case dedupe.DriverRedis:
+		dedupeStore = dedupe.NewRedisStore(redisClient, dedupeWindow)
+	case dedupe.DriverMemory:
+		dedupeStore = dedupe.NewMemoryStore(dedupeWindow)
+	default:
+		slog.Error("Unknown DEDUPE_DRIVER", "driver", driver)
+		os.Exit(1)
+	}
+
+	inboxService := service.NewInboxService(store, live.NewPublisher(redisClient))
+	pipeline := delivery.NewPipeline(registry, delivery.NewPreferenceRouter(preferencesService), authClient, eventPublisher, dedupeStore, inboxService)
+	slog.Info("Notification channels", "registered", registry.Names(), "default", channels)
+
+	retrier := consumer.NewRetrier(publisher, dlqTopic, retryTiers...)
+
+	reminderServiceAddr := getEnv("REMINDER_SERVICE_ADDR", "reminder-service:50052")
+	reminderClient, err := client.NewReminderClient(reminderServiceAddr)
+	if err != nil {
+		slog.Error("Failed to create Reminder Service client", "error", err)
+		os.Exit(1)
+	}
+	defer reminderClient.Close()
+
+	digestConfig, err := loadDigestConfig()
+	if err != nil {
+		slog.Error("Invalid digest configuration", "error", err)
+		os.Exit(1)
+	}
+	digestScheduler := digest.NewScheduler(store, reminderClient, authClient, pipeline, digestConfig)
 
-	consumer := kafka.NewConsumer(brokers, topic, groupID)
+	workers, err := strconv.Atoi(getEnv("NOTIFICATION_WORKERS", "8"))
+	if err != nil || workers < 1 {
+		slog.Error("NOTIFICATION_WORKERS must be a positive number", "value", os.Getenv("NOTIFICATION_WORKERS"))
+		os.Exit(1)
+	}
+
+	drainTimeout, err := time.ParseDuration(getEnv("SHUTDOWN_DRAIN_TIMEOUT", "20s"))
+	if err != nil {
+		slog.Error("Invalid SHUTDOWN_DRAIN_TIMEOUT", "error", err)
+		os.Exit(1)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go consumer.Start(ctx)
+	go digestScheduler.Start(ctx)
+
+	var consumers sync.WaitGroup
+	for _, subscriber := range subscribers {
+		c := consumer.NewConsumer(subscriber, pipeline, retrier, workers)
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			c.Start(ctx, drainTimeout)
+		}()
+	}
+	go consumer.NewPreferencesConsumer(preferencesSubscriber, preferencesService).Start(ctx)
+
+	webhookService := service.NewWebhookService(store, webhookChannel)
+	notificationServer := notificationgrpc.NewNotificationServer(webhookService, preferencesService, inboxService)
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(i18n.UnaryServerInterceptor()))
+	pb.RegisterNotificationServiceServer(grpcServer, notificationServer)
+
+	port := getEnv("NOTIFICATION_GRPC_PORT", "50054")
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		slog.Error("Failed to start listener", "error", err)
+		os.Exit(1)
+	}
+
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			slog.Error("gRPC server error", "error", err)
+			os.Exit(1)
+		}
+	}()
 
-	slog.Info("Notification Service started", "topic", topic)
+	slog.Info("Notification Service started", "topic", topic, "dlq_topic", dlqTopic, "grpc_port", port)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	slog.Info("Shutting down Notification Service...")
 	cancel()
+
+	// Consumers enforce drainTimeout themselves; wait for their last commits
+	consumers.Wait()
+	grpcServer.GracefulStop()
+}
+
+func loadDigestConfig() (digest.Config, error) {
+	interval, err := time.ParseDuration(getEnv("DIGEST_INTERVAL", "5m"))
+	if err != nil {
+		return digest.Config{}, fmt.Errorf("DIGEST_INTERVAL: %w", err)
+	}
+
+	hour, err := strconv.Atoi(getEnv("DIGEST_HOUR", "8"))
+	if err != nil || hour < 0 || hour > 23 {
+		return digest.Config{}, errors.New("DIGEST_HOUR must be an hour from 0 to 23")
+	}
+
+	weeklyDay := -1
+	name := getEnv("DIGEST_WEEKLY_DAY", "monday")
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), name) {
+			weeklyDay = int(d)
+		}
+	}
+	if weeklyDay < 0 {
+		return digest.Config{}, fmt.Errorf("DIGEST_WEEKLY_DAY must be a day of the week, got %q", name)
+	}
+
+	return digest.Config{
+		Interval:  interval,
+		Hour:      hour,
+		WeeklyDay: time.Weekday(weeklyDay),
+	}, nil
+}
+
+func retryTopics(tiers []consumer.RetryTier) []string {
+	topics := make([]string, len(tiers))
+	for i, tier := range tiers {
+		topics[i] = tier.Topic
+	}
+	return topics
 }
 
 func getEnv(key, defaultValue string) string {
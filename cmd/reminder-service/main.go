@@ -14,10 +14,12 @@ import (
 	"github.com/kiribu/jwt-practice/config"
 	remindergrpc "github.com/kiribu/jwt-practice/internal/reminder/grpc"
 	"github.com/kiribu/jwt-practice/internal/reminder/grpc/pb"
-	"github.com/kiribu/jwt-practice/internal/reminder/kafka"
 	"github.com/kiribu/jwt-practice/internal/reminder/service"
 	"github.com/kiribu/jwt-practice/internal/reminder/storage"
 	"github.com/kiribu/jwt-practice/internal/reminder/worker"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
 	"github.com/kiribu/jwt-practice/pkg/logger"
 	"google.golang.org/grpc"
 )
@@ -44,30 +46,38 @@ func main() {
 	store := storage.NewPostgresStorage(db)
 
 	brokersEnv := getEnv("KAFKA_BROKERS", "kafka:9092")
-	brokers := strings.Split(brokersEnv, ",")
+	brokerConfig := broker.Config{
+		Driver:        getEnv("BROKER_DRIVER", broker.DriverKafka),
+		KafkaBrokers:  strings.Split(brokersEnv, ","),
+		RedisAddr:     getEnv("REDIS_ADDR", "redis:6379"),
+		RedisPassword: os.Getenv("REDIS_PASSWORD"),
+	}
 
-	// Producer for Notifications (for NotificationWorker)
-	notificationTopic := getEnv("KAFKA_TOPIC_NOTIFICATIONS", "notifications")
-	notificationProducer := kafka.NewProducer(brokers, notificationTopic)
+	publisher, err := broker.NewPublisher(brokerConfig)
+	if err != nil {
+		slog.Error("Failed to create message publisher", "error", err)
+		os.Exit(1)
+	}
 	defer func() {
-		if err := notificationProducer.Close(); err != nil {
-			slog.Error("Failed to close notification producer", "error", err)
+		if err := publisher.Close(); err != nil {
+			slog.Error("Failed to close message publisher", "error", err)
 		}
 	}()
 
-	// Producer for Lifecycle Events (for OutboxWorker)
+	// Notifications go to notification-service, lifecycle events to analytics-service
+	notificationTopic := getEnv("KAFKA_TOPIC_NOTIFICATIONS", "notifications")
 	lifecycleTopic := getEnv("KAFKA_TOPIC_LIFECYCLE", "reminder_lifecycle")
-	lifecycleProducer := kafka.NewProducer(brokers, lifecycleTopic)
-	defer func() {
-		if err := lifecycleProducer.Close(); err != nil {
-			slog.Error("Failed to close lifecycle producer", "error", err)
-		}
-	}()
+
+	contentType, err := events.ParseContentType(getEnv("EVENT_CONTENT_TYPE", events.ContentTypeJSON))
+	if err != nil {
+		slog.Error("Invalid EVENT_CONTENT_TYPE", "error", err)
+		os.Exit(1)
+	}
 
 	reminderService := service.NewReminderService(store)
 	reminderServer := remindergrpc.NewReminderServer(reminderService)
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(i18n.UnaryServerInterceptor()))
 	pb.RegisterReminderServiceServer(grpcServer, reminderServer)
 
 	port := getEnv("REMINDER_GRPC_PORT", "50052")
@@ -86,8 +96,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	outboxPollStr := getEnv("OUTBOX_POLL_INTERVAL", "30s")
+	outboxPoll, err := time.ParseDuration(outboxPollStr)
+	if err != nil {
+		slog.Error("Invalid OUTBOX_POLL_INTERVAL", "error", err)
+		os.Exit(1)
+	}
+
+	outboxListener := storage.NewOutboxListener(dbConfig.ConnectionString())
+
 	notificationWorker := worker.NewNotificationWorker(store, interval)
-	outboxWorker := worker.NewOutboxWorker(store, lifecycleProducer, notificationProducer, 500*time.Millisecond)
+	outboxWorker := worker.NewOutboxWorker(store, outboxListener, publisher, lifecycleTopic, notificationTopic, contentType, outboxPoll)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -0,0 +1,91 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kiribu/jwt-practice/internal/analytics/service"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"github.com/kiribu/jwt-practice/pkg/events/pb"
+)
+
+type Consumer struct {
+	subscriber broker.Subscriber
+	service    *service.AnalyticsService
+}
+
+func NewConsumer(subscriber broker.Subscriber, service *service.AnalyticsService) *Consumer {
+	return &Consumer{
+		subscriber: subscriber,
+		service:    service,
+	}
+}
+
+func (c *Consumer) Start(ctx context.Context) {
+	slog.Info("Lifecycle consumer started...")
+
+	for {
+		m, err := c.subscriber.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				slog.Info("Stopping lifecycle consumer...")
+				return
+			}
+			slog.Error("Error reading message", "error", err)
+			time.Sleep(1 * time.Second)
+			continue
+		}
+
+		event, err := decodeEvent(m)
+		if err != nil {
+			// Rejected for good: retrying a message we cannot read will not help
+			slog.Error("Rejecting lifecycle message", "error", err, "offset", m.Offset)
+			if err := c.subscriber.Commit(ctx, m); err != nil {
+				slog.Error("Error committing message", "error", err)
+			}
+			continue
+		}
+
+		processCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err = c.service.ProcessEvent(processCtx, event)
+		cancel()
+
+		if err != nil {
+			slog.Error("Error processing event", "error", err)
+		}
+		if err := c.subscriber.Commit(ctx, m); err != nil {
+			slog.Error("Error committing message", "error", err)
+		}
+	}
+}
+
+func (c *Consumer) Close() error {
+	return c.subscriber.Close()
+}
+
+func decodeEvent(m broker.Message) (models.LifecycleEvent, error) {
+	env, err := events.Parse(m.Headers)
+	if err != nil {
+		return models.LifecycleEvent{}, err
+	}
+
+	switch env.Type {
+	case events.TypeReminderNotificationDelivered, events.TypeReminderNotificationFailed:
+		var data pb.NotificationDelivery
+		if err := env.Unmarshal(m.Value, &data); err != nil {
+			return models.LifecycleEvent{}, fmt.Errorf("failed to decode %s: %w", env.Type, err)
+		}
+		return events.DeliveryFromProto(env, &data)
+
+	default:
+		var data pb.ReminderLifecycle
+		if err := env.Unmarshal(m.Value, &data); err != nil {
+			return models.LifecycleEvent{}, fmt.Errorf("failed to decode %s: %w", env.Type, err)
+		}
+		return events.LifecycleFromProto(env, &data)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"log/slog"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/kiribu/jwt-practice/internal/analytics/storage"
 	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/events"
 )
 
 type AnalyticsService struct {
@@ -37,13 +39,17 @@ func (s *AnalyticsService) ProcessEvent(ctx context.Context, event models.Lifecy
 	}
 
 	switch event.EventType {
-	case "created":
+	case events.TypeReminderCreated:
 		err = s.storage.IncrementCreated(ctx, tx, event.UserID, event.Timestamp)
-	case "updated":
+	case events.TypeReminderUpdated:
 		err = nil // No-op for updated
-	case "notification_sent":
-		err = s.storage.IncrementCompleted(ctx, tx, event.UserID, event.Timestamp)
-	case "deleted":
+	case events.TypeReminderNotificationSent:
+		err = nil // Completion is counted on the first delivery receipt
+	case events.TypeReminderNotificationDelivered:
+		err = s.processDelivered(ctx, tx, event)
+	case events.TypeReminderNotificationFailed:
+		err = s.storage.IncrementFailed(ctx, tx, event.UserID, event.Timestamp)
+	case events.TypeReminderDeleted:
 		err = s.storage.IncrementDeleted(ctx, tx, event.UserID, event.Timestamp)
 	default:
 		slog.Warn("Unknown event type", "type", event.EventType)
@@ -61,6 +67,25 @@ func (s *AnalyticsService) ProcessEvent(ctx context.Context, event models.Lifecy
 	return tx.Commit()
 }
 
+// processDelivered records a successful delivery and completes the reminder
+// on the first one, so extra channels and redeliveries do not count twice.
+func (s *AnalyticsService) processDelivered(ctx context.Context, tx *sqlx.Tx, event models.LifecycleEvent) error {
+	var latencyMs int64
+	if event.Delivery != nil {
+		latencyMs = max(event.Delivery.LatencyMs, 0)
+	}
+
+	if err := s.storage.IncrementDelivered(ctx, tx, event.UserID, latencyMs, event.Timestamp); err != nil {
+		return err
+	}
+
+	first, err := s.storage.MarkReminderDelivered(ctx, tx, event.ReminderID, event.UserID, event.Timestamp)
+	if err != nil || !first {
+		return err
+	}
+	return s.storage.IncrementCompleted(ctx, tx, event.UserID, event.Timestamp)
+}
+
 func (s *AnalyticsService) GetUserStats(ctx context.Context, userID uuid.UUID) (*models.UserStatistics, error) {
 	stats, err := s.storage.GetUserStats(ctx, userID)
 	if err != nil {
@@ -18,6 +18,9 @@ type AnalyticsStorage interface {
 	IncrementCreated(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, timestamp time.Time) error
 	IncrementCompleted(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, timestamp time.Time) error
 	IncrementDeleted(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, timestamp time.Time) error
+	IncrementDelivered(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, latencyMs int64, timestamp time.Time) error
+	IncrementFailed(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, timestamp time.Time) error
+	MarkReminderDelivered(ctx context.Context, tx *sqlx.Tx, reminderID, userID uuid.UUID, timestamp time.Time) (bool, error)
 }
 
 type PostgresStorage struct {
@@ -104,3 +107,45 @@ func (s *PostgresStorage) IncrementDeleted(ctx context.Context, tx *sqlx.Tx, use
 	_, err := tx.ExecContext(ctx, query, userID, timestamp)
 	return err
 }
+
+func (s *PostgresStorage) IncrementDelivered(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, latencyMs int64, timestamp time.Time) error {
+	query := `
+		UPDATE analytics.user_statistics SET
+			total_notifications_delivered = total_notifications_delivered + 1,
+			total_delivery_latency_ms = total_delivery_latency_ms + $2,
+			avg_delivery_latency_ms = (total_delivery_latency_ms + $2) / (total_notifications_delivered + 1),
+			max_delivery_latency_ms = GREATEST(max_delivery_latency_ms, $2),
+			last_activity_at = $3,
+			updated_at = NOW()
+		WHERE user_id = $1
+	`
+	_, err := tx.ExecContext(ctx, query, userID, latencyMs, timestamp)
+	return err
+}
+
+func (s *PostgresStorage) IncrementFailed(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, timestamp time.Time) error {
+	query := `
+		UPDATE analytics.user_statistics SET
+			total_notifications_failed = total_notifications_failed + 1,
+			last_activity_at = $2,
+			updated_at = NOW()
+		WHERE user_id = $1
+	`
+	_, err := tx.ExecContext(ctx, query, userID, timestamp)
+	return err
+}
+
+// MarkReminderDelivered reports whether this is the reminder's first delivery.
+func (s *PostgresStorage) MarkReminderDelivered(ctx context.Context, tx *sqlx.Tx, reminderID, userID uuid.UUID, timestamp time.Time) (bool, error) {
+	query := `
+		INSERT INTO analytics.delivered_reminders (reminder_id, user_id, first_delivered_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (reminder_id) DO NOTHING
+	`
+	result, err := tx.ExecContext(ctx, query, reminderID, userID, timestamp)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
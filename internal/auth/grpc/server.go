@@ -2,9 +2,13 @@ package authgrpc
 
 import (
 	"context"
+	"errors"
 
+	"github.com/google/uuid"
 	"github.com/kiribu/jwt-practice/internal/auth/grpc/pb"
 	"github.com/kiribu/jwt-practice/internal/auth/service"
+	"github.com/kiribu/jwt-practice/internal/auth/storage"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -21,12 +25,12 @@ func NewAuthServer(svc *service.AuthService) *AuthServer {
 
 func (s *AuthServer) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
 	if req.Username == "" || req.Password == "" {
-		return nil, status.Error(codes.InvalidArgument, "username and password are required")
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.credentials_required"))
 	}
 
 	user, err := s.service.Register(req.Username, req.Password)
 	if err != nil {
-		return nil, status.Error(codes.AlreadyExists, err.Error())
+		return nil, status.Error(codes.AlreadyExists, localize(ctx, err))
 	}
 
 	return &pb.RegisterResponse{
@@ -38,29 +42,41 @@ func (s *AuthServer) Register(ctx context.Context, req *pb.RegisterRequest) (*pb
 
 func (s *AuthServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
 	if req.Username == "" || req.Password == "" {
-		return nil, status.Error(codes.InvalidArgument, "username and password are required")
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.credentials_required"))
 	}
 
-	tokens, err := s.service.Login(req.Username, req.Password)
+	result, err := s.service.Login(ctx, req.Username, req.Password, storage.Device{UserAgent: req.UserAgent, IP: req.Ip})
 	if err != nil {
-		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		return nil, status.Error(codes.Unauthenticated, translate(ctx, "auth.invalid_credentials"))
 	}
 
-	return &pb.LoginResponse{
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
-		TokenType:    tokens.TokenType,
-	}, nil
+	if result.Tokens == nil {
+		return &pb.LoginResponse{MfaRequired: true, MfaToken: result.MFAToken}, nil
+	}
+	return toProtoLogin(result.Tokens), nil
+}
+
+func (s *AuthServer) VerifyMFA(ctx context.Context, req *pb.VerifyMFARequest) (*pb.LoginResponse, error) {
+	if req.MfaToken == "" || req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.mfa_code_required"))
+	}
+
+	tokens, err := s.service.VerifyMFA(ctx, req.MfaToken, req.Code, storage.Device{UserAgent: req.UserAgent, IP: req.Ip})
+	if err != nil {
+		return nil, mfaStatus(ctx, err)
+	}
+
+	return toProtoLogin(tokens), nil
 }
 
 func (s *AuthServer) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.RefreshResponse, error) {
 	if req.RefreshToken == "" {
-		return nil, status.Error(codes.InvalidArgument, "refresh_token is required")
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.refresh_token_required"))
 	}
 
-	tokens, err := s.service.Refresh(req.RefreshToken)
+	tokens, err := s.service.Refresh(ctx, req.RefreshToken, storage.Device{UserAgent: req.UserAgent, IP: req.Ip})
 	if err != nil {
-		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+		return nil, status.Error(codes.Unauthenticated, translate(ctx, "auth.invalid_refresh_token"))
 	}
 
 	return &pb.RefreshResponse{
@@ -78,7 +94,7 @@ func (s *AuthServer) ValidateToken(ctx context.Context, req *pb.ValidateTokenReq
 		}, nil
 	}
 
-	username, userID, err := s.service.ValidateToken(ctx, req.AccessToken)
+	info, err := s.service.ValidateToken(ctx, req.AccessToken)
 	if err != nil {
 		return &pb.ValidateTokenResponse{
 			Valid: false,
@@ -87,19 +103,21 @@ func (s *AuthServer) ValidateToken(ctx context.Context, req *pb.ValidateTokenReq
 	}
 
 	return &pb.ValidateTokenResponse{
-		Valid:    true,
-		Username: username,
-		UserId:   userID.String(),
+		Valid:     true,
+		Username:  info.Username,
+		UserId:    info.UserID.String(),
+		SessionId: info.SessionID,
+		ExpiresAt: info.ExpiresAt.Unix(),
 	}, nil
 }
 
 func (s *AuthServer) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
 	if req.Token == "" {
-		return nil, status.Error(codes.InvalidArgument, "token is required")
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.token_required"))
 	}
 
 	if err := s.service.Logout(ctx, req.Token); err != nil {
-		return nil, status.Error(codes.Internal, "failed to logout")
+		return nil, status.Error(codes.Internal, translate(ctx, "auth.logout_failed"))
 	}
 
 	return &pb.LogoutResponse{Success: true}, nil
@@ -115,9 +133,347 @@ func (s *AuthServer) GetProfile(ctx context.Context, req *pb.GetProfileRequest)
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	return &pb.UserResponse{
-		Id:        user.ID.String(),
-		Username:  user.Username,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	return toProtoUser(user), nil
+}
+
+func (s *AuthServer) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UserResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	user, err := s.service.UpdateTimezone(ctx, userID, req.Timezone)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, localize(ctx, err))
+	}
+
+	return toProtoUser(user), nil
+}
+
+func (s *AuthServer) SetEmail(ctx context.Context, req *pb.SetEmailRequest) (*pb.SetEmailResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	if err := s.service.SetEmail(ctx, userID, req.Email); err != nil {
+		if errors.Is(err, storage.ErrEmailTaken) {
+			return nil, status.Error(codes.AlreadyExists, localize(ctx, err))
+		}
+		return nil, status.Error(codes.InvalidArgument, localize(ctx, err))
+	}
+
+	return &pb.SetEmailResponse{
+		Success: true,
+		Message: translate(ctx, "auth.verification_sent"),
 	}, nil
 }
+
+func (s *AuthServer) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.token_required"))
+	}
+
+	if err := s.service.VerifyEmail(ctx, req.Token); err != nil {
+		if errors.Is(err, storage.ErrVerificationToken) {
+			return nil, status.Error(codes.InvalidArgument, localize(ctx, err))
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.VerifyEmailResponse{
+		Success: true,
+		Message: translate(ctx, "auth.email_verified"),
+	}, nil
+}
+
+func (s *AuthServer) GetUserContact(ctx context.Context, req *pb.GetUserContactRequest) (*pb.UserContactResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	contact, err := s.service.GetUserContact(ctx, userID)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return &pb.UserContactResponse{
+		UserId:        contact.UserID.String(),
+		Username:      contact.Username,
+		Email:         contact.Email,
+		EmailVerified: contact.EmailVerified,
+		Timezone:      contact.Timezone,
+	}, nil
+}
+
+func (s *AuthServer) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	sessions, err := s.service.ListSessions(userID, req.CurrentSessionId)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &pb.ListSessionsResponse{Sessions: make([]*pb.SessionResponse, len(sessions))}
+	for i, session := range sessions {
+		resp.Sessions[i] = &pb.SessionResponse{
+			Id:         session.ID.String(),
+			UserAgent:  session.UserAgent,
+			Ip:         session.IP,
+			CreatedAt:  session.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			LastUsedAt: session.LastUsedAt.Format("2006-01-02T15:04:05Z07:00"),
+			Current:    session.Current,
+		}
+	}
+	return resp, nil
+}
+
+func (s *AuthServer) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+	sessionID, err := uuid.Parse(req.SessionId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.invalid_session_id"))
+	}
+
+	if err := s.service.RevokeSession(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			return nil, status.Error(codes.NotFound, localize(ctx, err))
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.RevokeSessionResponse{
+		Success: true,
+		Message: translate(ctx, "auth.session_revoked"),
+	}, nil
+}
+
+func (s *AuthServer) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokeAllSessionsResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	revoked, err := s.service.RevokeAllSessions(ctx, userID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.RevokeAllSessionsResponse{
+		Success: true,
+		Message: translate(ctx, "auth.sessions_revoked"),
+		Revoked: int32(revoked),
+	}, nil
+}
+
+func (s *AuthServer) GetJWKS(ctx context.Context, req *pb.GetJWKSRequest) (*pb.JWKSResponse, error) {
+	jwks := s.service.JWKS()
+
+	resp := &pb.JWKSResponse{Keys: make([]*pb.JSONWebKey, len(jwks.Keys))}
+	for i, k := range jwks.Keys {
+		resp.Keys[i] = &pb.JSONWebKey{
+			Kty: k.Kty,
+			Kid: k.Kid,
+			Alg: k.Alg,
+			Use: k.Use,
+			Crv: k.Crv,
+			X:   k.X,
+			N:   k.N,
+			E:   k.E,
+		}
+	}
+	return resp, nil
+}
+
+func (s *AuthServer) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.PasswordResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.passwords_required"))
+	}
+
+	if err := s.service.ChangePassword(ctx, userID, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, service.ErrCurrentPassword) {
+			return nil, status.Error(codes.PermissionDenied, localize(ctx, err))
+		}
+		if isUserError(err) {
+			return nil, status.Error(codes.InvalidArgument, localize(ctx, err))
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.PasswordResponse{
+		Success: true,
+		Message: translate(ctx, "auth.password_changed"),
+	}, nil
+}
+
+func (s *AuthServer) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.PasswordResponse, error) {
+	if req.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.email_required"))
+	}
+
+	if err := s.service.RequestPasswordReset(ctx, req.Email); err != nil {
+		if isUserError(err) {
+			return nil, status.Error(codes.InvalidArgument, localize(ctx, err))
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.PasswordResponse{
+		Success: true,
+		Message: translate(ctx, "auth.password_reset_sent"),
+	}, nil
+}
+
+func (s *AuthServer) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.PasswordResponse, error) {
+	if req.Token == "" {
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.token_required"))
+	}
+	if err := s.service.ResetPassword(ctx, req.Token, req.NewPassword); err != nil {
+		if isUserError(err) {
+			return nil, status.Error(codes.InvalidArgument, localize(ctx, err))
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.PasswordResponse{
+		Success: true,
+		Message: translate(ctx, "auth.password_changed"),
+	}, nil
+}
+
+func (s *AuthServer) EnrollTOTP(ctx context.Context, req *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	enrollment, err := s.service.EnrollTOTP(ctx, userID)
+	if err != nil {
+		return nil, mfaStatus(ctx, err)
+	}
+
+	return &pb.EnrollTOTPResponse{
+		Secret: enrollment.Secret,
+		Uri:    enrollment.URI,
+	}, nil
+}
+
+func (s *AuthServer) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.RecoveryCodesResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+	if req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.mfa_code_required"))
+	}
+
+	recoveryCodes, err := s.service.ConfirmTOTP(ctx, userID, req.Code)
+	if err != nil {
+		return nil, mfaStatus(ctx, err)
+	}
+
+	return &pb.RecoveryCodesResponse{
+		Message:       translate(ctx, "auth.mfa_enabled"),
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (s *AuthServer) DisableTOTP(ctx context.Context, req *pb.DisableTOTPRequest) (*pb.DisableTOTPResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+	if req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.mfa_code_required"))
+	}
+
+	if err := s.service.DisableTOTP(ctx, userID, req.Code); err != nil {
+		return nil, mfaStatus(ctx, err)
+	}
+
+	return &pb.DisableTOTPResponse{
+		Success: true,
+		Message: translate(ctx, "auth.mfa_disabled"),
+	}, nil
+}
+
+func (s *AuthServer) RegenerateRecoveryCodes(ctx context.Context, req *pb.RegenerateRecoveryCodesRequest) (*pb.RecoveryCodesResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+	if req.Code == "" {
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "auth.mfa_code_required"))
+	}
+
+	recoveryCodes, err := s.service.RegenerateRecoveryCodes(ctx, userID, req.Code)
+	if err != nil {
+		return nil, mfaStatus(ctx, err)
+	}
+
+	return &pb.RecoveryCodesResponse{
+		Message:       translate(ctx, "auth.recovery_codes_regenerated"),
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// mfaStatus maps the errors of the two-factor calls to gRPC codes.
+func mfaStatus(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrMFACode), errors.Is(err, service.ErrMFAToken):
+		return status.Error(codes.Unauthenticated, localize(ctx, err))
+	case errors.Is(err, service.ErrMFATooManyAttempts):
+		return status.Error(codes.ResourceExhausted, localize(ctx, err))
+	case errors.Is(err, storage.ErrMFAEnabled), errors.Is(err, storage.ErrMFANotEnabled):
+		return status.Error(codes.FailedPrecondition, localize(ctx, err))
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func toProtoLogin(tokens *service.TokenResponse) *pb.LoginResponse {
+	return &pb.LoginResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    tokens.TokenType,
+	}
+}
+
+func toProtoUser(user *service.UserResponse) *pb.UserResponse {
+	return &pb.UserResponse{
+		Id:            user.ID.String(),
+		Username:      user.Username,
+		CreatedAt:     user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Timezone:      user.Timezone,
+	}
+}
+
+// isUserError reports whether err is a localized message about the request,
+// as opposed to a failure of the service.
+func isUserError(err error) bool {
+	var e *i18n.Error
+	return errors.As(err, &e)
+}
+
+// translate renders a catalog message in the caller's language.
+func translate(ctx context.Context, key string, args ...any) string {
+	return i18n.T(i18n.FromContext(ctx), key, args...)
+}
+
+// localize renders err in the caller's language.
+func localize(ctx context.Context, err error) string {
+	return i18n.Message(i18n.FromContext(ctx), err)
+}
@@ -11,26 +11,37 @@ import (
 	"github.com/google/uuid"
 	"github.com/kiribu/jwt-practice/internal/auth/storage"
 	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"github.com/kiribu/jwt-practice/pkg/jwtkeys"
+	"github.com/kiribu/jwt-practice/pkg/revocation"
 	"github.com/kiribu/jwt-practice/utils"
 	"github.com/redis/go-redis/v9"
 )
 
 type AuthService struct {
-	store storage.Storage
-	redis *redis.Client
+	store  storage.Storage
+	redis  *redis.Client
+	keys   *jwtkeys.Keyset
+	events *EventPublisher
 }
 
-func NewAuthService(store storage.Storage, redisClient *redis.Client) *AuthService {
+func NewAuthService(store storage.Storage, redisClient *redis.Client, keys *jwtkeys.Keyset, publisher broker.Publisher, notificationTopic, contentType string) *AuthService {
 	return &AuthService{
-		store: store,
-		redis: redisClient,
+		store:  store,
+		redis:  redisClient,
+		keys:   keys,
+		events: NewEventPublisher(publisher, notificationTopic, contentType),
 	}
 }
 
 type UserResponse struct {
-	ID        uuid.UUID
-	Username  string
-	CreatedAt time.Time
+	ID            uuid.UUID
+	Username      string
+	Email         string
+	EmailVerified bool
+	Timezone      string
+	CreatedAt     time.Time
 }
 type TokenResponse struct {
 	AccessToken  string
@@ -38,6 +49,15 @@ type TokenResponse struct {
 	TokenType    string
 }
 
+// TokenInfo is what a valid access token says about its bearer.
+type TokenInfo struct {
+	Username string
+	UserID   uuid.UUID
+	// SessionID is empty for tokens issued before sessions existed
+	SessionID string
+	ExpiresAt time.Time
+}
+
 var (
 	usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]{3,255}$`)
 	passwordRegex = regexp.MustCompile(`^[a-zA-Z0-9!#$%*]{8,16}$`)
@@ -58,31 +78,60 @@ func (s *AuthService) Register(username, password string) (*UserResponse, error)
 		return nil, err
 	}
 
-	return &UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		CreatedAt: user.CreatedAt,
-	}, nil
+	return toUserResponse(user), nil
 }
 
-func (s *AuthService) Login(username, password string) (*TokenResponse, error) {
+// LoginResult is the outcome of a password check: tokens, or for users with
+// two-factor authentication a challenge to answer through VerifyMFA.
+type LoginResult struct {
+	Tokens *TokenResponse
+	// MFAToken is set instead of Tokens when a code is required
+	MFAToken string
+}
+
+// Login checks the password and starts a new session on the device the
+// credentials came from, unless the user has two-factor authentication on:
+// then the session only starts once VerifyMFA accepts a code.
+func (s *AuthService) Login(ctx context.Context, username, password string, device storage.Device) (*LoginResult, error) {
 	user, err := s.store.ValidatePassword(username, password)
 	if err != nil {
 		return nil, err
 	}
 
-	accessToken, err := utils.GenerateAccessToken(user.Username, user.ID.String())
+	enabled, err := s.mfaEnabled(user.ID)
 	if err != nil {
 		return nil, err
 	}
+	if enabled {
+		token, err := s.newMFAChallenge(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFAToken: token}, nil
+	}
 
+	tokens, err := s.startSession(user, device)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: tokens}, nil
+}
+
+// startSession issues the first token pair of a new session.
+func (s *AuthService) startSession(user *models.User, device storage.Device) (*TokenResponse, error) {
 	refreshToken, err := utils.GenerateRefreshToken()
 	if err != nil {
 		return nil, err
 	}
 
 	expiresAt := time.Now().Add(utils.RefreshTokenDuration)
-	if err := s.store.SaveRefreshToken(refreshToken, user.ID, expiresAt); err != nil {
+	session, err := s.store.CreateSession(user.ID, device, hashToken(refreshToken), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateAccessToken(s.keys, user.Username, user.ID.String(), session.ID.String())
+	if err != nil {
 		return nil, err
 	}
 
@@ -93,33 +142,41 @@ func (s *AuthService) Login(username, password string) (*TokenResponse, error) {
 	}, nil
 }
 
-func (s *AuthService) Refresh(refreshToken string) (*TokenResponse, error) {
-	userID, err := s.store.ValidateRefreshToken(refreshToken)
+// Refresh exchanges a refresh token for a new pair within the same session.
+// Presenting a token that was already exchanged revokes the session.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string, device storage.Device) (*TokenResponse, error) {
+	newRefreshToken, err := utils.GenerateRefreshToken()
 	if err != nil {
 		return nil, err
 	}
 
-	user, err := s.store.GetUserByID(userID)
+	expiresAt := time.Now().Add(utils.RefreshTokenDuration)
+	session, err := s.store.RotateRefreshToken(hashToken(refreshToken), hashToken(newRefreshToken), device, expiresAt)
 	if err != nil {
+		var reuse *storage.TokenReuseError
+		if errors.As(err, &reuse) {
+			slog.Warn("Security event: refresh token reuse detected, token family revoked",
+				"event", "refresh_token_reuse",
+				"user_id", reuse.UserID,
+				"family_id", reuse.FamilyID,
+				"rotated_at", reuse.RotatedAt)
+			if markErr := s.markSessionsRevoked(ctx, reuse.FamilyID); markErr != nil {
+				slog.Error("Failed to revoke access tokens of session", "session_id", reuse.FamilyID, "error", markErr)
+			}
+		}
 		return nil, err
 	}
 
-	accessToken, err := utils.GenerateAccessToken(user.Username, user.ID.String())
+	user, err := s.store.GetUserByID(session.UserID)
 	if err != nil {
 		return nil, err
 	}
 
-	newRefreshToken, err := utils.GenerateRefreshToken()
+	accessToken, err := utils.GenerateAccessToken(s.keys, user.Username, user.ID.String(), session.ID.String())
 	if err != nil {
 		return nil, err
 	}
 
-	s.store.DeleteRefreshToken(refreshToken)
-	expiresAt := time.Now().Add(utils.RefreshTokenDuration)
-	if err := s.store.SaveRefreshToken(newRefreshToken, user.ID, expiresAt); err != nil {
-		return nil, err
-	}
-
 	return &TokenResponse{
 		AccessToken:  accessToken,
 		RefreshToken: newRefreshToken,
@@ -127,28 +184,30 @@ func (s *AuthService) Refresh(refreshToken string) (*TokenResponse, error) {
 	}, nil
 }
 
-func (s *AuthService) ValidateToken(ctx context.Context, token string) (string, uuid.UUID, error) {
-	// Check Blacklist
-	val, err := s.redis.Get(ctx, "blacklist:"+token).Result()
-	if err == nil && val == "revoked" {
-		slog.Warn("Blacklist hit for token", "token", token)
-		return "", uuid.Nil, errors.New("token revoked")
+func (s *AuthService) ValidateToken(ctx context.Context, token string) (*TokenInfo, error) {
+	claims, err := utils.ValidateAccessToken(s.keys, token)
+	if err != nil {
+		return nil, err
 	}
 
-	claims, err := utils.ValidateAccessToken(token)
+	revoked, err := revocation.IsRevoked(ctx, s.redis, claims.Revocation())
 	if err != nil {
-		return "", uuid.Nil, err
+		return nil, err
+	}
+	if revoked {
+		slog.Debug("Revoked token presented", "jti", claims.ID, "user_id", claims.UserID)
+		return nil, errors.New("token revoked")
 	}
 
 	// Check User Cache
 	cacheKey := "user:" + claims.Username
-	val, err = s.redis.Get(ctx, cacheKey).Result()
+	val, err := s.redis.Get(ctx, cacheKey).Result()
 	if err == nil {
 		// Cache Hit
 		slog.Debug("Cache hit for user", "username", claims.Username)
 		var user models.User
 		if err := json.Unmarshal([]byte(val), &user); err == nil {
-			return user.Username, user.ID, nil
+			return &TokenInfo{Username: user.Username, UserID: user.ID, SessionID: claims.SessionID, ExpiresAt: claims.ExpiresAt.Time}, nil
 		}
 	}
 
@@ -156,7 +215,7 @@ func (s *AuthService) ValidateToken(ctx context.Context, token string) (string,
 	slog.Debug("Cache miss for user", "username", claims.Username)
 	user, err := s.store.GetUserByUsername(claims.Username)
 	if err != nil {
-		return "", uuid.Nil, err
+		return nil, err
 	}
 
 	// Set Cache
@@ -164,7 +223,7 @@ func (s *AuthService) ValidateToken(ctx context.Context, token string) (string,
 		s.redis.Set(ctx, cacheKey, userJSON, utils.AccessTokenDuration)
 	}
 
-	return claims.Username, user.ID, nil
+	return &TokenInfo{Username: claims.Username, UserID: user.ID, SessionID: claims.SessionID, ExpiresAt: claims.ExpiresAt.Time}, nil
 }
 
 func (s *AuthService) GetProfile(ctx context.Context, username string) (*UserResponse, error) {
@@ -176,11 +235,7 @@ func (s *AuthService) GetProfile(ctx context.Context, username string) (*UserRes
 		slog.Debug("Cache hit for user profile", "username", username)
 		var user models.User
 		if err := json.Unmarshal([]byte(val), &user); err == nil {
-			return &UserResponse{
-				ID:        user.ID,
-				Username:  user.Username,
-				CreatedAt: user.CreatedAt,
-			}, nil
+			return toUserResponse(&user), nil
 		}
 	}
 
@@ -196,29 +251,80 @@ func (s *AuthService) GetProfile(ctx context.Context, username string) (*UserRes
 		s.redis.Set(ctx, cacheKey, userJSON, utils.AccessTokenDuration)
 	}
 
-	return &UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		CreatedAt: user.CreatedAt,
-	}, nil
+	return toUserResponse(user), nil
 }
 
+// Logout revokes the access token and ends the session it belongs to, so
+// the session's refresh token stops working too. A token that is no longer
+// valid has nothing left to revoke.
 func (s *AuthService) Logout(ctx context.Context, token string) error {
-	return s.redis.Set(ctx, "blacklist:"+token, "revoked", utils.AccessTokenDuration).Err()
+	claims, err := utils.ValidateAccessToken(s.keys, token)
+	if err != nil {
+		return nil
+	}
+
+	if err := revocation.RevokeToken(ctx, s.redis, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+	if claims.SessionID == "" {
+		return nil
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil
+	}
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil
+	}
+
+	err = s.RevokeSession(ctx, userID, sessionID)
+	if errors.Is(err, storage.ErrSessionNotFound) {
+		return nil
+	}
+	return err
+}
+
+// invalidateUser drops the cached copy of a user after a profile change.
+func (s *AuthService) invalidateUser(ctx context.Context, username string) {
+	if err := s.redis.Del(ctx, "user:"+username).Err(); err != nil {
+		slog.Warn("Failed to invalidate user cache", "username", username, "error", err)
+	}
+}
+
+func toUserResponse(user *models.User) *UserResponse {
+	return &UserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Timezone:      user.Timezone,
+		CreatedAt:     user.CreatedAt,
+	}
 }
 
 func (s *AuthService) validateCredentials(username, password string) error {
 	if !usernameRegex.MatchString(username) {
-		return errors.New("invalid username format: must be 3-255 alphanumeric characters or underscore")
+		return i18n.NewError("auth.invalid_username")
 	}
 
+	return validatePassword(password)
+}
+
+func validatePassword(password string) error {
 	if !passwordRegex.MatchString(password) {
-		return errors.New("invalid password format: must be 8-16 characters and contain only alphanumeric or !#$%*")
+		return i18n.NewError("auth.invalid_password_format")
 	}
 
 	if !hasLetterRegex.MatchString(password) || !hasDigitRegex.MatchString(password) || !hasSpecialRegex.MatchString(password) {
-		return errors.New("password must contain at least one letter, one digit, and one special character (!#$%*)")
+		return i18n.NewError("auth.weak_password")
 	}
 
 	return nil
 }
+
+// JWKS returns the public keys access tokens are currently verified with.
+func (s *AuthService) JWKS() jwtkeys.JWKS {
+	return s.keys.JWKS()
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/kiribu/jwt-practice/internal/auth/storage"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"github.com/kiribu/jwt-practice/pkg/jwtkeys"
+	"github.com/kiribu/jwt-practice/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	testNotificationTopic = "notifications"
+	testPassword          = "secret12#"
+)
+
+var testDevice = storage.Device{UserAgent: "test", IP: "127.0.0.1"}
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// newTestService returns a service backed by memory storage, an in-process
+// Redis and an in-memory broker.
+func newTestService(t *testing.T) (*AuthService, *memoryStore, *broker.Memory) {
+	t.Helper()
+
+	key, err := jwtkeys.Generate(jwtkeys.EdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	store := newMemoryStore()
+	messages := broker.NewMemory()
+	s := NewAuthService(store, client, jwtkeys.NewKeyset(key), messages.Publisher(), testNotificationTopic, events.ContentTypeJSON)
+	return s, store, messages
+}
+
+func register(t *testing.T, s *AuthService, username string) *UserResponse {
+	t.Helper()
+
+	user, err := s.Register(username, testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func login(t *testing.T, s *AuthService, username, password string) *TokenResponse {
+	t.Helper()
+
+	result, err := s.Login(context.Background(), username, password, testDevice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Tokens == nil {
+		t.Fatal("login asked for a second factor")
+	}
+	return result.Tokens
+}
+
+// startOfSecond waits for the next second to begin, so that what follows
+// runs within one second of the clock and issues tokens with the same iat.
+func startOfSecond() {
+	now := time.Now()
+	time.Sleep(now.Truncate(time.Second).Add(time.Second).Sub(now))
+}
+
+// sameSecond skips the test when the tokens were not issued within one
+// second after all, as then it would not show what it is meant to.
+func sameSecond(t *testing.T, s *AuthService, tokens ...string) {
+	t.Helper()
+
+	var issued time.Time
+	for i, token := range tokens {
+		claims, err := utils.ValidateAccessToken(s.keys, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 && !claims.IssuedAt.Equal(issued) {
+			t.Skip("the clock moved on to the next second halfway through")
+		}
+		issued = claims.IssuedAt.Time
+	}
+}
+
+// Presenting a refresh token again after it was rotated ends the session:
+// the latest refresh token stops working and the access tokens are revoked.
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx := context.Background()
+	register(t, s, "alice")
+	first := login(t, s, "alice", testPassword)
+
+	second, err := s.Refresh(ctx, first.RefreshToken, testDevice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateToken(ctx, second.AccessToken); err != nil {
+		t.Fatalf("token from the refresh: %v", err)
+	}
+
+	_, err = s.Refresh(ctx, first.RefreshToken, testDevice)
+	var reuse *storage.TokenReuseError
+	if !errors.As(err, &reuse) {
+		t.Fatalf("rotated token presented again: err = %v, want *storage.TokenReuseError", err)
+	}
+
+	if _, err := s.Refresh(ctx, second.RefreshToken, testDevice); !errors.Is(err, storage.ErrRefreshTokenRevoked) {
+		t.Errorf("latest refresh token: err = %v, want %v", err, storage.ErrRefreshTokenRevoked)
+	}
+	for name, token := range map[string]string{"first": first.AccessToken, "second": second.AccessToken} {
+		if _, err := s.ValidateToken(ctx, token); err == nil {
+			t.Errorf("%s access token of the session is still valid", name)
+		}
+	}
+
+	// Other sessions are not affected
+	other := login(t, s, "alice", testPassword)
+	if _, err := s.Refresh(ctx, other.RefreshToken, testDevice); err != nil {
+		t.Errorf("refresh in another session: %v", err)
+	}
+}
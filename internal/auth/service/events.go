@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"github.com/kiribu/jwt-practice/pkg/events/pb"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// eventSource identifies this service in the envelope of published events.
+const eventSource = "/auth-service"
+
+// EventPublisher sends the auth service's requests to the notification service.
+type EventPublisher struct {
+	publisher         broker.Publisher
+	notificationTopic string
+	contentType       string
+}
+
+func NewEventPublisher(publisher broker.Publisher, notificationTopic, contentType string) *EventPublisher {
+	return &EventPublisher{
+		publisher:         publisher,
+		notificationTopic: notificationTopic,
+		contentType:       contentType,
+	}
+}
+
+// EmailVerificationRequested asks for the confirmation email, written in the
+// language of the request that set the address.
+func (p *EventPublisher) EmailVerificationRequested(ctx context.Context, user *models.User, token string, expiresAt time.Time) error {
+	return p.publish(ctx, events.TypeEmailVerificationRequested, user.ID, &pb.EmailVerificationRequested{
+		UserId:    user.ID.String(),
+		Username:  user.Username,
+		Email:     user.Email,
+		Token:     token,
+		ExpiresAt: timestamppb.New(expiresAt),
+		Timezone:  user.Timezone,
+		Locale:    string(i18n.FromContext(ctx)),
+	})
+}
+
+// PasswordResetRequested asks for the email with a password reset link,
+// sent to the user's verified address.
+func (p *EventPublisher) PasswordResetRequested(ctx context.Context, user *models.User, token string, expiresAt time.Time) error {
+	return p.publish(ctx, events.TypePasswordResetRequested, user.ID, &pb.PasswordResetRequested{
+		UserId:    user.ID.String(),
+		Username:  user.Username,
+		Email:     user.Email,
+		Token:     token,
+		ExpiresAt: timestamppb.New(expiresAt),
+		Timezone:  user.Timezone,
+		Locale:    string(i18n.FromContext(ctx)),
+	})
+}
+
+func (p *EventPublisher) publish(ctx context.Context, eventType string, userID uuid.UUID, data proto.Message) error {
+	env := events.New(uuid.Must(uuid.NewV7()).String(), eventType, eventSource, userID.String(), time.Now())
+	env.ContentType = p.contentType
+
+	value, headers, err := events.Encode(env, data)
+	if err != nil {
+		return err
+	}
+
+	return p.publisher.Publish(ctx, broker.Message{
+		Topic:   p.notificationTopic,
+		Key:     userID.String(),
+		Value:   value,
+		Headers: headers,
+		Time:    time.Now(),
+	})
+}
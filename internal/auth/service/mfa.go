@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/auth/storage"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"github.com/kiribu/jwt-practice/pkg/totp"
+	"github.com/kiribu/jwt-practice/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// totpIssuer names the service in authenticator apps
+	totpIssuer = "Reminder"
+
+	mfaChallengeTTL = 5 * time.Minute
+
+	// Wrong codes are counted per user, whichever endpoint they came
+	// through; past the limit every code is refused until the window passes
+	mfaMaxFailures   = 5
+	mfaFailureWindow = 15 * time.Minute
+
+	recoveryCodeCount = 10
+	// recoveryCodeSize random bytes make 16 base32 characters
+	recoveryCodeSize = 10
+)
+
+var (
+	ErrMFACode            = i18n.NewError("auth.mfa_code_invalid")
+	ErrMFATooManyAttempts = i18n.NewError("auth.mfa_too_many_attempts")
+	ErrMFAToken           = i18n.NewError("auth.mfa_token_invalid")
+)
+
+// TOTPEnrollment is what an authenticator app needs to be set up.
+type TOTPEnrollment struct {
+	Secret string
+	// URI is the otpauth:// link, usually shown as a QR code
+	URI string
+}
+
+// EnrollTOTP starts two-factor enrollment with a new secret. It takes
+// effect once ConfirmTOTP accepts a code generated from it; until then
+// enrolling again replaces the secret.
+func (s *AuthService) EnrollTOTP(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error) {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveTOTPSecret(userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP turns two-factor authentication on once the user shows that
+// their app produces valid codes. The recovery codes it returns are not
+// stored in the clear and cannot be shown again.
+func (s *AuthService) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	secret, err := s.store.GetTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if secret.ConfirmedAt != nil {
+		return nil, storage.ErrMFAEnabled
+	}
+	if err := s.checkMFAFailures(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	step, ok := totp.Validate(secret.Secret, normalizeCode(code), time.Now())
+	if !ok {
+		return nil, s.mfaFailed(ctx, userID)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.EnableTOTP(userID, step, hashes); err != nil {
+		return nil, err
+	}
+	s.mfaSucceeded(ctx, userID)
+
+	slog.Info("Two-factor authentication enabled", "user_id", userID)
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off. It takes a current code
+// or a recovery code, so a stolen access token alone is not enough.
+func (s *AuthService) DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	if err := s.verifySecondFactor(ctx, userID, code); err != nil {
+		return err
+	}
+	if err := s.store.DisableTOTP(userID); err != nil {
+		return err
+	}
+
+	slog.Info("Two-factor authentication disabled", "user_id", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user, used or
+// not, with a new set.
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := s.verifySecondFactor(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Recovery codes regenerated", "user_id", userID)
+	return codes, nil
+}
+
+// VerifyMFA completes a login that Login answered with an MFA token. The
+// token works for one successful answer; wrong codes count towards the
+// user's failure limit.
+func (s *AuthService) VerifyMFA(ctx context.Context, mfaToken, code string, device storage.Device) (*TokenResponse, error) {
+	key := mfaChallengeKey(mfaToken)
+	val, err := s.redis.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMFAToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	userID, err := uuid.Parse(val)
+	if err != nil {
+		return nil, ErrMFAToken
+	}
+
+	if err := s.verifySecondFactor(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	// Of concurrent answers to one challenge only the first gets a session
+	deleted, err := s.redis.Del(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if deleted == 0 {
+		return nil, ErrMFAToken
+	}
+
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.startSession(user, device)
+}
+
+// mfaEnabled reports whether logging in takes a second factor.
+func (s *AuthService) mfaEnabled(userID uuid.UUID) (bool, error) {
+	secret, err := s.store.GetTOTP(userID)
+	if errors.Is(err, storage.ErrMFANotEnabled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return secret.ConfirmedAt != nil, nil
+}
+
+// newMFAChallenge remembers that the user has passed the password check
+// and returns the token that proves it. Only its hash is kept.
+func (s *AuthService) newMFAChallenge(ctx context.Context, userID uuid.UUID) (string, error) {
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.redis.Set(ctx, mfaChallengeKey(token), userID.String(), mfaChallengeTTL).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// verifySecondFactor accepts a current TOTP code or an unused recovery code
+// of a user with two-factor authentication on. Either works only once.
+func (s *AuthService) verifySecondFactor(ctx context.Context, userID uuid.UUID, code string) error {
+	secret, err := s.store.GetTOTP(userID)
+	if err != nil {
+		return err
+	}
+	if secret.ConfirmedAt == nil {
+		return storage.ErrMFANotEnabled
+	}
+	if err := s.checkMFAFailures(ctx, userID); err != nil {
+		return err
+	}
+
+	code = normalizeCode(code)
+	var ok bool
+	if len(code) == totp.Digits {
+		if step, valid := totp.Validate(secret.Secret, code, time.Now()); valid {
+			ok, err = s.store.UseTOTPStep(userID, step)
+		}
+	} else {
+		ok, err = s.store.UseRecoveryCode(userID, hashToken(code))
+		if ok {
+			slog.Info("Recovery code used", "user_id", userID)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.mfaFailed(ctx, userID)
+	}
+
+	s.mfaSucceeded(ctx, userID)
+	return nil
+}
+
+func (s *AuthService) checkMFAFailures(ctx context.Context, userID uuid.UUID) error {
+	failures, err := s.redis.Get(ctx, mfaFailuresKey(userID)).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	if failures >= mfaMaxFailures {
+		return ErrMFATooManyAttempts
+	}
+	return nil
+}
+
+// mfaFailed counts a wrong code and returns the error to answer it with.
+// Every failure extends the window, so guessing never gets faster than
+// mfaMaxFailures codes per mfaFailureWindow.
+func (s *AuthService) mfaFailed(ctx context.Context, userID uuid.UUID) error {
+	key := mfaFailuresKey(userID)
+	pipe := s.redis.TxPipeline()
+	failures := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, mfaFailureWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	slog.Warn("Security event: invalid second factor code",
+		"event", "mfa_code_invalid",
+		"user_id", userID,
+		"failures", failures.Val())
+	return ErrMFACode
+}
+
+func (s *AuthService) mfaSucceeded(ctx context.Context, userID uuid.UUID) {
+	if err := s.redis.Del(ctx, mfaFailuresKey(userID)).Err(); err != nil {
+		slog.Warn("Failed to reset second factor failures", "user_id", userID, "error", err)
+	}
+}
+
+func mfaChallengeKey(token string) string {
+	return "mfa_challenge:" + hashToken(token)
+}
+
+func mfaFailuresKey(userID uuid.UUID) string {
+	return "mfa_failures:" + userID.String()
+}
+
+// newRecoveryCodes returns codes to show the user, formatted as
+// xxxx-xxxx-xxxx-xxxx, and the hashes to store in their place.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	codes = make([]string, recoveryCodeCount)
+	hashes = make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+
+		codes[i] = raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+		hashes[i] = hashToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeCode drops what people add when typing codes: spaces, dashes
+// and capitals.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/pkg/totp"
+)
+
+// enableMFA turns two-factor authentication on for the user and returns
+// the secret and the recovery codes.
+func enableMFA(t *testing.T, s *AuthService, userID uuid.UUID) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	enrollment, err := s.EnrollTOTP(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, err := s.ConfirmTOTP(ctx, userID, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enrollment.Secret, codes
+}
+
+// mfaChallenge logs in with the password and returns the MFA token.
+func mfaChallenge(t *testing.T, s *AuthService, username string) string {
+	t.Helper()
+
+	result, err := s.Login(context.Background(), username, testPassword, testDevice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.MFAToken == "" {
+		t.Fatal("login did not ask for a second factor")
+	}
+	return result.MFAToken
+}
+
+func TestRecoveryCodeWorksOnce(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx := context.Background()
+	user := register(t, s, "alice")
+	_, codes := enableMFA(t, s, user.ID)
+
+	tokens, err := s.VerifyMFA(ctx, mfaChallenge(t, s, "alice"), codes[0], testDevice)
+	if err != nil {
+		t.Fatalf("unused recovery code: %v", err)
+	}
+	if _, err := s.ValidateToken(ctx, tokens.AccessToken); err != nil {
+		t.Fatalf("token from the recovery code: %v", err)
+	}
+
+	// Neither as shown nor typed differently
+	for _, code := range []string{codes[0], strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))} {
+		_, err := s.VerifyMFA(ctx, mfaChallenge(t, s, "alice"), code, testDevice)
+		if !errors.Is(err, ErrMFACode) {
+			t.Errorf("used recovery code %q: err = %v, want %v", code, err, ErrMFACode)
+		}
+	}
+
+	// The other codes are unaffected
+	if _, err := s.VerifyMFA(ctx, mfaChallenge(t, s, "alice"), codes[1], testDevice); err != nil {
+		t.Errorf("second recovery code: %v", err)
+	}
+}
+
+func TestRegeneratedRecoveryCodesReplaceOldOnes(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx := context.Background()
+	user := register(t, s, "alice")
+	_, old := enableMFA(t, s, user.ID)
+
+	fresh, err := s.RegenerateRecoveryCodes(ctx, user.ID, old[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.VerifyMFA(ctx, mfaChallenge(t, s, "alice"), old[1], testDevice); !errors.Is(err, ErrMFACode) {
+		t.Errorf("old recovery code: err = %v, want %v", err, ErrMFACode)
+	}
+	if _, err := s.VerifyMFA(ctx, mfaChallenge(t, s, "alice"), fresh[0], testDevice); err != nil {
+		t.Errorf("new recovery code: %v", err)
+	}
+}
+
+// A TOTP code is spent once accepted, here by ConfirmTOTP.
+func TestTOTPCodeWorksOnce(t *testing.T) {
+	s, store, _ := newTestService(t)
+	user := register(t, s, "alice")
+	secret, _ := enableMFA(t, s, user.ID)
+
+	confirmed, err := store.GetTOTP(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.Code(secret, confirmed.LastUsedStep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.VerifyMFA(context.Background(), mfaChallenge(t, s, "alice"), code, testDevice)
+	if !errors.Is(err, ErrMFACode) {
+		t.Errorf("replayed code: err = %v, want %v", err, ErrMFACode)
+	}
+}
+
+func TestMFAFailureLimit(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx := context.Background()
+	user := register(t, s, "alice")
+	_, codes := enableMFA(t, s, user.ID)
+	mfaToken := mfaChallenge(t, s, "alice")
+
+	for i := range mfaMaxFailures {
+		_, err := s.VerifyMFA(ctx, mfaToken, "aaaa-bbbb-cccc-dddd", testDevice)
+		if !errors.Is(err, ErrMFACode) {
+			t.Fatalf("wrong code %d: err = %v, want %v", i+1, err, ErrMFACode)
+		}
+	}
+
+	// Past the limit even a right code is refused, on every endpoint, and
+	// not spent
+	if _, err := s.VerifyMFA(ctx, mfaToken, codes[0], testDevice); !errors.Is(err, ErrMFATooManyAttempts) {
+		t.Errorf("right code past the limit: err = %v, want %v", err, ErrMFATooManyAttempts)
+	}
+	if err := s.DisableTOTP(ctx, user.ID, codes[0]); !errors.Is(err, ErrMFATooManyAttempts) {
+		t.Errorf("disabling past the limit: err = %v, want %v", err, ErrMFATooManyAttempts)
+	}
+
+	ttl, err := s.redis.TTL(ctx, mfaFailuresKey(user.ID)).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl <= 0 || ttl > mfaFailureWindow {
+		t.Errorf("failures expire in %v, want within %v", ttl, mfaFailureWindow)
+	}
+
+	// Once the window has passed the code still works
+	if err := s.redis.Del(ctx, mfaFailuresKey(user.ID)).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VerifyMFA(ctx, mfaToken, codes[0], testDevice); err != nil {
+		t.Errorf("right code after the window: %v", err)
+	}
+}
+
+// A successful code clears the count, so that a user's occasional typos do
+// not add up to a lockout.
+func TestMFASuccessResetsFailures(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx := context.Background()
+	user := register(t, s, "alice")
+	_, codes := enableMFA(t, s, user.ID)
+
+	for round := range 2 {
+		mfaToken := mfaChallenge(t, s, "alice")
+		for range mfaMaxFailures - 1 {
+			s.VerifyMFA(ctx, mfaToken, "aaaa-bbbb-cccc-dddd", testDevice)
+		}
+		if _, err := s.VerifyMFA(ctx, mfaToken, codes[round], testDevice); err != nil {
+			t.Fatalf("round %d: %v", round+1, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"github.com/kiribu/jwt-practice/utils"
+)
+
+const (
+	passwordResetTTL = time.Hour
+	// passwordResetCooldown limits how often reset emails go to one user
+	passwordResetCooldown = time.Minute
+)
+
+var ErrCurrentPassword = i18n.NewError("auth.current_password_incorrect")
+
+// ChangePassword replaces the password of a signed-in user who knows the
+// current one. The user is logged out everywhere, this device included.
+func (s *AuthService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.store.ValidatePassword(user.Username, currentPassword); err != nil {
+		return ErrCurrentPassword
+	}
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	user, sessions, err := s.store.ChangePassword(userID, newPassword)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Password changed", "user_id", user.ID, "sessions_revoked", len(sessions))
+	return s.passwordChanged(ctx, user, sessions)
+}
+
+// RequestPasswordReset emails a reset link to the verified address given.
+// The outcome is the same whether or not the address belongs to anyone, so
+// the endpoint cannot be used to find out which addresses are registered.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+	if err := validateEmail(email); err != nil {
+		return err
+	}
+
+	user, err := s.store.GetUserByEmail(email)
+	if err != nil || !user.EmailVerified {
+		slog.Info("Password reset requested for unknown address")
+		return nil
+	}
+
+	ok, err := s.redis.SetNX(ctx, "password_reset:"+user.ID.String(), 1, passwordResetCooldown).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		slog.Info("Password reset requested again too soon", "user_id", user.ID)
+		return nil
+	}
+
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(passwordResetTTL)
+	if err := s.store.SavePasswordReset(user.ID, hashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	if err := s.events.PasswordResetRequested(ctx, user, token, expiresAt); err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	slog.Info("Password reset requested", "user_id", user.ID)
+	return nil
+}
+
+// ResetPassword sets a new password with a token from a reset email. Like a
+// password change, it logs the user out everywhere.
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	user, sessions, err := s.store.ResetPassword(hashToken(token), newPassword)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Password reset", "user_id", user.ID, "sessions_revoked", len(sessions))
+	return s.passwordChanged(ctx, user, sessions)
+}
+
+// passwordChanged revokes the access tokens of a user whose password has
+// just changed; storage has already revoked the sessions themselves.
+func (s *AuthService) passwordChanged(ctx context.Context, user *models.User, sessions []uuid.UUID) error {
+	s.invalidateUser(ctx, user.Username)
+
+	if err := s.markSessionsRevoked(ctx, sessions...); err != nil {
+		return err
+	}
+	return s.RevokeTokensIssuedBefore(ctx, user.ID, time.Now())
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kiribu/jwt-practice/internal/auth/storage"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"github.com/kiribu/jwt-practice/pkg/events/pb"
+)
+
+// Logging in again straight after a password change must work, even within
+// the second the change was made in, while every earlier token is revoked.
+func TestLoginInSameSecondAsPasswordChange(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx := context.Background()
+	user := register(t, s, "alice")
+	const newPassword = "secret34#"
+
+	startOfSecond()
+	old := login(t, s, "alice", testPassword)
+	if err := s.ChangePassword(ctx, user.ID, testPassword, newPassword); err != nil {
+		t.Fatal(err)
+	}
+	fresh := login(t, s, "alice", newPassword)
+	sameSecond(t, s, old.AccessToken, fresh.AccessToken)
+
+	if _, err := s.ValidateToken(ctx, fresh.AccessToken); err != nil {
+		t.Errorf("token from the new login: %v", err)
+	}
+	if _, err := s.ValidateToken(ctx, old.AccessToken); err == nil {
+		t.Error("token issued before the password change is still valid")
+	}
+}
+
+// requestReset asks for a reset link for the user's verified address and
+// returns the token the email would carry.
+func requestReset(t *testing.T, s *AuthService, messages *broker.Memory, email string) string {
+	t.Helper()
+
+	sent := len(messages.Messages(testNotificationTopic))
+	if err := s.RequestPasswordReset(context.Background(), email); err != nil {
+		t.Fatal(err)
+	}
+	msgs := messages.Messages(testNotificationTopic)
+	if len(msgs) != sent+1 {
+		t.Fatalf("%d reset emails requested, want 1", len(msgs)-sent)
+	}
+
+	msg := msgs[len(msgs)-1]
+	env, err := events.Parse(msg.Headers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Type != events.TypePasswordResetRequested {
+		t.Fatalf("event type = %s, want %s", env.Type, events.TypePasswordResetRequested)
+	}
+	var event pb.PasswordResetRequested
+	if err := env.Unmarshal(msg.Value, &event); err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(event.ExpiresAt.AsTime()) > passwordResetTTL {
+		t.Errorf("reset link valid until %v, longer than %v", event.ExpiresAt.AsTime(), passwordResetTTL)
+	}
+	return event.Token
+}
+
+func TestPasswordResetTokenWorksOnce(t *testing.T) {
+	s, store, messages := newTestService(t)
+	ctx := context.Background()
+	user := register(t, s, "alice")
+	store.verifyEmail(user.ID, "alice@example.com")
+	old := login(t, s, "alice", testPassword)
+
+	token := requestReset(t, s, messages, "Alice@example.com")
+	if err := s.ResetPassword(ctx, token, "secret34#"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ResetPassword(ctx, token, "secret56#"); !errors.Is(err, storage.ErrResetToken) {
+		t.Errorf("token used again: err = %v, want %v", err, storage.ErrResetToken)
+	}
+
+	// The password is the one of the first reset
+	if _, err := s.Login(ctx, "alice", "secret56#", testDevice); err == nil {
+		t.Error("second reset changed the password")
+	}
+	login(t, s, "alice", "secret34#")
+
+	// Whoever knew the old password is logged out
+	if _, err := s.ValidateToken(ctx, old.AccessToken); err == nil {
+		t.Error("access token issued before the reset is still valid")
+	}
+	if _, err := s.Refresh(ctx, old.RefreshToken, testDevice); !errors.Is(err, storage.ErrRefreshTokenRevoked) {
+		t.Errorf("refresh token issued before the reset: err = %v, want %v", err, storage.ErrRefreshTokenRevoked)
+	}
+}
+
+func TestPasswordResetTokenExpires(t *testing.T) {
+	s, store, messages := newTestService(t)
+	ctx := context.Background()
+	user := register(t, s, "alice")
+	store.verifyEmail(user.ID, "alice@example.com")
+
+	token := requestReset(t, s, messages, "alice@example.com")
+	store.expirePasswordResets()
+
+	if err := s.ResetPassword(ctx, token, "secret34#"); !errors.Is(err, storage.ErrResetToken) {
+		t.Errorf("expired token: err = %v, want %v", err, storage.ErrResetToken)
+	}
+	login(t, s, "alice", testPassword)
+}
+
+// A password change drops pending resets, so an old link cannot undo it.
+func TestPasswordChangeInvalidatesResetToken(t *testing.T) {
+	s, store, messages := newTestService(t)
+	ctx := context.Background()
+	user := register(t, s, "alice")
+	store.verifyEmail(user.ID, "alice@example.com")
+
+	token := requestReset(t, s, messages, "alice@example.com")
+	if err := s.ChangePassword(ctx, user.ID, testPassword, "secret34#"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ResetPassword(ctx, token, "secret56#"); !errors.Is(err, storage.ErrResetToken) {
+		t.Errorf("token from before the change: err = %v, want %v", err, storage.ErrResetToken)
+	}
+}
+
+// Nothing is sent for addresses nobody has verified, and the caller cannot
+// tell the difference.
+func TestPasswordResetForUnverifiedAddress(t *testing.T) {
+	s, store, messages := newTestService(t)
+	user := register(t, s, "alice")
+	store.verifyEmail(user.ID, "alice@example.com")
+	store.users[user.ID].EmailVerified = false
+
+	for _, email := range []string{"alice@example.com", "nobody@example.com"} {
+		if err := s.RequestPasswordReset(context.Background(), email); err != nil {
+			t.Errorf("%s: %v", email, err)
+		}
+	}
+	if n := len(messages.Messages(testNotificationTopic)); n != 0 {
+		t.Errorf("%d reset emails sent, want 0", n)
+	}
+}
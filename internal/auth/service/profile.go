@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"github.com/kiribu/jwt-practice/utils"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+// UserContact is what other services need to reach a user.
+type UserContact struct {
+	UserID        uuid.UUID
+	Username      string
+	Email         string
+	EmailVerified bool
+	Timezone      string
+}
+
+func (s *AuthService) UpdateTimezone(ctx context.Context, userID uuid.UUID, timezone string) (*UserResponse, error) {
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" || timezone == "Local" {
+		return nil, i18n.NewError("auth.invalid_timezone")
+	}
+
+	user, err := s.store.UpdateTimezone(userID, timezone)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateUser(ctx, user.Username)
+
+	return toUserResponse(user), nil
+}
+
+// SetEmail attaches an unverified address to the user and sends a
+// confirmation link to it through the notification service.
+func (s *AuthService) SetEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	email = strings.TrimSpace(email)
+	if err := validateEmail(email); err != nil {
+		return err
+	}
+
+	user, err := s.store.SetEmail(userID, email)
+	if err != nil {
+		return err
+	}
+	s.invalidateUser(ctx, user.Username)
+
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(emailVerificationTTL)
+	if err := s.store.SaveEmailVerification(user.ID, email, hashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	if err := s.events.EmailVerificationRequested(ctx, user, token, expiresAt); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	slog.Info("Email verification requested", "user_id", user.ID)
+	return nil
+}
+
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	user, err := s.store.ConfirmEmailVerification(hashToken(token))
+	if err != nil {
+		return err
+	}
+	s.invalidateUser(ctx, user.Username)
+
+	slog.Info("Email verified", "user_id", user.ID)
+	return nil
+}
+
+func (s *AuthService) GetUserContact(ctx context.Context, userID uuid.UUID) (*UserContact, error) {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserContact{
+		UserID:        user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Timezone:      user.Timezone,
+	}, nil
+}
+
+func validateEmail(email string) error {
+	if len(email) > 255 {
+		return i18n.NewError("auth.email_too_long")
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return i18n.NewError("auth.invalid_email")
+	}
+	return nil
+}
+
+// hashToken is how refresh tokens, links sent by email and recovery codes
+// are stored, so a leaked table does not leak working credentials. The
+// tokens are random, so a fast unsalted hash is enough to make them
+// unguessable from the digest.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/pkg/revocation"
+	"github.com/kiribu/jwt-practice/utils"
+)
+
+type SessionResponse struct {
+	ID         uuid.UUID
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	// Current marks the session of the token the list was requested with
+	Current bool
+}
+
+// ListSessions returns the active sessions of a user. currentSessionID is
+// the session of the caller's own token, if it has one.
+func (s *AuthService) ListSessions(userID uuid.UUID, currentSessionID string) ([]SessionResponse, error) {
+	sessions, err := s.store.ListSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]SessionResponse, len(sessions))
+	for i, session := range sessions {
+		result[i] = SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.ID.String() == currentSessionID,
+		}
+	}
+	return result, nil
+}
+
+// RevokeSession ends one session of a user: its refresh token stops working
+// and its access tokens are rejected from now on.
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if err := s.store.RevokeSession(userID, sessionID); err != nil {
+		return err
+	}
+	return s.markSessionsRevoked(ctx, sessionID)
+}
+
+// RevokeAllSessions logs the user out everywhere and returns how many
+// sessions were ended. Every access token issued so far is revoked too,
+// whichever session it came from.
+func (s *AuthService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) (int, error) {
+	ids, err := s.store.RevokeSessions(userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.markSessionsRevoked(ctx, ids...); err != nil {
+		return 0, err
+	}
+	if err := s.RevokeTokensIssuedBefore(ctx, userID, time.Now()); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// RevokeTokensIssuedBefore revokes every access token of the user issued
+// before the second t falls in. Tokens issued within that second stay valid,
+// so a login straight after the call works; revoking the sessions as well
+// takes care of the older ones among them.
+func (s *AuthService) RevokeTokensIssuedBefore(ctx context.Context, userID uuid.UUID, t time.Time) error {
+	return revocation.RevokeUser(ctx, s.redis, userID.String(), t, utils.AccessTokenDuration)
+}
+
+// markSessionsRevoked makes the access tokens of the sessions invalid. They
+// are not stored, so instead the sessions are listed as revoked, here and in
+// every gateway, for as long as any token issued to them can be valid.
+func (s *AuthService) markSessionsRevoked(ctx context.Context, ids ...uuid.UUID) error {
+	sessionIDs := make([]string, len(ids))
+	for i, id := range ids {
+		sessionIDs[i] = id.String()
+	}
+	return revocation.RevokeSessions(ctx, s.redis, utils.AccessTokenDuration, sessionIDs...)
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+// Logging out everywhere ends the sessions there are, not the next one: a
+// login straight after it, even within the same second, must work.
+func TestLoginInSameSecondAsRevokeAllSessions(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx := context.Background()
+	user := register(t, s, "alice")
+
+	startOfSecond()
+	old := login(t, s, "alice", testPassword)
+	n, err := s.RevokeAllSessions(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("revoked %d sessions, want 1", n)
+	}
+	fresh := login(t, s, "alice", testPassword)
+	sameSecond(t, s, old.AccessToken, fresh.AccessToken)
+
+	if _, err := s.ValidateToken(ctx, fresh.AccessToken); err != nil {
+		t.Errorf("token from the new login: %v", err)
+	}
+	if _, err := s.ValidateToken(ctx, old.AccessToken); err == nil {
+		t.Error("token issued before logging out everywhere is still valid")
+	}
+}
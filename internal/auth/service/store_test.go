@@ -0,0 +1,365 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/auth/storage"
+	"github.com/kiribu/jwt-practice/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memoryStore keeps users and sessions in memory the way PostgresStorage
+// keeps them in tables. Methods the tests do not reach are left to the
+// embedded interface and panic.
+type memoryStore struct {
+	storage.Storage
+
+	mu       sync.Mutex
+	users    map[uuid.UUID]*models.User
+	sessions map[uuid.UUID]*models.Session
+	// tokens are refresh tokens by hash
+	tokens map[string]*models.RefreshToken
+	totp   map[uuid.UUID]*models.TOTP
+	resets map[string]*passwordReset
+	// recoveryCodes maps code hashes to whether they were used
+	recoveryCodes map[uuid.UUID]map[string]bool
+}
+
+type passwordReset struct {
+	userID    uuid.UUID
+	expiresAt time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		users:         map[uuid.UUID]*models.User{},
+		sessions:      map[uuid.UUID]*models.Session{},
+		tokens:        map[string]*models.RefreshToken{},
+		resets:        map[string]*passwordReset{},
+		totp:          map[uuid.UUID]*models.TOTP{},
+		recoveryCodes: map[uuid.UUID]map[string]bool{},
+	}
+}
+
+func (s *memoryStore) CreateUser(username, password string) (*models.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Username == username {
+			return nil, storage.ErrUsernameTaken
+		}
+	}
+	user := &models.User{
+		ID:           uuid.Must(uuid.NewV7()),
+		Username:     username,
+		PasswordHash: string(hash),
+		Timezone:     "UTC",
+		CreatedAt:    time.Now(),
+	}
+	s.users[user.ID] = user
+	u := *user
+	return &u, nil
+}
+
+func (s *memoryStore) GetUserByUsername(username string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Username == username {
+			user := *u
+			return &user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (s *memoryStore) GetUserByID(id uuid.UUID) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	user := *u
+	return &user, nil
+}
+
+func (s *memoryStore) GetUserByEmail(email string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Email != "" && strings.EqualFold(u.Email, email) {
+			user := *u
+			return &user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+// verifyEmail gives the user a confirmed address, as SetEmail followed by
+// ConfirmEmailVerification would.
+func (s *memoryStore) verifyEmail(userID uuid.UUID, email string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[userID].Email = email
+	s.users[userID].EmailVerified = true
+}
+
+func (s *memoryStore) ValidatePassword(username, password string) (*models.User, error) {
+	user, err := s.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, errors.New("invalid password")
+	}
+	return user, nil
+}
+
+func (s *memoryStore) ChangePassword(userID uuid.UUID, password string) (*models.User, []uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.setPassword(userID, password)
+}
+
+func (s *memoryStore) SavePasswordReset(userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropPasswordResets(userID)
+	s.resets[tokenHash] = &passwordReset{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *memoryStore) ResetPassword(tokenHash, password string) (*models.User, []uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.resets[tokenHash]
+	if !ok {
+		return nil, nil, storage.ErrResetToken
+	}
+	delete(s.resets, tokenHash)
+	if time.Now().After(r.expiresAt) {
+		return nil, nil, storage.ErrResetToken
+	}
+	return s.setPassword(r.userID, password)
+}
+
+// expirePasswordResets moves every pending reset past its expiry.
+func (s *memoryStore) expirePasswordResets() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.resets {
+		r.expiresAt = time.Now().Add(-time.Second)
+	}
+}
+
+// dropPasswordResets deletes the user's pending resets; s.mu is held.
+func (s *memoryStore) dropPasswordResets(userID uuid.UUID) {
+	for hash, r := range s.resets {
+		if r.userID == userID {
+			delete(s.resets, hash)
+		}
+	}
+}
+
+// setPassword mirrors the storage function of the same name; s.mu is held.
+func (s *memoryStore) setPassword(userID uuid.UUID, password string) (*models.User, []uuid.UUID, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		return nil, nil, err
+	}
+	u, ok := s.users[userID]
+	if !ok {
+		return nil, nil, errors.New("user not found")
+	}
+	u.PasswordHash = string(hash)
+	s.dropPasswordResets(userID)
+
+	user := *u
+	return &user, s.revokeUserSessions(userID), nil
+}
+
+func (s *memoryStore) GetTOTP(userID uuid.UUID) (*models.TOTP, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.totp[userID]
+	if !ok {
+		return nil, storage.ErrMFANotEnabled
+	}
+	c := *t
+	return &c, nil
+}
+
+func (s *memoryStore) SaveTOTPSecret(userID uuid.UUID, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.totp[userID]; ok && t.ConfirmedAt != nil {
+		return storage.ErrMFAEnabled
+	}
+	s.totp[userID] = &models.TOTP{UserID: userID, Secret: secret, CreatedAt: time.Now()}
+	return nil
+}
+
+func (s *memoryStore) EnableTOTP(userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.totp[userID]
+	if !ok || t.ConfirmedAt != nil {
+		return storage.ErrMFAEnabled
+	}
+	now := time.Now()
+	t.ConfirmedAt = &now
+	t.LastUsedStep = step
+	s.replaceRecoveryCodes(userID, recoveryCodeHashes)
+	return nil
+}
+
+func (s *memoryStore) UseTOTPStep(userID uuid.UUID, step int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.totp[userID]
+	if !ok || t.LastUsedStep >= step {
+		return false, nil
+	}
+	t.LastUsedStep = step
+	return true, nil
+}
+
+func (s *memoryStore) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	used, ok := s.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	s.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+func (s *memoryStore) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replaceRecoveryCodes(userID, codeHashes)
+	return nil
+}
+
+// replaceRecoveryCodes stores a new set of unused codes; s.mu is held.
+func (s *memoryStore) replaceRecoveryCodes(userID uuid.UUID, codeHashes []string) {
+	codes := make(map[string]bool, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes[hash] = false
+	}
+	s.recoveryCodes[userID] = codes
+}
+
+func (s *memoryStore) DisableTOTP(userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.totp[userID]; !ok {
+		return storage.ErrMFANotEnabled
+	}
+	delete(s.totp, userID)
+	delete(s.recoveryCodes, userID)
+	return nil
+}
+
+func (s *memoryStore) CreateSession(userID uuid.UUID, device storage.Device, tokenHash string, expiresAt time.Time) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	session := &models.Session{
+		ID:         uuid.Must(uuid.NewV7()),
+		UserID:     userID,
+		UserAgent:  device.UserAgent,
+		IP:         device.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+	s.sessions[session.ID] = session
+	s.tokens[tokenHash] = &models.RefreshToken{
+		ID:        uuid.Must(uuid.NewV7()),
+		FamilyID:  session.ID,
+		TokenHash: tokenHash,
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	c := *session
+	return &c, nil
+}
+
+func (s *memoryStore) RotateRefreshToken(tokenHash, newTokenHash string, device storage.Device, expiresAt time.Time) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rt, ok := s.tokens[tokenHash]
+	now := time.Now()
+	switch {
+	case !ok:
+		return nil, storage.ErrRefreshTokenNotFound
+	case rt.RevokedAt != nil:
+		return nil, storage.ErrRefreshTokenRevoked
+	case rt.RotatedAt != nil:
+		s.revokeFamily(rt.FamilyID)
+		return nil, &storage.TokenReuseError{UserID: rt.UserID, FamilyID: rt.FamilyID, RotatedAt: *rt.RotatedAt}
+	case now.After(rt.ExpiresAt):
+		return nil, storage.ErrRefreshTokenExpired
+	}
+
+	rt.RotatedAt = &now
+	s.tokens[newTokenHash] = &models.RefreshToken{
+		ID:        uuid.Must(uuid.NewV7()),
+		FamilyID:  rt.FamilyID,
+		TokenHash: newTokenHash,
+		UserID:    rt.UserID,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+
+	session := s.sessions[rt.FamilyID]
+	session.LastUsedAt = now
+	c := *session
+	return &c, nil
+}
+
+func (s *memoryStore) RevokeSessions(userID uuid.UUID) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revokeUserSessions(userID), nil
+}
+
+// revokeUserSessions returns the ids of the user's sessions that were still
+// active; s.mu is held.
+func (s *memoryStore) revokeUserSessions(userID uuid.UUID) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			ids = append(ids, session.ID)
+			s.revokeFamily(session.ID)
+		}
+	}
+	return ids
+}
+
+// revokeFamily marks a session and its refresh tokens revoked; s.mu is held.
+func (s *memoryStore) revokeFamily(familyID uuid.UUID) {
+	now := time.Now()
+	if session := s.sessions[familyID]; session.RevokedAt == nil {
+		session.RevokedAt = &now
+	}
+	for _, rt := range s.tokens {
+		if rt.FamilyID == familyID && rt.RevokedAt == nil {
+			rt.RevokedAt = &now
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package storage
 
 import (
+	"database/sql"
 	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -14,10 +16,63 @@ type Storage interface {
 	CreateUser(username, password string) (*models.User, error)
 	GetUserByUsername(username string) (*models.User, error)
 	GetUserByID(id uuid.UUID) (*models.User, error)
+	GetUserByEmail(email string) (*models.User, error)
 	ValidatePassword(username, password string) (*models.User, error)
-	SaveRefreshToken(token string, userID uuid.UUID, expiresAt time.Time) error
-	ValidateRefreshToken(token string) (uuid.UUID, error)
-	DeleteRefreshToken(token string) error
+	ChangePassword(userID uuid.UUID, password string) (*models.User, []uuid.UUID, error)
+	SavePasswordReset(userID uuid.UUID, tokenHash string, expiresAt time.Time) error
+	ResetPassword(tokenHash, password string) (*models.User, []uuid.UUID, error)
+	GetTOTP(userID uuid.UUID) (*models.TOTP, error)
+	SaveTOTPSecret(userID uuid.UUID, secret string) error
+	EnableTOTP(userID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	UseTOTPStep(userID uuid.UUID, step int64) (bool, error)
+	UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error
+	DisableTOTP(userID uuid.UUID) error
+	CreateSession(userID uuid.UUID, device Device, tokenHash string, expiresAt time.Time) (*models.Session, error)
+	RotateRefreshToken(tokenHash, newTokenHash string, device Device, expiresAt time.Time) (*models.Session, error)
+	ListSessions(userID uuid.UUID) ([]models.Session, error)
+	RevokeSession(userID, sessionID uuid.UUID) error
+	RevokeSessions(userID uuid.UUID) ([]uuid.UUID, error)
+	DeleteExpiredRefreshTokens() (int64, error)
+	DeleteStaleSessions() (int64, error)
+	UpdateTimezone(userID uuid.UUID, timezone string) (*models.User, error)
+	SetEmail(userID uuid.UUID, email string) (*models.User, error)
+	SaveEmailVerification(userID uuid.UUID, email, tokenHash string, expiresAt time.Time) error
+	ConfirmEmailVerification(tokenHash string) (*models.User, error)
+}
+
+var (
+	ErrRefreshTokenNotFound = errors.New("token not found")
+	ErrRefreshTokenExpired  = errors.New("token expired")
+	ErrRefreshTokenRevoked  = errors.New("token revoked")
+
+	ErrSessionNotFound   = i18n.NewError("auth.session_not_found")
+	ErrUsernameTaken     = i18n.NewError("auth.username_taken")
+	ErrEmailTaken        = i18n.NewError("auth.email_taken")
+	ErrVerificationToken = i18n.NewError("auth.verification_token_invalid")
+	ErrResetToken        = i18n.NewError("auth.reset_token_invalid")
+	ErrMFAEnabled        = i18n.NewError("auth.mfa_already_enabled")
+	ErrMFANotEnabled     = i18n.NewError("auth.mfa_not_enabled")
+)
+
+// TokenReuseError reports that a refresh token was presented again after it
+// had been rotated. Either the client or an attacker holds a stolen copy, so
+// the whole family has been revoked.
+type TokenReuseError struct {
+	UserID   uuid.UUID
+	FamilyID uuid.UUID
+	// RotatedAt is when the token was first exchanged
+	RotatedAt time.Time
+}
+
+func (e *TokenReuseError) Error() string {
+	return "refresh token reused after rotation"
+}
+
+// Device is what is known about the client a session was used from.
+type Device struct {
+	UserAgent string
+	IP        string
 }
 
 type PostgresStorage struct {
@@ -39,12 +94,12 @@ func (s *PostgresStorage) CreateUser(username, password string) (*models.User, e
 	err = s.db.QueryRowx(
 		`INSERT INTO users (id, username, password_hash) 
 		 VALUES ($1, $2, $3) 
-		 RETURNING id, username, password_hash, created_at`,
+		 RETURNING *`,
 		userID, username, string(hashedPassword),
 	).StructScan(&user)
 
 	if err != nil {
-		return nil, errors.New("user with this username already exists")
+		return nil, ErrUsernameTaken
 	}
 
 	return &user, nil
@@ -67,6 +122,17 @@ func (s *PostgresStorage) GetUserByID(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
+// GetUserByEmail looks a user up by address, ignoring case as the unique
+// index does.
+func (s *PostgresStorage) GetUserByEmail(email string) (*models.User, error) {
+	var user models.User
+	err := s.db.Get(&user, "SELECT * FROM users WHERE LOWER(email) = LOWER($1) AND email <> ''", email)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return &user, nil
+}
+
 func (s *PostgresStorage) ValidatePassword(username, password string) (*models.User, error) {
 	user, err := s.GetUserByUsername(username)
 	if err != nil {
@@ -80,38 +146,590 @@ func (s *PostgresStorage) ValidatePassword(username, password string) (*models.U
 	return user, nil
 }
 
-func (s *PostgresStorage) SaveRefreshToken(token string, userID uuid.UUID, expiresAt time.Time) error {
-	tokenID := uuid.Must(uuid.NewV7())
-	_, err := s.db.Exec(
-		`INSERT INTO refresh_tokens (id, token, user_id, expires_at) VALUES ($1, $2, $3, $4)`,
-		tokenID, token, userID, expiresAt,
+// CreateSession starts a session at login together with the first refresh
+// token of its family. Tokens are known to storage by their hash only.
+func (s *PostgresStorage) CreateSession(userID uuid.UUID, device Device, tokenHash string, expiresAt time.Time) (*models.Session, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var session models.Session
+	err = tx.Get(&session,
+		`INSERT INTO sessions (id, user_id, user_agent, ip) VALUES ($1, $2, $3, $4) RETURNING *`,
+		uuid.Must(uuid.NewV7()), userID, device.UserAgent, device.IP,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO refresh_tokens (id, family_id, token_hash, user_id, expires_at) VALUES ($1, $2, $3, $4, $5)`,
+		uuid.Must(uuid.NewV7()), session.ID, tokenHash, userID, expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RotateRefreshToken exchanges a token for the next one in the same family and
+// returns the session it belongs to. Rotated tokens are kept, not deleted, so
+// that presenting one again is recognised as reuse: the session is then
+// revoked and a *TokenReuseError returned.
+func (s *PostgresStorage) RotateRefreshToken(tokenHash, newTokenHash string, device Device, expiresAt time.Time) (*models.Session, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the row makes concurrent refreshes with one token take turns,
+	// so only the first of them rotates it
+	var rt models.RefreshToken
+	err = tx.Get(&rt,
+		`SELECT id, family_id, user_id, expires_at, rotated_at, revoked_at
+		 FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`,
+		tokenHash,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case rt.RevokedAt != nil:
+		return nil, ErrRefreshTokenRevoked
+	case rt.RotatedAt != nil:
+		if err := revokeFamily(tx, rt.FamilyID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, &TokenReuseError{UserID: rt.UserID, FamilyID: rt.FamilyID, RotatedAt: *rt.RotatedAt}
+	case time.Now().After(rt.ExpiresAt):
+		return nil, ErrRefreshTokenExpired
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET rotated_at = NOW() WHERE id = $1`, rt.ID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO refresh_tokens (id, family_id, token_hash, user_id, expires_at) VALUES ($1, $2, $3, $4, $5)`,
+		uuid.Must(uuid.NewV7()), rt.FamilyID, newTokenHash, rt.UserID, expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var session models.Session
+	err = tx.Get(&session,
+		`UPDATE sessions
+		 SET last_used_at = NOW(), user_agent = COALESCE(NULLIF($2, ''), user_agent), ip = COALESCE(NULLIF($3, ''), ip)
+		 WHERE id = $1 RETURNING *`,
+		rt.FamilyID, device.UserAgent, device.IP,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListSessions returns the sessions of a user that can still be refreshed,
+// most recently used first.
+func (s *PostgresStorage) ListSessions(userID uuid.UUID) ([]models.Session, error) {
+	sessions := []models.Session{}
+	err := s.db.Select(&sessions,
+		`SELECT s.* FROM sessions s
+		 WHERE s.user_id = $1 AND s.revoked_at IS NULL
+		   AND EXISTS (
+		     SELECT 1 FROM refresh_tokens rt
+		     WHERE rt.family_id = s.id AND rt.rotated_at IS NULL AND rt.revoked_at IS NULL AND rt.expires_at > NOW()
+		   )
+		 ORDER BY s.last_used_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one session of a user along with its refresh tokens.
+func (s *PostgresStorage) RevokeSession(userID, sessionID uuid.UUID) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	err = tx.Get(&id,
+		`SELECT id FROM sessions WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL FOR UPDATE`,
+		sessionID, userID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := revokeFamily(tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RevokeSessions ends every session of a user and returns the ids of those
+// that were still active.
+func (s *PostgresStorage) RevokeSessions(userID uuid.UUID) ([]uuid.UUID, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids, err := revokeUserSessions(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// revokeUserSessions marks every session and refresh token of a user revoked
+// and returns the ids of the sessions that were still active.
+func revokeUserSessions(tx *sqlx.Tx, userID uuid.UUID) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	err := tx.Select(&ids,
+		`UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL RETURNING id`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`,
+		userID,
+	); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// revokeFamily marks a session and every refresh token of its family revoked.
+func revokeFamily(tx *sqlx.Tx, familyID uuid.UUID) error {
+	if _, err := tx.Exec(
+		`UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`,
+		familyID,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`,
+		familyID,
 	)
 	return err
 }
 
-func (s *PostgresStorage) ValidateRefreshToken(token string) (uuid.UUID, error) {
-	var rt struct {
+// DeleteExpiredRefreshTokens purges tokens past their expiry, rotated and
+// revoked ones included: once expired they can neither be used nor matter
+// for reuse detection.
+func (s *PostgresStorage) DeleteExpiredRefreshTokens() (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM refresh_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteStaleSessions purges sessions none of whose refresh tokens are left,
+// which happens once the last of them has expired and been deleted.
+func (s *PostgresStorage) DeleteStaleSessions() (int64, error) {
+	result, err := s.db.Exec(
+		`DELETE FROM sessions s WHERE NOT EXISTS (SELECT 1 FROM refresh_tokens rt WHERE rt.family_id = s.id)`,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *PostgresStorage) UpdateTimezone(userID uuid.UUID, timezone string) (*models.User, error) {
+	var user models.User
+	err := s.db.Get(&user,
+		`UPDATE users SET timezone = $2 WHERE id = $1 RETURNING *`,
+		userID, timezone,
+	)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return &user, nil
+}
+
+// SetEmail replaces the user's address and marks it unverified until the
+// new one is confirmed.
+func (s *PostgresStorage) SetEmail(userID uuid.UUID, email string) (*models.User, error) {
+	var user models.User
+	err := s.db.Get(&user,
+		`UPDATE users SET email = $2, email_verified = FALSE WHERE id = $1 RETURNING *`,
+		userID, email,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("user not found")
+	}
+	if err != nil {
+		return nil, ErrEmailTaken
+	}
+	return &user, nil
+}
+
+// SaveEmailVerification stores a pending confirmation, replacing any earlier
+// one of the same user so that only the latest link works.
+func (s *PostgresStorage) SaveEmailVerification(userID uuid.UUID, email, tokenHash string, expiresAt time.Time) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM email_verifications WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO email_verifications (token_hash, user_id, email, expires_at) VALUES ($1, $2, $3, $4)`,
+		tokenHash, userID, email, expiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConfirmEmailVerification consumes the token and marks the address as
+// verified, provided the user has not changed it since the token was issued.
+func (s *PostgresStorage) ConfirmEmailVerification(tokenHash string) (*models.User, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var v struct {
 		UserID    uuid.UUID `db:"user_id"`
+		Email     string    `db:"email"`
 		ExpiresAt time.Time `db:"expires_at"`
 	}
+	err = tx.Get(&v,
+		`DELETE FROM email_verifications WHERE token_hash = $1 RETURNING user_id, email, expires_at`,
+		tokenHash,
+	)
+	if err != nil {
+		return nil, ErrVerificationToken
+	}
+
+	if time.Now().After(v.ExpiresAt) {
+		tx.Commit()
+		return nil, ErrVerificationToken
+	}
 
-	err := s.db.Get(&rt,
-		`SELECT user_id, expires_at FROM refresh_tokens WHERE token = $1`,
-		token,
+	var user models.User
+	err = tx.Get(&user,
+		`UPDATE users SET email_verified = TRUE WHERE id = $1 AND email = $2 RETURNING *`,
+		v.UserID, v.Email,
 	)
 	if err != nil {
-		return uuid.Nil, errors.New("token not found")
+		return nil, ErrVerificationToken
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ChangePassword sets a new password and revokes every session of the user,
+// returning the ids of those that were still active. Pending resets are
+// dropped as well, so an old reset link cannot undo the change.
+func (s *PostgresStorage) ChangePassword(userID uuid.UUID, password string) (*models.User, []uuid.UUID, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, nil, err
 	}
+	defer tx.Rollback()
 
-	if time.Now().After(rt.ExpiresAt) {
-		s.DeleteRefreshToken(token)
-		return uuid.Nil, errors.New("token expired")
+	user, ids, err := setPassword(tx, userID, password)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return rt.UserID, nil
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return user, ids, nil
 }
 
-func (s *PostgresStorage) DeleteRefreshToken(token string) error {
-	_, err := s.db.Exec(`DELETE FROM refresh_tokens WHERE token = $1`, token)
-	return err
+// SavePasswordReset stores a pending reset, replacing any earlier one of the
+// same user so that only the latest link works.
+func (s *PostgresStorage) SavePasswordReset(userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM password_resets WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO password_resets (token_hash, user_id, expires_at) VALUES ($1, $2, $3)`,
+		tokenHash, userID, expiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ResetPassword consumes the reset token and sets the new password the same
+// way ChangePassword does. Each token can be used once; an expired one is
+// deleted when presented.
+func (s *PostgresStorage) ResetPassword(tokenHash, password string) (*models.User, []uuid.UUID, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var r struct {
+		UserID    uuid.UUID `db:"user_id"`
+		ExpiresAt time.Time `db:"expires_at"`
+	}
+	err = tx.Get(&r,
+		`DELETE FROM password_resets WHERE token_hash = $1 RETURNING user_id, expires_at`,
+		tokenHash,
+	)
+	if err != nil {
+		return nil, nil, ErrResetToken
+	}
+
+	if time.Now().After(r.ExpiresAt) {
+		tx.Commit()
+		return nil, nil, ErrResetToken
+	}
+
+	user, ids, err := setPassword(tx, r.UserID, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return user, ids, nil
+}
+
+// setPassword stores the hash of a new password, drops pending resets and
+// revokes every session of the user.
+func setPassword(tx *sqlx.Tx, userID uuid.UUID, password string) (*models.User, []uuid.UUID, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var user models.User
+	err = tx.Get(&user,
+		`UPDATE users SET password_hash = $2 WHERE id = $1 RETURNING *`,
+		userID, string(hashedPassword),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, errors.New("user not found")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM password_resets WHERE user_id = $1`, userID); err != nil {
+		return nil, nil, err
+	}
+
+	ids, err := revokeUserSessions(tx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &user, ids, nil
+}
+
+// GetTOTP returns the user's TOTP secret, confirmed or still pending, or
+// ErrMFANotEnabled when enrollment has not been started.
+func (s *PostgresStorage) GetTOTP(userID uuid.UUID) (*models.TOTP, error) {
+	var t models.TOTP
+	err := s.db.Get(&t, `SELECT * FROM user_totp WHERE user_id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMFANotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SaveTOTPSecret starts enrollment, replacing a pending secret. Once a
+// secret is confirmed it is kept until DisableTOTP and ErrMFAEnabled is
+// returned instead.
+func (s *PostgresStorage) SaveTOTPSecret(userID uuid.UUID, secret string) error {
+	result, err := s.db.Exec(
+		`INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		 ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = NOW()
+		 WHERE user_totp.confirmed_at IS NULL`,
+		userID, secret,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrMFAEnabled
+	}
+	return nil
+}
+
+// EnableTOTP confirms a pending secret with the step of the code that
+// proved it works, and stores the first set of recovery codes.
+func (s *PostgresStorage) EnableTOTP(userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`UPDATE user_totp SET confirmed_at = NOW(), last_used_step = $2 WHERE user_id = $1 AND confirmed_at IS NULL`,
+		userID, step,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrMFAEnabled
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseTOTPStep records that a code for step was accepted. It reports false
+// when a code for that step or a later one has been accepted already.
+func (s *PostgresStorage) UseTOTPStep(userID uuid.UUID, step int64) (bool, error) {
+	result, err := s.db.Exec(
+		`UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`,
+		userID, step,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// UseRecoveryCode spends a recovery code. It reports false when the code
+// does not exist or was used before.
+func (s *PostgresStorage) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	result, err := s.db.Exec(
+		`UPDATE recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// ReplaceRecoveryCodes invalidates every recovery code of the user, used or
+// not, in favour of a new set.
+func (s *PostgresStorage) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sqlx.Tx, userID uuid.UUID, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(
+			`INSERT INTO recovery_codes (code_hash, user_id) VALUES ($1, $2)`,
+			hash, userID,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisableTOTP turns two-factor authentication off and drops the recovery
+// codes with it.
+func (s *PostgresStorage) DisableTOTP(userID uuid.UUID) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrMFANotEnabled
+	}
+
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kiribu/jwt-practice/pkg/jwtkeys"
+)
+
+type KeyRotationConfig struct {
+	// Dir holds one <kid>.pem file per key
+	Dir string
+	// Algorithm of the keys the worker generates
+	Algorithm string
+	// RotationInterval is how long a key signs before a new one is
+	// generated. Zero leaves rotation to whoever manages Dir.
+	RotationInterval time.Duration
+	// Overlap is how long a superseded key still verifies tokens. It has to
+	// cover the lifetime of an access token.
+	Overlap time.Duration
+	// ReloadInterval is how often Dir is rescanned for changes
+	ReloadInterval time.Duration
+}
+
+// KeyRotationWorker keeps the signing keyset in sync with a key directory:
+// it picks up keys added or removed there, generates a new key when the
+// current one is due for rotation and deletes keys past their overlap.
+type KeyRotationWorker struct {
+	keys   *jwtkeys.Keyset
+	config KeyRotationConfig
+}
+
+func NewKeyRotationWorker(keys *jwtkeys.Keyset, config KeyRotationConfig) *KeyRotationWorker {
+	return &KeyRotationWorker{
+		keys:   keys,
+		config: config,
+	}
+}
+
+func (w *KeyRotationWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.config.ReloadInterval)
+	defer ticker.Stop()
+
+	slog.Info("Key rotation worker started", "dir", w.config.Dir, "rotation_interval", w.config.RotationInterval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping key rotation worker...")
+			return
+		case <-ticker.C:
+			if err := w.Reload(); err != nil {
+				slog.Error("Failed to reload signing keys, keeping the current ones", "error", err)
+			}
+		}
+	}
+}
+
+// Reload reads the key directory, rotates if due and replaces the keyset.
+// On error the keyset is left as it was.
+func (w *KeyRotationWorker) Reload() error {
+	keys, err := jwtkeys.LoadDir(w.config.Dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if w.rotationDue(keys, now) {
+		key, err := jwtkeys.Generate(w.config.Algorithm)
+		if err != nil {
+			return err
+		}
+		if err := jwtkeys.WriteKey(w.config.Dir, key); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		slog.Info("Generated new signing key", "kid", key.ID, "alg", key.Algorithm)
+	}
+
+	active, expired := jwtkeys.Active(keys, now, w.config.Overlap)
+	if _, err := jwtkeys.NewKeyset(active...).SigningKey(); err != nil {
+		return err
+	}
+
+	if w.config.RotationInterval > 0 {
+		for _, k := range expired {
+			if err := jwtkeys.RemoveKey(w.config.Dir, k.ID); err != nil {
+				slog.Warn("Failed to remove expired signing key", "kid", k.ID, "error", err)
+				continue
+			}
+			slog.Info("Removed expired signing key", "kid", k.ID)
+		}
+	}
+
+	w.keys.Replace(active)
+	return nil
+}
+
+func (w *KeyRotationWorker) rotationDue(keys []*jwtkeys.Key, now time.Time) bool {
+	var newest *jwtkeys.Key
+	for _, k := range keys {
+		if k.CanSign() && (newest == nil || k.CreatedAt.After(newest.CreatedAt)) {
+			newest = k
+		}
+	}
+	if newest == nil {
+		// An empty directory is seeded even with rotation turned off
+		return true
+	}
+	return w.config.RotationInterval > 0 && now.Sub(newest.CreatedAt) >= w.config.RotationInterval
+}
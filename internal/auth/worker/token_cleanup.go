@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kiribu/jwt-practice/internal/auth/storage"
+)
+
+// TokenCleanupWorker purges expired refresh tokens and the sessions left
+// without any. Clients stop presenting a token once it expires, so without
+// it the rows would stay forever.
+type TokenCleanupWorker struct {
+	storage  storage.Storage
+	interval time.Duration
+}
+
+func NewTokenCleanupWorker(storage storage.Storage, interval time.Duration) *TokenCleanupWorker {
+	return &TokenCleanupWorker{
+		storage:  storage,
+		interval: interval,
+	}
+}
+
+func (w *TokenCleanupWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	slog.Info("Token cleanup worker started", "interval", w.interval)
+
+	w.purge()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping token cleanup worker...")
+			return
+		case <-ticker.C:
+			w.purge()
+		}
+	}
+}
+
+func (w *TokenCleanupWorker) purge() {
+	deleted, err := w.storage.DeleteExpiredRefreshTokens()
+	if err != nil {
+		slog.Error("Failed to delete expired refresh tokens", "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.Info("Deleted expired refresh tokens", "count", deleted)
+	}
+
+	deleted, err = w.storage.DeleteStaleSessions()
+	if err != nil {
+		slog.Error("Failed to delete stale sessions", "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.Info("Deleted stale sessions", "count", deleted)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/kiribu/jwt-practice/internal/analytics/grpc/pb"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
@@ -23,6 +24,7 @@ func NewAnalyticsClient(addr string) (*AnalyticsClient, error) {
 	conn, err := grpc.DialContext(ctx, addr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithBlock(),
+		grpc.WithUnaryInterceptor(i18n.UnaryClientInterceptor()),
 	)
 	if err != nil {
 		return nil, err
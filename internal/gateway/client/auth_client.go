@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/kiribu/jwt-practice/internal/auth/grpc/pb"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"github.com/kiribu/jwt-practice/pkg/jwtkeys"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
@@ -15,14 +17,18 @@ type AuthClient struct {
 	client pb.AuthServiceClient
 }
 
-func NewAuthClient(addr string) (*AuthClient, error) {
+// NewAuthClient connects to the auth service. opts are added to the
+// default dial options, e.g. to dial an in-process server in tests.
+func NewAuthClient(addr string, opts ...grpc.DialOption) (*AuthClient, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	conn, err := grpc.DialContext(ctx, addr,
+	opts = append([]grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithBlock(),
-	)
+		grpc.WithUnaryInterceptor(i18n.UnaryClientInterceptor()),
+	}, opts...)
+	conn, err := grpc.DialContext(ctx, addr, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -43,16 +49,20 @@ func (c *AuthClient) Register(ctx context.Context, username, password string) (*
 	})
 }
 
-func (c *AuthClient) Login(ctx context.Context, username, password string) (*pb.LoginResponse, error) {
+func (c *AuthClient) Login(ctx context.Context, username, password, userAgent, ip string) (*pb.LoginResponse, error) {
 	return c.client.Login(ctx, &pb.LoginRequest{
-		Username: username,
-		Password: password,
+		Username:  username,
+		Password:  password,
+		UserAgent: userAgent,
+		Ip:        ip,
 	})
 }
 
-func (c *AuthClient) Refresh(ctx context.Context, refreshToken string) (*pb.RefreshResponse, error) {
+func (c *AuthClient) Refresh(ctx context.Context, refreshToken, userAgent, ip string) (*pb.RefreshResponse, error) {
 	return c.client.Refresh(ctx, &pb.RefreshRequest{
 		RefreshToken: refreshToken,
+		UserAgent:    userAgent,
+		Ip:           ip,
 	})
 }
 
@@ -73,3 +83,123 @@ func (c *AuthClient) GetProfile(ctx context.Context, username string) (*pb.UserR
 		Username: username,
 	})
 }
+
+func (c *AuthClient) UpdateProfile(ctx context.Context, userID, timezone string) (*pb.UserResponse, error) {
+	return c.client.UpdateProfile(ctx, &pb.UpdateProfileRequest{
+		UserId:   userID,
+		Timezone: timezone,
+	})
+}
+
+func (c *AuthClient) SetEmail(ctx context.Context, userID, email string) (*pb.SetEmailResponse, error) {
+	return c.client.SetEmail(ctx, &pb.SetEmailRequest{
+		UserId: userID,
+		Email:  email,
+	})
+}
+
+func (c *AuthClient) VerifyEmail(ctx context.Context, token string) (*pb.VerifyEmailResponse, error) {
+	return c.client.VerifyEmail(ctx, &pb.VerifyEmailRequest{
+		Token: token,
+	})
+}
+
+func (c *AuthClient) ListSessions(ctx context.Context, userID, currentSessionID string) (*pb.ListSessionsResponse, error) {
+	return c.client.ListSessions(ctx, &pb.ListSessionsRequest{
+		UserId:           userID,
+		CurrentSessionId: currentSessionID,
+	})
+}
+
+func (c *AuthClient) RevokeSession(ctx context.Context, userID, sessionID string) (*pb.RevokeSessionResponse, error) {
+	return c.client.RevokeSession(ctx, &pb.RevokeSessionRequest{
+		UserId:    userID,
+		SessionId: sessionID,
+	})
+}
+
+func (c *AuthClient) RevokeAllSessions(ctx context.Context, userID string) (*pb.RevokeAllSessionsResponse, error) {
+	return c.client.RevokeAllSessions(ctx, &pb.RevokeAllSessionsRequest{
+		UserId: userID,
+	})
+}
+
+func (c *AuthClient) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) (*pb.PasswordResponse, error) {
+	return c.client.ChangePassword(ctx, &pb.ChangePasswordRequest{
+		UserId:          userID,
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+	})
+}
+
+func (c *AuthClient) RequestPasswordReset(ctx context.Context, email string) (*pb.PasswordResponse, error) {
+	return c.client.RequestPasswordReset(ctx, &pb.RequestPasswordResetRequest{
+		Email: email,
+	})
+}
+
+func (c *AuthClient) ResetPassword(ctx context.Context, token, newPassword string) (*pb.PasswordResponse, error) {
+	return c.client.ResetPassword(ctx, &pb.ResetPasswordRequest{
+		Token:       token,
+		NewPassword: newPassword,
+	})
+}
+
+func (c *AuthClient) VerifyMFA(ctx context.Context, mfaToken, code, userAgent, ip string) (*pb.LoginResponse, error) {
+	return c.client.VerifyMFA(ctx, &pb.VerifyMFARequest{
+		MfaToken:  mfaToken,
+		Code:      code,
+		UserAgent: userAgent,
+		Ip:        ip,
+	})
+}
+
+func (c *AuthClient) EnrollTOTP(ctx context.Context, userID string) (*pb.EnrollTOTPResponse, error) {
+	return c.client.EnrollTOTP(ctx, &pb.EnrollTOTPRequest{
+		UserId: userID,
+	})
+}
+
+func (c *AuthClient) ConfirmTOTP(ctx context.Context, userID, code string) (*pb.RecoveryCodesResponse, error) {
+	return c.client.ConfirmTOTP(ctx, &pb.ConfirmTOTPRequest{
+		UserId: userID,
+		Code:   code,
+	})
+}
+
+func (c *AuthClient) DisableTOTP(ctx context.Context, userID, code string) (*pb.DisableTOTPResponse, error) {
+	return c.client.DisableTOTP(ctx, &pb.DisableTOTPRequest{
+		UserId: userID,
+		Code:   code,
+	})
+}
+
+func (c *AuthClient) RegenerateRecoveryCodes(ctx context.Context, userID, code string) (*pb.RecoveryCodesResponse, error) {
+	return c.client.RegenerateRecoveryCodes(ctx, &pb.RegenerateRecoveryCodesRequest{
+		UserId: userID,
+		Code:   code,
+	})
+}
+
+// GetJWKS returns the public keys access tokens are signed with.
+func (c *AuthClient) GetJWKS(ctx context.Context) (jwtkeys.JWKS, error) {
+	resp, err := c.client.GetJWKS(ctx, &pb.GetJWKSRequest{})
+	if err != nil {
+		return jwtkeys.JWKS{}, err
+	}
+
+	jwks := jwtkeys.JWKS{Keys: make([]jwtkeys.JWK, len(resp.Keys))}
+	for i, k := range resp.Keys {
+		jwks.Keys[i] = jwtkeys.JWK{
+			Kty: k.Kty,
+			Kid: k.Kid,
+			Alg: k.Alg,
+			Use: k.Use,
+			Crv: k.Crv,
+			X:   k.X,
+			N:   k.N,
+			E:   k.E,
+		}
+	}
+	return jwks, nil
+}
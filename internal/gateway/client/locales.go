@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+)
+
+const (
+	maxCachedLocales = 10000
+
+	// localeLookupTimeout keeps a slow notification service from holding up
+	// every request; the request then falls back to Accept-Language
+	localeLookupTimeout = time.Second
+)
+
+// Locales remembers the language users have chosen in their notification
+// preferences, so that responses to them are in it. Changes made through
+// this gateway apply at once, those made through another one within ttl.
+type Locales struct {
+	notifications *NotificationClient
+	ttl           time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedLocale
+}
+
+type cachedLocale struct {
+	locale i18n.Locale
+	// chosen is false while the user's preferences are the defaults
+	chosen    bool
+	expiresAt time.Time
+}
+
+func NewLocales(notifications *NotificationClient, ttl time.Duration) *Locales {
+	return &Locales{
+		notifications: notifications,
+		ttl:           ttl,
+		entries:       make(map[string]cachedLocale),
+	}
+}
+
+// UserLocale returns the language the user has chosen. ok is false when
+// they never saved preferences or they cannot be fetched right now.
+func (l *Locales) UserLocale(ctx context.Context, userID string) (i18n.Locale, bool) {
+	if userID == "" {
+		return "", false
+	}
+	if entry, ok := l.get(userID); ok {
+		return entry.locale, entry.chosen
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, localeLookupTimeout)
+	defer cancel()
+
+	prefs, err := l.notifications.GetPreferences(ctx, userID)
+	if err != nil {
+		slog.Warn("Failed to fetch user locale", "user_id", userID, "error", err)
+		return "", false
+	}
+
+	// Defaults come without an update time and are no choice of the user
+	locale, ok := i18n.Parse(prefs.Locale)
+	entry := cachedLocale{locale: locale, chosen: ok && prefs.UpdatedAt != ""}
+	l.set(userID, entry)
+	return entry.locale, entry.chosen
+}
+
+// Forget drops what is known about the user, to be called once their
+// preferences change.
+func (l *Locales) Forget(userID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, userID)
+}
+
+func (l *Locales) get(userID string) (cachedLocale, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[userID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(l.entries, userID)
+		return cachedLocale{}, false
+	}
+	return entry, true
+}
+
+func (l *Locales) set(userID string, entry cachedLocale) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if len(l.entries) >= maxCachedLocales {
+		for id, e := range l.entries {
+			if now.After(e.expiresAt) {
+				delete(l.entries, id)
+			}
+		}
+	}
+
+	entry.expiresAt = now.Add(l.ttl)
+	l.entries[userID] = entry
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kiribu/jwt-practice/internal/notification/grpc/pb"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// fakePreferences answers with the preferences in prefs, or the defaults
+// for users missing from it, and counts the lookups.
+type fakePreferences struct {
+	pb.UnimplementedNotificationServiceServer
+	prefs   map[string]*pb.PreferencesResponse
+	lookups atomic.Int32
+}
+
+func (s *fakePreferences) GetPreferences(ctx context.Context, req *pb.GetPreferencesRequest) (*pb.PreferencesResponse, error) {
+	s.lookups.Add(1)
+	if prefs, ok := s.prefs[req.UserId]; ok {
+		return prefs, nil
+	}
+	return &pb.PreferencesResponse{Locale: string(i18n.Default)}, nil
+}
+
+func newTestNotificationClient(t *testing.T, server pb.NotificationServiceServer) *NotificationClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	pb.RegisterNotificationServiceServer(s, server)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	c, err := NewNotificationClient("bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+	if err != nil {
+		t.Fatalf("dial notification service: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestUserLocaleFromPreferences(t *testing.T) {
+	server := &fakePreferences{prefs: map[string]*pb.PreferencesResponse{
+		"user-ru": {Locale: "ru", UpdatedAt: time.Now().Format(time.RFC3339)},
+	}}
+	locales := NewLocales(newTestNotificationClient(t, server), time.Minute)
+	ctx := context.Background()
+
+	if locale, ok := locales.UserLocale(ctx, "user-ru"); !ok || locale != i18n.Russian {
+		t.Errorf("saved preferences: locale = %q, %v; want ru, true", locale, ok)
+	}
+	if _, ok := locales.UserLocale(ctx, "user-default"); ok {
+		t.Error("default preferences are taken for a choice")
+	}
+
+	// Both answers are remembered until forgotten
+	locales.UserLocale(ctx, "user-ru")
+	locales.UserLocale(ctx, "user-default")
+	if n := server.lookups.Load(); n != 2 {
+		t.Errorf("%d lookups, want 2", n)
+	}
+
+	server.prefs["user-default"] = &pb.PreferencesResponse{Locale: "ru", UpdatedAt: time.Now().Format(time.RFC3339)}
+	locales.Forget("user-default")
+	if locale, ok := locales.UserLocale(ctx, "user-default"); !ok || locale != i18n.Russian {
+		t.Errorf("after an update: locale = %q, %v; want ru, true", locale, ok)
+	}
+}
+
+func TestUserLocaleFallsBackWhenServiceFails(t *testing.T) {
+	// Nothing implemented: every lookup fails
+	locales := NewLocales(newTestNotificationClient(t, &pb.UnimplementedNotificationServiceServer{}), time.Minute)
+
+	if _, ok := locales.UserLocale(context.Background(), "user-1"); ok {
+		t.Error("locale reported although the lookup failed")
+	}
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kiribu/jwt-practice/internal/notification/grpc/pb"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type NotificationClient struct {
+	conn   *grpc.ClientConn
+	client pb.NotificationServiceClient
+}
+
+// NewNotificationClient connects to the notification service. opts are
+// added to the default dial options, e.g. to dial an in-process server in
+// tests.
+func NewNotificationClient(addr string, opts ...grpc.DialOption) (*NotificationClient, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	slog.Info("Connecting to Notification Service", "addr", addr)
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock(),
+		grpc.WithUnaryInterceptor(i18n.UnaryClientInterceptor()),
+	}, opts...)
+	conn, err := grpc.DialContext(ctx, addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Connected to Notification Service", "addr", addr)
+
+	return &NotificationClient{
+		conn:   conn,
+		client: pb.NewNotificationServiceClient(conn),
+	}, nil
+}
+
+func (c *NotificationClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *NotificationClient) CreateWebhook(ctx context.Context, userID, url, secret string) (*pb.WebhookResponse, error) {
+	return c.client.CreateWebhook(ctx, &pb.CreateWebhookRequest{
+		UserId: userID,
+		Url:    url,
+		Secret: secret,
+	})
+}
+
+func (c *NotificationClient) ListWebhooks(ctx context.Context, userID string) (*pb.ListWebhooksResponse, error) {
+	return c.client.ListWebhooks(ctx, &pb.ListWebhooksRequest{
+		UserId: userID,
+	})
+}
+
+func (c *NotificationClient) DeleteWebhook(ctx context.Context, userID, id string) (*pb.DeleteWebhookResponse, error) {
+	return c.client.DeleteWebhook(ctx, &pb.WebhookRequest{
+		UserId: userID,
+		Id:     id,
+	})
+}
+
+func (c *NotificationClient) EnableWebhook(ctx context.Context, userID, id string) (*pb.WebhookResponse, error) {
+	return c.client.EnableWebhook(ctx, &pb.WebhookRequest{
+		UserId: userID,
+		Id:     id,
+	})
+}
+
+func (c *NotificationClient) ListWebhookDeliveries(ctx context.Context, userID, id string, limit int32) (*pb.ListWebhookDeliveriesResponse, error) {
+	return c.client.ListWebhookDeliveries(ctx, &pb.ListWebhookDeliveriesRequest{
+		UserId:    userID,
+		WebhookId: id,
+		Limit:     limit,
+	})
+}
+
+func (c *NotificationClient) SendTestWebhook(ctx context.Context, userID, id string) (*pb.WebhookDeliveryResponse, error) {
+	return c.client.SendTestWebhook(ctx, &pb.WebhookRequest{
+		UserId: userID,
+		Id:     id,
+	})
+}
+
+func (c *NotificationClient) GetPreferences(ctx context.Context, userID string) (*pb.PreferencesResponse, error) {
+	return c.client.GetPreferences(ctx, &pb.GetPreferencesRequest{
+		UserId: userID,
+	})
+}
+
+func (c *NotificationClient) UpdatePreferences(ctx context.Context, req *pb.UpdatePreferencesRequest) (*pb.PreferencesResponse, error) {
+	return c.client.UpdatePreferences(ctx, req)
+}
+
+func (c *NotificationClient) ListInbox(ctx context.Context, userID string, limit int32, cursor string, unreadOnly bool) (*pb.ListInboxResponse, error) {
+	return c.client.ListInbox(ctx, &pb.ListInboxRequest{
+		UserId:     userID,
+		Limit:      limit,
+		Cursor:     cursor,
+		UnreadOnly: unreadOnly,
+	})
+}
+
+func (c *NotificationClient) MarkInboxItemRead(ctx context.Context, userID, id string) (*pb.InboxItemResponse, error) {
+	return c.client.MarkInboxItemRead(ctx, &pb.InboxItemRequest{
+		UserId: userID,
+		Id:     id,
+	})
+}
+
+func (c *NotificationClient) MarkAllInboxRead(ctx context.Context, userID string) (*pb.MarkAllInboxReadResponse, error) {
+	return c.client.MarkAllInboxRead(ctx, &pb.MarkAllInboxReadRequest{
+		UserId: userID,
+	})
+}
+
+func (c *NotificationClient) DeleteInboxItem(ctx context.Context, userID, id string) (*pb.DeleteInboxItemResponse, error) {
+	return c.client.DeleteInboxItem(ctx, &pb.InboxItemRequest{
+		UserId: userID,
+		Id:     id,
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/kiribu/jwt-practice/internal/reminder/grpc/pb"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
@@ -23,6 +24,7 @@ func NewReminderClient(addr string) (*ReminderClient, error) {
 	conn, err := grpc.DialContext(ctx, addr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithBlock(),
+		grpc.WithUnaryInterceptor(i18n.UnaryClientInterceptor()),
 	)
 	if err != nil {
 		return nil, err
@@ -39,12 +41,13 @@ func (c *ReminderClient) Close() error {
 	return c.conn.Close()
 }
 
-func (c *ReminderClient) Create(ctx context.Context, userID string, title, description, remindAt string) (*pb.ReminderResponse, error) {
+func (c *ReminderClient) Create(ctx context.Context, userID string, title, description, priority, remindAt string) (*pb.ReminderResponse, error) {
 	return c.client.CreateReminder(ctx, &pb.CreateReminderRequest{
 		UserId:      userID,
 		Title:       title,
 		Description: description,
 		RemindAt:    remindAt,
+		Priority:    priority,
 	})
 }
 
@@ -62,13 +65,14 @@ func (c *ReminderClient) GetByID(ctx context.Context, userID, id string) (*pb.Re
 	})
 }
 
-func (c *ReminderClient) Update(ctx context.Context, userID, id string, title, description, remindAt string) (*pb.ReminderResponse, error) {
+func (c *ReminderClient) Update(ctx context.Context, userID, id string, title, description, priority, remindAt string) (*pb.ReminderResponse, error) {
 	return c.client.UpdateReminder(ctx, &pb.UpdateReminderRequest{
 		UserId:      userID,
 		Id:          id,
 		Title:       title,
 		Description: description,
 		RemindAt:    remindAt,
+		Priority:    priority,
 	})
 }
 
@@ -2,20 +2,31 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/kiribu/jwt-practice/internal/gateway/client"
+	"github.com/kiribu/jwt-practice/internal/gateway/tokens"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
 	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// tokenRecheckInterval is how often a long-lived request asks the auth
+// service about its token when revocations cannot be followed locally.
+const tokenRecheckInterval = 30 * time.Second
+
 type AuthHandler struct {
 	authClient *client.AuthClient
+	// verifier is nil when every token is checked by the auth service
+	verifier *tokens.Verifier
 }
 
-func NewAuthHandler(authClient *client.AuthClient) *AuthHandler {
-	return &AuthHandler{authClient: authClient}
+func NewAuthHandler(authClient *client.AuthClient, verifier *tokens.Verifier) *AuthHandler {
+	return &AuthHandler{authClient: authClient, verifier: verifier}
 }
 
 type Credentials struct {
@@ -27,14 +38,31 @@ type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+type UpdateProfileRequest struct {
+	Timezone string `json:"timezone"`
+}
+
+type SetEmailRequest struct {
+	Email string `json:"email"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" query:"token"`
+}
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// translate renders a catalog message in the language of the request.
+func translate(c echo.Context, key string) string {
+	return i18n.T(i18n.FromContext(c.Request().Context()), key)
+}
+
 func (h *AuthHandler) Register(c echo.Context) error {
 	var creds Credentials
 	if err := c.Bind(&creds); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
@@ -51,15 +79,15 @@ func (h *AuthHandler) Register(c echo.Context) error {
 func (h *AuthHandler) Login(c echo.Context) error {
 	var creds Credentials
 	if err := c.Bind(&creds); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
 	defer cancel()
 
-	resp, err := h.authClient.Login(ctx, creds.Username, creds.Password)
+	resp, err := h.authClient.Login(ctx, creds.Username, creds.Password, c.Request().UserAgent(), c.RealIP())
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid credentials"})
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: translate(c, "gateway.invalid_credentials")})
 	}
 
 	return c.JSON(http.StatusOK, resp)
@@ -68,15 +96,15 @@ func (h *AuthHandler) Login(c echo.Context) error {
 func (h *AuthHandler) Refresh(c echo.Context) error {
 	var req RefreshRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
 	defer cancel()
 
-	resp, err := h.authClient.Refresh(ctx, req.RefreshToken)
+	resp, err := h.authClient.Refresh(ctx, req.RefreshToken, c.Request().UserAgent(), c.RealIP())
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid refresh token"})
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: translate(c, "gateway.invalid_refresh_token")})
 	}
 
 	return c.JSON(http.StatusOK, resp)
@@ -90,21 +118,87 @@ func (h *AuthHandler) Profile(c echo.Context) error {
 
 	userProfile, err := h.authClient.GetProfile(ctx, username)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch profile"})
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.profile_fetch_failed")})
 	}
 
 	return c.JSON(http.StatusOK, userProfile)
 }
 
+func (h *AuthHandler) UpdateProfile(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	var req UpdateProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	userProfile, err := h.authClient.UpdateProfile(ctx, userID, req.Timezone)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: status.Convert(err).Message()})
+	}
+
+	return c.JSON(http.StatusOK, userProfile)
+}
+
+func (h *AuthHandler) SetEmail(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	var req SetEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.SetEmail(ctx, userID, req.Email)
+	if err != nil {
+		st := status.Convert(err)
+		switch st.Code() {
+		case codes.AlreadyExists:
+			return c.JSON(http.StatusConflict, ErrorResponse{Error: st.Message()})
+		case codes.InvalidArgument:
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: st.Message()})
+		default:
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.email_update_failed")})
+		}
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{"message": resp.Message})
+}
+
+func (h *AuthHandler) VerifyEmail(c echo.Context) error {
+	var req VerifyEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.VerifyEmail(ctx, req.Token)
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: status.Convert(err).Message()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.email_verify_failed")})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": resp.Message})
+}
+
 func (h *AuthHandler) Logout(c echo.Context) error {
 	authHeader := c.Request().Header.Get("Authorization")
 	if authHeader == "" {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Authorization header is required"})
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: translate(c, "gateway.authorization_required")})
 	}
 
 	parts := strings.Split(authHeader, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid Authorization header format"})
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: translate(c, "gateway.invalid_authorization")})
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
@@ -112,10 +206,10 @@ func (h *AuthHandler) Logout(c echo.Context) error {
 
 	_, err := h.authClient.Logout(ctx, parts[1])
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to logout"})
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.logout_failed")})
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "Successfully logged out"})
+	return c.JSON(http.StatusOK, map[string]string{"message": translate(c, "gateway.logged_out")})
 }
 
 // AuthMiddleware validates the JWT token
@@ -123,25 +217,115 @@ func (h *AuthHandler) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		authHeader := c.Request().Header.Get("Authorization")
 		if authHeader == "" {
-			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Authorization header is required"})
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: translate(c, "gateway.authorization_required")})
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid Authorization header format"})
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: translate(c, "gateway.invalid_authorization")})
+		}
+
+		return h.authenticate(c, parts[1], next)
+	}
+}
+
+// StreamAuthMiddleware also accepts the token in the access_token query
+// parameter, since browsers cannot set headers on an EventSource.
+func (h *AuthHandler) StreamAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().Header.Get("Authorization") != "" {
+			return h.AuthMiddleware(next)(c)
+		}
+
+		token := c.QueryParam("access_token")
+		if token == "" {
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: translate(c, "gateway.stream_authorization_required")})
 		}
+		return h.authenticate(c, token, next)
+	}
+}
 
-		ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
-		defer cancel()
+func (h *AuthHandler) authenticate(c echo.Context, token string, next echo.HandlerFunc) error {
+	identity, err := h.verify(c.Request().Context(), token)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: translate(c, "gateway.invalid_token")})
+	}
+
+	// Add username and user_id to context
+	c.Set("username", identity.Username)
+	c.Set("user_id", identity.UserID)
+	c.Set("session_id", identity.SessionID)
+	c.Set("access_token", token)
+	c.Set("token_expires_at", identity.ExpiresAt)
+	return next(c)
+}
 
-		resp, err := h.authClient.ValidateToken(ctx, parts[1])
-		if err != nil || !resp.Valid {
-			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid token"})
+// TokenEnded returns a channel that is closed once the token that
+// authenticated c expires or is revoked. Requests that outlive a token, like
+// event streams, end on it; ordinary requests are over long before.
+func (h *AuthHandler) TokenEnded(c echo.Context) <-chan struct{} {
+	ctx := c.Request().Context()
+	token, _ := c.Get("access_token").(string)
+	expiresAt, _ := c.Get("token_expires_at").(time.Time)
+
+	ended := make(chan struct{})
+	go func() {
+		defer close(ended)
+
+		expiry := time.NewTimer(time.Until(expiresAt))
+		defer expiry.Stop()
+		recheck := time.NewTicker(tokenRecheckInterval)
+		defer recheck.Stop()
+
+		for {
+			var changed <-chan struct{}
+			if h.verifier != nil {
+				changed = h.verifier.Changed()
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-expiry.C:
+				return
+			case <-changed:
+			case <-recheck.C:
+			}
+
+			_, err := h.verify(ctx, token)
+			// A failed call to the auth service says nothing about the token
+			if _, rpcErr := status.FromError(err); err != nil && !rpcErr && ctx.Err() == nil {
+				return
+			}
 		}
+	}()
+	return ended
+}
+
+// verify checks the token in the gateway when it can, and asks the auth
+// service when the gateway cannot tell.
+func (h *AuthHandler) verify(ctx context.Context, token string) (*tokens.Identity, error) {
+	if h.verifier != nil {
+		identity, err := h.verifier.Verify(ctx, token)
+		if !errors.Is(err, tokens.ErrUncertain) {
+			return identity, err
+		}
+	}
 
-		// Add username and user_id to context
-		c.Set("username", resp.Username)
-		c.Set("user_id", resp.UserId)
-		return next(c)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Valid {
+		return nil, errors.New(resp.Error)
 	}
+	return &tokens.Identity{
+		Username:  resp.Username,
+		UserID:    resp.UserId,
+		SessionID: resp.SessionId,
+		ExpiresAt: time.Unix(resp.ExpiresAt, 0),
+	}, nil
 }
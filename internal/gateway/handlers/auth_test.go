@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/auth/grpc/pb"
+	"github.com/kiribu/jwt-practice/internal/gateway/client"
+	"github.com/kiribu/jwt-practice/internal/gateway/tokens"
+	"github.com/kiribu/jwt-practice/pkg/jwtkeys"
+	"github.com/kiribu/jwt-practice/pkg/redistest"
+	"github.com/kiribu/jwt-practice/pkg/revocation"
+	"github.com/kiribu/jwt-practice/utils"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeAuthServer validates tokens the way the auth service does, signature
+// and revocation record, without the database behind it.
+type fakeAuthServer struct {
+	pb.UnimplementedAuthServiceServer
+	keys  *jwtkeys.Keyset
+	redis *redis.Client
+}
+
+func (s *fakeAuthServer) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	claims, err := utils.ValidateAccessToken(s.keys, req.AccessToken)
+	if err != nil {
+		return &pb.ValidateTokenResponse{Valid: false, Error: err.Error()}, nil
+	}
+	revoked, err := revocation.IsRevoked(ctx, s.redis, claims.Revocation())
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return &pb.ValidateTokenResponse{Valid: false, Error: "token revoked"}, nil
+	}
+	return &pb.ValidateTokenResponse{
+		Valid:     true,
+		Username:  claims.Username,
+		UserId:    claims.UserID,
+		SessionId: claims.SessionID,
+		ExpiresAt: claims.ExpiresAt.Unix(),
+	}, nil
+}
+
+func (s *fakeAuthServer) GetJWKS(ctx context.Context, req *pb.GetJWKSRequest) (*pb.JWKSResponse, error) {
+	jwks := s.keys.JWKS()
+	resp := &pb.JWKSResponse{Keys: make([]*pb.JSONWebKey, len(jwks.Keys))}
+	for i, k := range jwks.Keys {
+		resp.Keys[i] = &pb.JSONWebKey{Kty: k.Kty, Kid: k.Kid, Alg: k.Alg, Use: k.Use, Crv: k.Crv, X: k.X, N: k.N, E: k.E}
+	}
+	return resp, nil
+}
+
+// newVerifyHandlers returns a handler that verifies tokens in the gateway,
+// one that asks the auth service over gRPC, and a token for both.
+func newVerifyHandlers(tb testing.TB) (local, remote *AuthHandler, token string) {
+	tb.Helper()
+
+	key, err := jwtkeys.Generate(jwtkeys.EdDSA)
+	if err != nil {
+		tb.Fatalf("generate key: %v", err)
+	}
+	keys := jwtkeys.NewKeyset(key)
+	token, err = utils.GenerateAccessToken(keys, "anna", uuid.NewString(), uuid.NewString())
+	if err != nil {
+		tb.Fatalf("sign token: %v", err)
+	}
+
+	redisClient := redistest.NewServer(tb).Client(tb, 0)
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterAuthServiceServer(server, &fakeAuthServer{keys: keys, redis: redisClient})
+	go server.Serve(listener)
+	tb.Cleanup(server.Stop)
+
+	authClient, err := client.NewAuthClient("bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+	if err != nil {
+		tb.Fatalf("dial auth service: %v", err)
+	}
+	tb.Cleanup(func() { authClient.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tb.Cleanup(cancel)
+	revoked := revocation.NewList(redisClient)
+	go revoked.Run(ctx)
+	verifier := tokens.NewVerifier(authClient, revoked)
+	go verifier.Run(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := verifier.Verify(ctx, token); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			tb.Fatal("verifier did not sync with the fake auth service")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return NewAuthHandler(authClient, verifier), NewAuthHandler(authClient, nil), token
+}
+
+func TestVerifyLocallyAndRemotelyAgree(t *testing.T) {
+	local, remote, token := newVerifyHandlers(t)
+
+	for name, h := range map[string]*AuthHandler{"local": local, "remote": remote} {
+		identity, err := h.verify(context.Background(), token)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if identity.Username != "anna" || identity.ExpiresAt.Before(time.Now()) {
+			t.Errorf("%s: identity = %+v", name, identity)
+		}
+		if _, err := h.verify(context.Background(), token+"x"); err == nil {
+			t.Errorf("%s: accepted a tampered token", name)
+		}
+	}
+}
+
+func benchmarkVerify(b *testing.B, local bool) {
+	localHandler, remoteHandler, token := newVerifyHandlers(b)
+	h := remoteHandler
+	if local {
+		h = localHandler
+	}
+
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := h.verify(ctx, token); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// The RPC path runs over an in-memory connection, so real deployments pay a
+// network round trip on top of what it measures.
+func BenchmarkVerifyLocal(b *testing.B) { benchmarkVerify(b, true) }
+
+func BenchmarkVerifyRPC(b *testing.B) { benchmarkVerify(b, false) }
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kiribu/jwt-practice/internal/gateway/client"
+	"github.com/kiribu/jwt-practice/internal/notification/grpc/pb"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type InboxHandler struct {
+	notificationClient *client.NotificationClient
+}
+
+func NewInboxHandler(notificationClient *client.NotificationClient) *InboxHandler {
+	return &InboxHandler{notificationClient: notificationClient}
+}
+
+type InboxItemResponse struct {
+	ID         string `json:"id"`
+	ReminderID string `json:"reminder_id"`
+	Title      string `json:"title"`
+	Body       string `json:"body"`
+	Priority   string `json:"priority"`
+	Occurrence string `json:"occurrence"`
+	Read       bool   `json:"read"`
+	ReadAt     string `json:"read_at,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type InboxResponse struct {
+	Items       []InboxItemResponse `json:"items"`
+	UnreadCount int32               `json:"unread_count"`
+	NextCursor  string              `json:"next_cursor,omitempty"`
+}
+
+func (h *InboxHandler) List(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	unreadOnly, _ := strconv.ParseBool(c.QueryParam("unread"))
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.notificationClient.ListInbox(ctx, userID, int32(limit), c.QueryParam("cursor"), unreadOnly)
+	if err != nil {
+		return inboxError(c, err)
+	}
+
+	items := make([]InboxItemResponse, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		items = append(items, toInboxItemResponse(item))
+	}
+
+	return c.JSON(http.StatusOK, InboxResponse{
+		Items:       items,
+		UnreadCount: resp.UnreadCount,
+		NextCursor:  resp.NextCursor,
+	})
+}
+
+func (h *InboxHandler) MarkRead(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	id := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.notificationClient.MarkInboxItemRead(ctx, userID, id)
+	if err != nil {
+		return inboxError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, toInboxItemResponse(resp))
+}
+
+func (h *InboxHandler) MarkAllRead(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.notificationClient.MarkAllInboxRead(ctx, userID)
+	if err != nil {
+		return inboxError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]int32{"updated": resp.Updated})
+}
+
+func (h *InboxHandler) Delete(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	id := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.notificationClient.DeleteInboxItem(ctx, userID, id)
+	if err != nil {
+		return inboxError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": resp.Message})
+}
+
+func toInboxItemResponse(item *pb.InboxItemResponse) InboxItemResponse {
+	return InboxItemResponse{
+		ID:         item.Id,
+		ReminderID: item.ReminderId,
+		Title:      item.Title,
+		Body:       item.Body,
+		Priority:   item.Priority,
+		Occurrence: item.Occurrence,
+		Read:       item.Read,
+		ReadAt:     item.ReadAt,
+		CreatedAt:  item.CreatedAt,
+	}
+}
+
+func inboxError(c echo.Context, err error) error {
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.NotFound:
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: translate(c, "gateway.inbox_item_not_found")})
+	case codes.InvalidArgument:
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: st.Message()})
+	default:
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: st.Message()})
+	}
+}
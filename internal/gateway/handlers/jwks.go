@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// JWKS serves the public keys access tokens are signed with, for services
+// and clients that verify tokens themselves.
+func (h *AuthHandler) JWKS(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	jwks, err := h.authClient.GetJWKS(ctx)
+	if err != nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: translate(c, "gateway.jwks_fetch_failed")})
+	}
+
+	// Short enough that a newly rotated key is picked up soon; verifiers
+	// should also refetch when they meet an unknown kid
+	c.Response().Header().Set("Cache-Control", "public, max-age=60")
+	return c.JSON(http.StatusOK, jwks)
+}
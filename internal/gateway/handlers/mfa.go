@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+// VerifyMFA completes a login that answered with mfa_required by checking
+// a TOTP or recovery code.
+func (h *AuthHandler) VerifyMFA(c echo.Context) error {
+	var req VerifyMFARequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.VerifyMFA(ctx, req.MFAToken, req.Code, c.Request().UserAgent(), c.RealIP())
+	if err != nil {
+		st := status.Convert(err)
+		switch st.Code() {
+		case codes.Unauthenticated:
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: st.Message()})
+		case codes.ResourceExhausted:
+			return c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: st.Message()})
+		case codes.InvalidArgument:
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: st.Message()})
+		default:
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: translate(c, "gateway.invalid_credentials")})
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// EnrollTOTP returns a new secret for an authenticator app. Two-factor
+// authentication is not on until ConfirmTOTP.
+func (h *AuthHandler) EnrollTOTP(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.EnrollTOTP(ctx, userID)
+	if err != nil {
+		return mfaError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *AuthHandler) ConfirmTOTP(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	var req MFACodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.ConfirmTOTP(ctx, userID, req.Code)
+	if err != nil {
+		return mfaError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *AuthHandler) DisableTOTP(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	var req MFACodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.DisableTOTP(ctx, userID, req.Code)
+	if err != nil {
+		return mfaError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": resp.Message})
+}
+
+func (h *AuthHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	var req MFACodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.RegenerateRecoveryCodes(ctx, userID, req.Code)
+	if err != nil {
+		return mfaError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// mfaError answers a failed two-factor settings call. A wrong code is 403
+// rather than 401: the access token itself is fine.
+func mfaError(c echo.Context, err error) error {
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.Unauthenticated:
+		return c.JSON(http.StatusForbidden, ErrorResponse{Error: st.Message()})
+	case codes.ResourceExhausted:
+		return c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: st.Message()})
+	case codes.FailedPrecondition:
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: st.Message()})
+	case codes.InvalidArgument:
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: st.Message()})
+	default:
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.mfa_failed")})
+	}
+}
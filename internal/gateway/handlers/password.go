@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// ChangePassword sets a new password and logs the user out on every device,
+// this one included.
+func (h *AuthHandler) ChangePassword(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	var req ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.ChangePassword(ctx, userID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		st := status.Convert(err)
+		switch st.Code() {
+		case codes.PermissionDenied:
+			return c.JSON(http.StatusForbidden, ErrorResponse{Error: st.Message()})
+		case codes.InvalidArgument:
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: st.Message()})
+		default:
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.password_change_failed")})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": resp.Message})
+}
+
+// RequestPasswordReset emails a reset link. The answer does not say whether
+// the address is registered.
+func (h *AuthHandler) RequestPasswordReset(c echo.Context) error {
+	var req ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.RequestPasswordReset(ctx, req.Email)
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: status.Convert(err).Message()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.password_reset_failed")})
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{"message": resp.Message})
+}
+
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+	var req ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.ResetPassword(ctx, req.Token, req.NewPassword)
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: status.Convert(err).Message()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.password_reset_failed")})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": resp.Message})
+}
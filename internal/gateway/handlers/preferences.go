@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/kiribu/jwt-practice/internal/gateway/client"
+	"github.com/kiribu/jwt-practice/internal/notification/grpc/pb"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type PreferencesHandler struct {
+	notificationClient *client.NotificationClient
+	locales            *client.Locales
+}
+
+func NewPreferencesHandler(notificationClient *client.NotificationClient, locales *client.Locales) *PreferencesHandler {
+	return &PreferencesHandler{notificationClient: notificationClient, locales: locales}
+}
+
+type PreferencesRequest struct {
+	EnabledChannels  []string          `json:"enabled_channels"`
+	PriorityChannels map[string]string `json:"priority_channels"`
+	Locale           string            `json:"locale"`
+	DigestEnabled    bool              `json:"digest_enabled"`
+}
+
+// PreferencesResponse spells out every field, empty lists and false included,
+// so that clients can render the form without knowing the defaults.
+type PreferencesResponse struct {
+	EnabledChannels   []string          `json:"enabled_channels"`
+	PriorityChannels  map[string]string `json:"priority_channels"`
+	Locale            string            `json:"locale"`
+	DigestEnabled     bool              `json:"digest_enabled"`
+	AvailableChannels []string          `json:"available_channels"`
+	UpdatedAt         string            `json:"updated_at,omitempty"`
+}
+
+func (h *PreferencesHandler) Get(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.notificationClient.GetPreferences(ctx, userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.preferences_fetch_failed")})
+	}
+
+	return c.JSON(http.StatusOK, toPreferencesResponse(resp))
+}
+
+func (h *PreferencesHandler) Update(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	var req PreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.notificationClient.UpdatePreferences(ctx, &pb.UpdatePreferencesRequest{
+		UserId:           userID,
+		EnabledChannels:  req.EnabledChannels,
+		PriorityChannels: req.PriorityChannels,
+		Locale:           req.Locale,
+		DigestEnabled:    req.DigestEnabled,
+	})
+	if err != nil {
+		st := status.Convert(err)
+		if st.Code() == codes.InvalidArgument {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: st.Message()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.preferences_update_failed")})
+	}
+	h.locales.Forget(userID)
+
+	return c.JSON(http.StatusOK, toPreferencesResponse(resp))
+}
+
+func toPreferencesResponse(p *pb.PreferencesResponse) PreferencesResponse {
+	resp := PreferencesResponse{
+		EnabledChannels:   p.EnabledChannels,
+		PriorityChannels:  p.PriorityChannels,
+		Locale:            p.Locale,
+		DigestEnabled:     p.DigestEnabled,
+		AvailableChannels: p.AvailableChannels,
+		UpdatedAt:         p.UpdatedAt,
+	}
+	if resp.EnabledChannels == nil {
+		resp.EnabledChannels = []string{}
+	}
+	if resp.PriorityChannels == nil {
+		resp.PriorityChannels = map[string]string{}
+	}
+	if resp.AvailableChannels == nil {
+		resp.AvailableChannels = []string{}
+	}
+	return resp
+}
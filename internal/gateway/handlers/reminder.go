@@ -23,25 +23,27 @@ type CreateReminderRequest struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	RemindAt    string `json:"remind_at"`
+	Priority    string `json:"priority"`
 }
 
 type UpdateReminderRequest struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	RemindAt    string `json:"remind_at"`
+	Priority    string `json:"priority"`
 }
 
 func (h *ReminderHandler) Create(c echo.Context) error {
 	userID := c.Get("user_id").(string)
 	var req CreateReminderRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
 	defer cancel()
 
-	resp, err := h.reminderClient.Create(ctx, userID, req.Title, req.Description, req.RemindAt)
+	resp, err := h.reminderClient.Create(ctx, userID, req.Title, req.Description, req.Priority, req.RemindAt)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
@@ -74,7 +76,7 @@ func (h *ReminderHandler) Get(c echo.Context) error {
 
 	resp, err := h.reminderClient.GetByID(ctx, userID, id)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Reminder not found"})
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: translate(c, "gateway.reminder_not_found")})
 	}
 
 	return c.JSON(http.StatusOK, resp)
@@ -87,13 +89,13 @@ func (h *ReminderHandler) Update(c echo.Context) error {
 
 	var req UpdateReminderRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
 	defer cancel()
 
-	resp, err := h.reminderClient.Update(ctx, userID, id, req.Title, req.Description, req.RemindAt)
+	resp, err := h.reminderClient.Update(ctx, userID, id, req.Title, req.Description, req.Priority, req.RemindAt)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
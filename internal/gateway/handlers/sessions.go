@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *AuthHandler) ListSessions(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	sessionID := c.Get("session_id").(string)
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.ListSessions(ctx, userID, sessionID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.sessions_fetch_failed")})
+	}
+
+	return c.JSON(http.StatusOK, resp.Sessions)
+}
+
+func (h *AuthHandler) RevokeSession(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	id := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.RevokeSession(ctx, userID, id)
+	if err != nil {
+		st := status.Convert(err)
+		switch st.Code() {
+		case codes.NotFound:
+			return c.JSON(http.StatusNotFound, ErrorResponse{Error: translate(c, "gateway.session_not_found")})
+		case codes.InvalidArgument:
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: st.Message()})
+		default:
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.session_revoke_failed")})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": resp.Message})
+}
+
+// RevokeAllSessions logs the user out on every device, this one included.
+func (h *AuthHandler) RevokeAllSessions(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.authClient.RevokeAllSessions(ctx, userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.session_revoke_failed")})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{"message": resp.Message, "revoked": resp.Revoked})
+}
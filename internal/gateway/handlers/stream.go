@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/pkg/live"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	streamHeartbeat  = 25 * time.Second
+	streamRetryDelay = 3 * time.Second
+)
+
+// TokenWatcher tells when the token a request was authenticated with stops
+// being valid.
+type TokenWatcher interface {
+	TokenEnded(c echo.Context) <-chan struct{}
+}
+
+type StreamHandler struct {
+	hub    *live.Hub
+	tokens TokenWatcher
+}
+
+func NewStreamHandler(hub *live.Hub, tokens TokenWatcher) *StreamHandler {
+	return &StreamHandler{hub: hub, tokens: tokens}
+}
+
+// Stream pushes new inbox items as Server-Sent Events. A client that
+// reconnects with Last-Event-ID first gets everything it missed since then.
+// The stream ends when its token expires or is revoked, so the client has to
+// reconnect with a current one.
+func (h *StreamHandler) Stream(c echo.Context) error {
+	userID, err := uuid.Parse(c.Get("user_id").(string))
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: translate(c, "gateway.invalid_user")})
+	}
+
+	lastID := c.Request().Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.QueryParam("last_event_id")
+	}
+
+	ctx := c.Request().Context()
+
+	// Subscribe before replaying so nothing published in between is lost;
+	// duplicates are skipped by ID below.
+	msgs, unsubscribe := h.hub.Subscribe(userID)
+	defer unsubscribe()
+
+	var backlog []live.Message
+	if lastID != "" {
+		backlog, err = h.hub.Replay(ctx, userID, lastID)
+		if errors.Is(err, live.ErrInvalidID) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_last_event_id")})
+		}
+		if err != nil {
+			slog.Error("Failed to replay live messages", "user_id", userID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: translate(c, "gateway.replay_failed")})
+		}
+	}
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetryDelay.Milliseconds())
+	for _, m := range backlog {
+		writeEvent(w, m)
+		lastID = m.ID
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	tokenEnded := h.tokens.TokenEnded(c)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tokenEnded:
+			slog.Info("Closing stream, token no longer valid", "user_id", userID)
+			return nil
+		case m, ok := <-msgs:
+			if !ok {
+				// Dropped for falling behind, the client reconnects and replays
+				return nil
+			}
+			if lastID != "" && !live.After(m.ID, lastID) {
+				continue
+			}
+			writeEvent(w, m)
+			lastID = m.ID
+			w.Flush()
+		case <-heartbeat.C:
+			// Comments keep proxies from closing an idle connection
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		}
+	}
+}
+
+func writeEvent(w *echo.Response, m live.Message) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", m.ID, m.Event, m.Data)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/pkg/live"
+	"github.com/labstack/echo/v4"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+type fakeTokenWatcher chan struct{}
+
+func (w fakeTokenWatcher) TokenEnded(echo.Context) <-chan struct{} { return w }
+
+func newStreamContext(ctx context.Context) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, "/inbox/stream", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.Set("user_id", uuid.NewString())
+	return c, rec
+}
+
+func TestStreamEndsWithToken(t *testing.T) {
+	ended := make(fakeTokenWatcher)
+	h := NewStreamHandler(live.NewHub(nil), ended)
+	c, rec := newStreamContext(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- h.Stream(c) }()
+
+	close(ended)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("stream returned %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream outlived its token")
+	}
+	if !strings.HasPrefix(rec.Body.String(), "retry:") {
+		t.Errorf("stream did not start: %q", rec.Body.String())
+	}
+}
+
+func TestTokenEndedAtExpiry(t *testing.T) {
+	h := NewAuthHandler(nil, nil)
+	c, _ := newStreamContext(context.Background())
+	c.Set("access_token", "token")
+	c.Set("token_expires_at", time.Now().Add(20*time.Millisecond))
+
+	select {
+	case <-h.TokenEnded(c):
+	case <-time.After(time.Second):
+		t.Fatal("token did not end at its expiry")
+	}
+}
+
+func TestTokenEndedStopsWithRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := NewAuthHandler(nil, nil)
+	c, _ := newStreamContext(ctx)
+	c.Set("access_token", "token")
+	c.Set("token_expires_at", time.Now().Add(time.Hour))
+
+	ended := h.TokenEnded(c)
+	select {
+	case <-ended:
+		t.Fatal("valid token ended")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// The watcher must not outlive the request
+	cancel()
+	select {
+	case <-ended:
+	case <-time.After(time.Second):
+		t.Fatal("watcher kept running after the request ended")
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kiribu/jwt-practice/internal/gateway/client"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type WebhookHandler struct {
+	notificationClient *client.NotificationClient
+}
+
+func NewWebhookHandler(notificationClient *client.NotificationClient) *WebhookHandler {
+	return &WebhookHandler{notificationClient: notificationClient}
+}
+
+type CreateWebhookRequest struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+}
+
+func (h *WebhookHandler) Create(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	var req CreateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: translate(c, "gateway.invalid_request")})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.notificationClient.CreateWebhook(ctx, userID, req.URL, req.Secret)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: status.Convert(err).Message()})
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *WebhookHandler) List(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.notificationClient.ListWebhooks(ctx, userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: status.Convert(err).Message()})
+	}
+
+	return c.JSON(http.StatusOK, resp.Webhooks)
+}
+
+func (h *WebhookHandler) Delete(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	id := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.notificationClient.DeleteWebhook(ctx, userID, id)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": resp.Message})
+}
+
+func (h *WebhookHandler) Enable(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	id := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.notificationClient.EnableWebhook(ctx, userID, id)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *WebhookHandler) Deliveries(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	id := c.Param("id")
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.notificationClient.ListWebhookDeliveries(ctx, userID, id, int32(limit))
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, resp.Deliveries)
+}
+
+func (h *WebhookHandler) SendTest(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	id := c.Param("id")
+
+	// Covers all retry attempts of the test delivery
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	resp, err := h.notificationClient.SendTestWebhook(ctx, userID, id)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func webhookError(c echo.Context, err error) error {
+	st := status.Convert(err)
+	switch st.Code() {
+	case codes.NotFound:
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: translate(c, "gateway.webhook_not_found")})
+	case codes.InvalidArgument:
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: st.Message()})
+	default:
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: st.Message()})
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"github.com/labstack/echo/v4"
+)
+
+// Locale picks the response language from Accept-Language and puts it in
+// the request context, from where the gRPC clients pass it on to the services.
+func Locale(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		locale := i18n.Negotiate(req.Header.Get("Accept-Language"))
+
+		setLocale(c, locale)
+		c.Response().Header().Add(echo.HeaderVary, "Accept-Language")
+		return next(c)
+	}
+}
+
+// UserLocales looks up the language a user has chosen in their preferences.
+// ok is false when they have not chosen one or it cannot be found out.
+type UserLocales interface {
+	UserLocale(ctx context.Context, userID string) (locale i18n.Locale, ok bool)
+}
+
+// UserLocale runs after authentication and switches the request to the
+// language of the user's preferences. The one Locale negotiated from
+// Accept-Language stays only while the user has not chosen any.
+func UserLocale(locales UserLocales) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, _ := c.Get("user_id").(string)
+			if locale, ok := locales.UserLocale(c.Request().Context(), userID); ok {
+				setLocale(c, locale)
+			}
+			return next(c)
+		}
+	}
+}
+
+func setLocale(c echo.Context, locale i18n.Locale) {
+	req := c.Request()
+	c.SetRequest(req.WithContext(i18n.WithLocale(req.Context(), locale)))
+	c.Response().Header().Set("Content-Language", string(locale))
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"github.com/labstack/echo/v4"
+)
+
+// chosenLocales maps user ids to the language they chose.
+type chosenLocales map[string]i18n.Locale
+
+func (l chosenLocales) UserLocale(ctx context.Context, userID string) (i18n.Locale, bool) {
+	locale, ok := l[userID]
+	return locale, ok
+}
+
+func TestUserLocaleOverridesAcceptLanguage(t *testing.T) {
+	locales := chosenLocales{"user-ru": i18n.Russian}
+
+	tests := []struct {
+		name           string
+		userID         string
+		acceptLanguage string
+		want           i18n.Locale
+	}{
+		{"chosen locale wins", "user-ru", "en", i18n.Russian},
+		{"no choice falls back to the header", "user-none", "ru", i18n.Russian},
+		{"no choice and no header", "user-none", "", i18n.Default},
+		{"anonymous request", "", "ru", i18n.Russian},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			var got i18n.Locale
+			authenticated := func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					if tt.userID != "" {
+						c.Set("user_id", tt.userID)
+					}
+					return next(c)
+				}
+			}
+			h := Locale(authenticated(UserLocale(locales)(func(c echo.Context) error {
+				got = i18n.FromContext(c.Request().Context())
+				return nil
+			})))
+			if err := h(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("locale = %q, want %q", got, tt.want)
+			}
+			if lang := rec.Header().Get("Content-Language"); lang != string(tt.want) {
+				t.Errorf("Content-Language = %q, want %q", lang, tt.want)
+			}
+		})
+	}
+}
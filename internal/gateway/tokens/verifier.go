@@ -0,0 +1,155 @@
+// Package tokens verifies access tokens in the gateway itself, against the
+// auth service's public keys and the revocation list, so that most requests
+// do not need a call to the auth service.
+package tokens
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kiribu/jwt-practice/internal/gateway/client"
+	"github.com/kiribu/jwt-practice/pkg/jwtkeys"
+	"github.com/kiribu/jwt-practice/pkg/revocation"
+	"github.com/kiribu/jwt-practice/utils"
+)
+
+const (
+	// keysRefreshInterval is how often the keys are refetched on schedule
+	keysRefreshInterval = 5 * time.Minute
+	// minKeysRefetch limits refetches caused by tokens with an unknown kid,
+	// which anyone can send
+	minKeysRefetch = 10 * time.Second
+)
+
+var (
+	// ErrUncertain means the token has to be checked by the auth service.
+	ErrUncertain    = errors.New("token cannot be verified locally")
+	ErrTokenRevoked = errors.New("token revoked")
+)
+
+// Identity is who a valid token belongs to.
+type Identity struct {
+	Username  string
+	UserID    string
+	SessionID string
+	ExpiresAt time.Time
+}
+
+type Verifier struct {
+	auth    *client.AuthClient
+	keys    *jwtkeys.Keyset
+	revoked *revocation.List
+
+	mu        sync.Mutex
+	fetchedAt time.Time
+}
+
+func NewVerifier(auth *client.AuthClient, revoked *revocation.List) *Verifier {
+	return &Verifier{
+		auth:    auth,
+		keys:    jwtkeys.NewKeyset(),
+		revoked: revoked,
+	}
+}
+
+// Run fetches the keys and keeps them fresh until ctx is done.
+func (v *Verifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(keysRefreshInterval)
+	defer ticker.Stop()
+
+	v.refreshKeys(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			v.refreshKeys(ctx)
+		}
+	}
+}
+
+// Verify checks token locally. It returns ErrUncertain when it cannot tell:
+// the revocation list is out of sync or the signing key is unknown even
+// after refetching the keys.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Identity, error) {
+	if !v.revoked.Synced() {
+		return nil, ErrUncertain
+	}
+
+	claims, err := utils.ValidateAccessToken(v.keys, token)
+	if errors.Is(err, jwtkeys.ErrUnknownKey) && v.refetchKeys(ctx) {
+		claims, err = utils.ValidateAccessToken(v.keys, token)
+	}
+	if errors.Is(err, jwtkeys.ErrUnknownKey) {
+		return nil, ErrUncertain
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if v.revoked.IsRevoked(claims.Revocation()) {
+		return nil, ErrTokenRevoked
+	}
+
+	return &Identity{
+		Username:  claims.Username,
+		UserID:    claims.UserID,
+		SessionID: claims.SessionID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// Changed returns a channel that is closed when tokens may have been
+// revoked, so that a token accepted earlier should be verified again.
+func (v *Verifier) Changed() <-chan struct{} {
+	return v.revoked.Changed()
+}
+
+// refetchKeys refreshes the keys unless that was done very recently, and
+// reports whether it did.
+func (v *Verifier) refetchKeys(ctx context.Context) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if time.Since(v.fetchedAt) < minKeysRefetch {
+		return false
+	}
+	return v.fetchKeys(ctx)
+}
+
+func (v *Verifier) refreshKeys(ctx context.Context) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.fetchKeys(ctx)
+}
+
+// fetchKeys must be called with mu held.
+func (v *Verifier) fetchKeys(ctx context.Context) bool {
+	// Whatever the outcome, a burst of requests should not retry at once
+	v.fetchedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	jwks, err := v.auth.GetJWKS(ctx)
+	if err != nil {
+		slog.Warn("Failed to fetch token signing keys", "error", err)
+		return false
+	}
+
+	keys := make([]*jwtkeys.Key, 0, len(jwks.Keys))
+	for _, j := range jwks.Keys {
+		k, err := jwtkeys.ParseJWK(j)
+		if err != nil {
+			slog.Warn("Skipping unusable signing key", "kid", j.Kid, "error", err)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	v.keys.Replace(keys)
+	return true
+}
@@ -0,0 +1,52 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kiribu/jwt-practice/pkg/redistest"
+	"github.com/kiribu/jwt-practice/pkg/revocation"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// A feed that stops answering must not leave the gateway trusting a list
+// that no longer receives revocations.
+func TestVerifierDefersToAuthServiceWhenFeedStalls(t *testing.T) {
+	server := redistest.NewServer(t)
+	revoked := revocation.NewList(server.Client(t, 20*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go revoked.Run(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !revoked.Synced() {
+		if time.Now().After(deadline) {
+			t.Fatal("revocation list did not sync")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	server.Stall()
+	v := NewVerifier(nil, revoked)
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		_, err := v.Verify(ctx, "any-token")
+		if errors.Is(err, ErrUncertain) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Verify returned %v after the feed stalled, want ErrUncertain", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,69 @@
+package channel
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+)
+
+// Notification is a single reminder delivery handed to a channel, or a digest
+// of several reminders when Digest is set.
+type Notification struct {
+	ID       string // event ID of the trigger, stable across redeliveries
+	Attempt  int    // 1 for the first delivery of the trigger
+	UserID   uuid.UUID
+	Reminder models.Reminder
+	// Recipient is the channel specific address (email, URL), empty for channels that need none
+	Recipient string
+	Username  string
+	Timezone  string // IANA name, empty means UTC
+	Locale    i18n.Locale
+	Digest    *Digest
+}
+
+// Channel delivers notifications over one transport.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, n Notification) error
+}
+
+// Registry holds the channels available to the delivery pipeline by name.
+type Registry struct {
+	mu       sync.RWMutex
+	channels map[string]Channel
+}
+
+func NewRegistry() *Registry {
+	return &Registry{channels: make(map[string]Channel)}
+}
+
+func (r *Registry) Register(ch Channel) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.channels[ch.Name()] = ch
+}
+
+func (r *Registry) Get(name string) (Channel, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ch, ok := r.channels[name]
+	return ch, ok
+}
+
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.channels))
+	for name := range r.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
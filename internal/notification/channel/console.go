@@ -0,0 +1,89 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+)
+
+// ConsoleChannel writes one JSON line per notification to a writer,
+// stdout for the console sink or an append-only file for the file sink.
+type ConsoleChannel struct {
+	name string
+	mu   sync.Mutex
+	out  io.Writer
+}
+
+func NewConsoleChannel(out io.Writer) *ConsoleChannel {
+	return &ConsoleChannel{name: "console", out: out}
+}
+
+func NewFileChannel(path string) (*ConsoleChannel, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open notification file: %w", err)
+	}
+	return &ConsoleChannel{name: "file", out: f}, nil
+}
+
+func (c *ConsoleChannel) Name() string {
+	return c.name
+}
+
+func (c *ConsoleChannel) Send(ctx context.Context, n Notification) error {
+	entry := map[string]interface{}{
+		"delivered_at": time.Now().Format(time.RFC3339),
+		"id":           n.ID,
+		"user_id":      n.UserID,
+	}
+	if d := n.Digest; d != nil {
+		entry["digest"] = d.Kind
+		entry["from"] = d.From.Format(time.RFC3339)
+		entry["to"] = d.To.Format(time.RFC3339)
+		entry["upcoming"] = d.Upcoming
+		entry["overdue"] = d.Overdue
+		entry["missed"] = d.Missed
+		entry["text"] = digestText(n.Locale, d, userLocation(n.Timezone))
+	} else {
+		entry["reminder_id"] = n.Reminder.ID
+		entry["title"] = n.Reminder.Title
+		entry["description"] = n.Reminder.Description
+		entry["remind_at"] = n.Reminder.RemindAt.Format(time.RFC3339)
+		entry["text"] = i18n.T(n.Locale, "notification.reminder",
+			n.Reminder.Title, i18n.FormatDateTime(n.Locale, n.Reminder.RemindAt.In(userLocation(n.Timezone))))
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err = c.out.Write(append(line, '\n'))
+	return err
+}
+
+// digestText is a one-line summary of d for the reader of the log.
+func digestText(l i18n.Locale, d *Digest, loc *time.Location) string {
+	key := "notification.digest.daily"
+	if d.Kind == DigestWeekly {
+		key = "notification.digest.weekly"
+	}
+	total := len(d.Upcoming) + len(d.Overdue) + len(d.Missed)
+	return i18n.T(l, key, i18n.FormatDate(l, d.From.In(loc)), i18n.Plural(l, "digest.reminders", total))
+}
+
+func (c *ConsoleChannel) Close() error {
+	if closer, ok := c.out.(io.Closer); ok && c.out != os.Stdout {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestConsoleChannelWritesOneLinePerNotification(t *testing.T) {
+	var out bytes.Buffer
+	ch := NewConsoleChannel(&out)
+
+	n := testNotification(uuid.New())
+	n.Locale = "en"
+	for range 2 {
+		if err := ch.Send(context.Background(), n); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), out.String())
+	}
+
+	var entry map[string]string
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+	if entry["id"] != n.ID || entry["reminder_id"] != n.Reminder.ID.String() || entry["title"] != "Call mom" {
+		t.Errorf("entry = %v", entry)
+	}
+	if !strings.Contains(entry["text"], "Call mom") {
+		t.Errorf("text %q does not mention the reminder", entry["text"])
+	}
+	if err := ch.Close(); err != nil {
+		t.Errorf("close: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package channel
+
+import (
+	"time"
+
+	"github.com/kiribu/jwt-practice/models"
+)
+
+// Digest kinds.
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Digest summarises a user's reminders for one period in a single message.
+// Channels send it instead of a reminder when Notification.Digest is set.
+type Digest struct {
+	Kind string
+	// Period is [From, To) in the user's timezone: the day for a daily
+	// digest, the past week for a weekly one.
+	From time.Time
+	To   time.Time
+	// Upcoming is due within the day, or within the coming week.
+	Upcoming []models.Reminder
+	// Overdue was due in the period but has not been sent.
+	Overdue []models.Reminder
+	// Missed was delivered in the period but is still unread in the inbox.
+	Missed []models.Reminder
+}
+
+// Empty reports whether there is nothing to tell the user about.
+func (d *Digest) Empty() bool {
+	return len(d.Upcoming) == 0 && len(d.Overdue) == 0 && len(d.Missed) == 0
+}
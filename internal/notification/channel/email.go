@@ -0,0 +1,286 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+)
+
+// EmailChannel sends notifications as multipart mail over SMTP, with the
+// bodies rendered from EmailTemplates.
+type EmailChannel struct {
+	sender    *SMTPSender
+	templates *EmailTemplates
+	verifyURL string
+	resetURL  string
+}
+
+// NewEmailChannel creates the channel. verifyURL is the page that confirms
+// an address and resetURL the page where a new password is chosen; links to
+// both carry the token as their "token" parameter.
+func NewEmailChannel(sender *SMTPSender, templates *EmailTemplates, verifyURL, resetURL string) *EmailChannel {
+	return &EmailChannel{
+		sender:    sender,
+		templates: templates,
+		verifyURL: verifyURL,
+		resetURL:  resetURL,
+	}
+}
+
+func (c *EmailChannel) Name() string {
+	return "email"
+}
+
+type reminderEmail struct {
+	Locale      i18n.Locale
+	Username    string
+	Title       string
+	Description string
+	DueAt       string
+	Timezone    string
+}
+
+func (c *EmailChannel) Send(ctx context.Context, n Notification) error {
+	if n.Recipient == "" {
+		return errors.New("email recipient is required")
+	}
+
+	loc := userLocation(n.Timezone)
+	if n.Digest != nil {
+		return c.send(ctx, n.Recipient, EmailDigest, newDigestEmail(n.Locale, n.Username, n.Digest, loc))
+	}
+
+	return c.send(ctx, n.Recipient, EmailReminder, reminderEmail{
+		Locale:      n.Locale,
+		Username:    n.Username,
+		Title:       n.Reminder.Title,
+		Description: n.Reminder.Description,
+		DueAt:       i18n.FormatDateTime(n.Locale, n.Reminder.RemindAt.In(loc)),
+		Timezone:    loc.String(),
+	})
+}
+
+type digestEmail struct {
+	Locale   i18n.Locale
+	Username string
+	Weekly   bool
+	Period   string
+	Timezone string
+	Upcoming []digestEmailItem
+	Overdue  []digestEmailItem
+	Missed   []digestEmailItem
+}
+
+type digestEmailItem struct {
+	Title       string
+	Description string
+	DueAt       string
+}
+
+func newDigestEmail(locale i18n.Locale, username string, d *Digest, loc *time.Location) digestEmail {
+	items := func(reminders []models.Reminder) []digestEmailItem {
+		out := make([]digestEmailItem, 0, len(reminders))
+		for _, r := range reminders {
+			out = append(out, digestEmailItem{
+				Title:       r.Title,
+				Description: r.Description,
+				DueAt:       i18n.FormatDateTime(locale, r.RemindAt.In(loc)),
+			})
+		}
+		return out
+	}
+
+	period := i18n.FormatDate(locale, d.From.In(loc))
+	if d.Kind == DigestWeekly {
+		// To is exclusive, the last day of the week is the one before it
+		period += " – " + i18n.FormatDate(locale, d.To.In(loc).AddDate(0, 0, -1))
+	}
+
+	return digestEmail{
+		Locale:   locale,
+		Username: username,
+		Weekly:   d.Kind == DigestWeekly,
+		Period:   period,
+		Timezone: loc.String(),
+		Upcoming: items(d.Upcoming),
+		Overdue:  items(d.Overdue),
+		Missed:   items(d.Missed),
+	}
+}
+
+// EmailVerificationRequest asks to confirm that a user owns an address.
+type EmailVerificationRequest struct {
+	Username  string
+	Email     string
+	Token     string
+	ExpiresAt time.Time
+	Timezone  string
+	Locale    i18n.Locale
+}
+
+type verificationEmail struct {
+	Locale    i18n.Locale
+	Username  string
+	Email     string
+	Link      string
+	ExpiresAt string
+}
+
+func (c *EmailChannel) SendVerification(ctx context.Context, req EmailVerificationRequest) error {
+	link, err := tokenLink(c.verifyURL, req.Token)
+	if err != nil {
+		return fmt.Errorf("invalid verification URL: %w", err)
+	}
+
+	return c.send(ctx, req.Email, EmailVerification, verificationEmail{
+		Locale:    req.Locale,
+		Username:  req.Username,
+		Email:     req.Email,
+		Link:      link,
+		ExpiresAt: i18n.FormatDateTime(req.Locale, req.ExpiresAt.In(userLocation(req.Timezone))),
+	})
+}
+
+// PasswordResetRequest asks to send a password reset link to a verified
+// address.
+type PasswordResetRequest struct {
+	Username  string
+	Email     string
+	Token     string
+	ExpiresAt time.Time
+	Timezone  string
+	Locale    i18n.Locale
+}
+
+type passwordResetEmail struct {
+	Locale    i18n.Locale
+	Username  string
+	Link      string
+	ExpiresAt string
+}
+
+func (c *EmailChannel) SendPasswordReset(ctx context.Context, req PasswordResetRequest) error {
+	link, err := tokenLink(c.resetURL, req.Token)
+	if err != nil {
+		return fmt.Errorf("invalid password reset URL: %w", err)
+	}
+
+	return c.send(ctx, req.Email, EmailPasswordReset, passwordResetEmail{
+		Locale:    req.Locale,
+		Username:  req.Username,
+		Link:      link,
+		ExpiresAt: i18n.FormatDateTime(req.Locale, req.ExpiresAt.In(userLocation(req.Timezone))),
+	})
+}
+
+// tokenLink adds token to the query of the page at rawURL.
+func tokenLink(rawURL, token string) (string, error) {
+	link, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+func (c *EmailChannel) send(ctx context.Context, to, kind string, data any) error {
+	rendered, err := c.templates.Render(kind, data)
+	if err != nil {
+		return fmt.Errorf("failed to render %s email: %w", kind, err)
+	}
+
+	msg, err := c.message(to, rendered)
+	if err != nil {
+		return err
+	}
+
+	if err := c.sender.Send(ctx, []string{to}, msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// message builds a multipart/alternative message: mail clients show the
+// last part they can render, so the HTML body goes after the plain-text one.
+func (c *EmailChannel) message(to string, rendered RenderedEmail) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", rendered.Text},
+		{"text/html; charset=UTF-8", rendered.HTML},
+	}
+	for _, part := range parts {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.WriteString("From: " + c.sender.From() + "\r\n")
+	b.WriteString("To: " + sanitizeHeader(to) + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", sanitizeHeader(rendered.Subject)) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("Message-ID: <" + uuid.NewString() + "@" + messageIDDomain(c.sender.From()) + ">\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: multipart/alternative; boundary=" + mw.Boundary() + "\r\n")
+	b.WriteString("\r\n")
+	b.Write(body.Bytes())
+
+	return b.Bytes(), nil
+}
+
+// userLocation falls back to UTC for users without a (valid) timezone.
+func userLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func messageIDDomain(from string) string {
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		return strings.TrimSuffix(from[i+1:], ">")
+	}
+	return "localhost"
+}
+
+func sanitizeHeader(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
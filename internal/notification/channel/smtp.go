@@ -0,0 +1,224 @@
+package channel
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"sync"
+	"time"
+)
+
+// TLS modes of the SMTP connection
+const (
+	SMTPTLSStartTLS = "starttls" // upgrade a plain connection, fail if the server can't
+	SMTPTLSImplicit = "tls"      // TLS from the first byte, usually port 465
+	SMTPTLSNone     = "none"     // plain text, only for local relays
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	TLSMode  string
+	// InsecureSkipVerify accepts any server certificate, for test relays only
+	InsecureSkipVerify bool
+	// IdleTimeout closes a reused connection that has not been used for this long
+	IdleTimeout time.Duration
+}
+
+// SMTPSender keeps one authenticated connection open and reuses it for
+// consecutive messages, reconnecting when the server has dropped it.
+type SMTPSender struct {
+	config SMTPConfig
+
+	mu        sync.Mutex
+	conn      net.Conn
+	client    *smtp.Client
+	idleTimer *time.Timer
+}
+
+func NewSMTPSender(config SMTPConfig) (*SMTPSender, error) {
+	switch config.TLSMode {
+	case "":
+		config.TLSMode = SMTPTLSStartTLS
+	case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP TLS mode %q", config.TLSMode)
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = time.Minute
+	}
+
+	return &SMTPSender{config: config}, nil
+}
+
+func (s *SMTPSender) From() string {
+	return s.config.From
+}
+
+// Send delivers msg to the recipients. A failure on a reused connection is
+// retried once on a fresh one, since the server may have closed it while idle.
+func (s *SMTPSender) Send(ctx context.Context, to []string, msg []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+	}
+
+	reused := s.client != nil && s.ping(ctx)
+	if !reused {
+		s.closeLocked()
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := s.transaction(ctx, to, msg)
+	if err != nil && reused && ctx.Err() == nil {
+		s.closeLocked()
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+		err = s.transaction(ctx, to, msg)
+	}
+	if err != nil {
+		s.closeLocked()
+		return err
+	}
+
+	s.idleTimer = time.AfterFunc(s.config.IdleTimeout, s.Close)
+	return nil
+}
+
+// Close ends the open connection, if any.
+func (s *SMTPSender) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != nil {
+		s.client.Quit()
+	}
+	s.closeLocked()
+}
+
+func (s *SMTPSender) closeLocked() {
+	if s.client != nil {
+		s.client.Close()
+	}
+	s.client = nil
+	s.conn = nil
+}
+
+func (s *SMTPSender) connect(ctx context.Context) error {
+	addr := net.JoinHostPort(s.config.Host, s.config.Port)
+	tlsConfig := &tls.Config{
+		ServerName:         s.config.Host,
+		InsecureSkipVerify: s.config.InsecureSkipVerify,
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+
+	if s.config.TLSMode == SMTPTLSImplicit {
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	stop := s.bindContext(ctx, conn)
+	defer stop()
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+
+	if s.config.TLSMode == SMTPTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	s.conn = conn
+	s.client = client
+	return nil
+}
+
+func (s *SMTPSender) ping(ctx context.Context) bool {
+	stop := s.bindContext(ctx, s.conn)
+	defer stop()
+
+	return s.client.Noop() == nil
+}
+
+func (s *SMTPSender) transaction(ctx context.Context, to []string, msg []byte) error {
+	stop := s.bindContext(ctx, s.conn)
+	defer stop()
+
+	if err := s.client.Mail(s.config.From); err != nil {
+		return fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := s.client.Rcpt(rcpt); err != nil {
+			s.client.Reset()
+			return fmt.Errorf("RCPT TO rejected: %w", err)
+		}
+	}
+
+	w, err := s.client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return nil
+}
+
+// bindContext makes blocking I/O on conn honour ctx: its deadline becomes the
+// connection deadline and cancellation interrupts the pending read or write.
+func (s *SMTPSender) bindContext(ctx context.Context, conn net.Conn) func() {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(30 * time.Second)
+	}
+	conn.SetDeadline(deadline)
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+	return func() {
+		stop()
+		conn.SetDeadline(time.Time{})
+	}
+}
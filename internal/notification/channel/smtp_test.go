@@ -0,0 +1,375 @@
+package channel
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeSMTPServer speaks just enough SMTP for SMTPSender: EHLO, AUTH PLAIN,
+// MAIL, RCPT, DATA, NOOP, RSET and QUIT. It never offers STARTTLS.
+type fakeSMTPServer struct {
+	listener net.Listener
+	password string
+
+	mu          sync.Mutex
+	conns       []net.Conn
+	connections int
+	auths       []string
+	messages    []receivedMail
+}
+
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeSMTPServer{listener: listener}
+	go s.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		s.dropConnections()
+	})
+	return s
+}
+
+// config returns a sender config for the server, without TLS.
+func (s *fakeSMTPServer) config() SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return SMTPConfig{Host: host, Port: port, From: "reminders@example.com", TLSMode: SMTPTLSNone}
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.connections++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// dropConnections closes every open connection, like a server that times
+// out idle clients.
+func (s *fakeSMTPServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeSMTPServer) stats() (connections int, messages []receivedMail, auths []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.connections, append([]receivedMail(nil), s.messages...), append([]string(nil), s.auths...)
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(lines ...string) {
+		io.WriteString(conn, strings.Join(lines, "\r\n")+"\r\n")
+	}
+
+	reply("220 fake.example.com ESMTP")
+	var current receivedMail
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-fake.example.com", "250 AUTH PLAIN")
+		case "AUTH":
+			mechanism, credentials, _ := strings.Cut(arg, " ")
+			decoded, err := base64.StdEncoding.DecodeString(credentials)
+			if mechanism != "PLAIN" || err != nil {
+				reply("501 malformed AUTH")
+				continue
+			}
+			s.mu.Lock()
+			s.auths = append(s.auths, string(decoded))
+			s.mu.Unlock()
+			if parts := strings.Split(string(decoded), "\x00"); len(parts) != 3 || parts[2] != s.password {
+				reply("535 authentication failed")
+				continue
+			}
+			reply("235 authenticated")
+		case "MAIL":
+			current = receivedMail{from: strings.TrimSuffix(strings.TrimPrefix(arg, "FROM:<"), ">")}
+			reply("250 ok")
+		case "RCPT":
+			current.to = append(current.to, strings.TrimSuffix(strings.TrimPrefix(arg, "TO:<"), ">"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			current.data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, current)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "NOOP", "RSET":
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPSenderReusesConnection(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	sender, err := NewSMTPSender(server.config())
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+	defer sender.Close()
+
+	for i := range 3 {
+		if err := sender.Send(context.Background(), []string{"anna@example.com"}, []byte("Subject: hi\r\n\r\nhello\r\n")); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+
+	connections, messages, _ := server.stats()
+	if connections != 1 {
+		t.Errorf("opened %d connections, want 1", connections)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("server got %d messages, want 3", len(messages))
+	}
+	if got := messages[0]; got.from != "reminders@example.com" || len(got.to) != 1 || got.to[0] != "anna@example.com" {
+		t.Errorf("envelope = %+v", got)
+	}
+}
+
+func TestSMTPSenderReconnectsAfterDrop(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	sender, err := NewSMTPSender(server.config())
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+	defer sender.Close()
+
+	msg := []byte("Subject: hi\r\n\r\nhello\r\n")
+	if err := sender.Send(context.Background(), []string{"anna@example.com"}, msg); err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	server.dropConnections()
+	if err := sender.Send(context.Background(), []string{"anna@example.com"}, msg); err != nil {
+		t.Fatalf("send after drop: %v", err)
+	}
+
+	connections, messages, _ := server.stats()
+	if connections != 2 || len(messages) != 2 {
+		t.Errorf("connections = %d, messages = %d, want 2 and 2", connections, len(messages))
+	}
+}
+
+func TestSMTPSenderRequiresStartTLS(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	config := server.config()
+	config.TLSMode = SMTPTLSStartTLS
+	sender, err := NewSMTPSender(config)
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+	defer sender.Close()
+
+	err = sender.Send(context.Background(), []string{"anna@example.com"}, []byte("Subject: hi\r\n\r\nhello\r\n"))
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("send without STARTTLS returned %v, want a STARTTLS error", err)
+	}
+	if _, messages, _ := server.stats(); len(messages) != 0 {
+		t.Errorf("message went out in plain text")
+	}
+}
+
+func TestSMTPSenderAuthenticates(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.password = "hunter2"
+
+	config := server.config()
+	config.Username = "relay"
+	config.Password = "wrong"
+	sender, err := NewSMTPSender(config)
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+	defer sender.Close()
+
+	msg := []byte("Subject: hi\r\n\r\nhello\r\n")
+	if err := sender.Send(context.Background(), []string{"anna@example.com"}, msg); err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Fatalf("send with a wrong password returned %v", err)
+	}
+
+	config.Password = "hunter2"
+	sender, err = NewSMTPSender(config)
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+	defer sender.Close()
+	if err := sender.Send(context.Background(), []string{"anna@example.com"}, msg); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	_, messages, auths := server.stats()
+	if len(messages) != 1 {
+		t.Errorf("server got %d messages, want 1", len(messages))
+	}
+	if len(auths) != 2 || auths[1] != "\x00relay\x00hunter2" {
+		t.Errorf("auth attempts = %q", auths)
+	}
+}
+
+func TestEmailChannelSendsMultipartMessage(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	sender, err := NewSMTPSender(server.config())
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+	defer sender.Close()
+
+	templates, err := LoadEmailTemplates("")
+	if err != nil {
+		t.Fatalf("load templates: %v", err)
+	}
+	ch := NewEmailChannel(sender, templates, "http://localhost/verify", "http://localhost/reset")
+
+	n := testNotification(uuid.New())
+	n.Recipient = "anna@example.com"
+	n.Username = "anna"
+	n.Locale = "ru"
+	n.Reminder.Title = "Позвонить <маме>"
+	if err := ch.Send(context.Background(), n); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	_, messages, _ := server.stats()
+	if len(messages) != 1 {
+		t.Fatalf("server got %d messages, want 1", len(messages))
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(messages[0].data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if got := msg.Header.Get("To"); got != "anna@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err != nil || !strings.Contains(subject, "Позвонить") {
+		t.Errorf("subject = %q (%v), want the reminder title", subject, err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %q (%v)", mediaType, err)
+	}
+
+	// Plain text first, HTML last: clients show the last part they can render
+	var types, bodies []string
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("read part body: %v", err)
+		}
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		types = append(types, mediaType)
+		bodies = append(bodies, string(body))
+	}
+	if strings.Join(types, ",") != "text/plain,text/html" {
+		t.Fatalf("parts = %v, want text/plain then text/html", types)
+	}
+	if !strings.Contains(bodies[0], "Позвонить <маме>") {
+		t.Errorf("text part does not contain the title:\n%s", bodies[0])
+	}
+	if !strings.Contains(bodies[1], "Позвонить &lt;маме&gt;") {
+		t.Errorf("HTML part does not contain the escaped title:\n%s", bodies[1])
+	}
+}
+
+func TestSMTPSenderHonoursContext(t *testing.T) {
+	// A server that accepts connections but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	sender, err := NewSMTPSender(SMTPConfig{Host: host, Port: port, TLSMode: SMTPTLSNone})
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := sender.Send(ctx, []string{"anna@example.com"}, []byte("hi")); err == nil {
+		t.Fatal("send to a silent server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("send took %v after the context ended", elapsed)
+	}
+}
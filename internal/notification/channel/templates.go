@@ -0,0 +1,123 @@
+package channel
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Email kinds. Each is made of three templates: <kind>.subject.tmpl and
+// <kind>.txt.tmpl are plain text, <kind>.html.tmpl is HTML-escaped.
+const (
+	EmailReminder      = "reminder"
+	EmailDigest        = "digest"
+	EmailVerification  = "email_verification"
+	EmailPasswordReset = "password_reset"
+)
+
+const (
+	templatesDir          = "templates"
+	subjectTemplateSuffix = ".subject.tmpl"
+	textTemplateSuffix    = ".txt.tmpl"
+	htmlTemplateSuffix    = ".html.tmpl"
+)
+
+// templateFuncs render catalog messages in the recipient's language, e.g.
+// {{t .Locale "email.greeting" .Username}} or {{plural .Locale "digest.reminders" 3}}.
+var templateFuncs = map[string]any{
+	"t":      i18n.T,
+	"plural": i18n.Plural,
+}
+
+// RenderedEmail is the content of one message before MIME encoding.
+type RenderedEmail struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// EmailTemplates renders the bodies of outgoing mail.
+type EmailTemplates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// LoadEmailTemplates parses the built-in templates. A file of the same name
+// in overrideDir replaces the built-in one; an empty overrideDir keeps them all.
+func LoadEmailTemplates(overrideDir string) (*EmailTemplates, error) {
+	names, err := fs.Glob(defaultTemplates, templatesDir+"/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &EmailTemplates{
+		text: texttemplate.New("email").Funcs(templateFuncs),
+		html: htmltemplate.New("email").Funcs(templateFuncs),
+	}
+
+	for _, path := range names {
+		name := filepath.Base(path)
+
+		content, err := readTemplate(overrideDir, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasSuffix(name, htmlTemplateSuffix) {
+			_, err = t.html.New(name).Parse(string(content))
+		} else {
+			_, err = t.text.New(name).Parse(string(content))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s: %w", name, err)
+		}
+	}
+
+	return t, nil
+}
+
+func readTemplate(overrideDir, name string) ([]byte, error) {
+	if overrideDir != "" {
+		content, err := os.ReadFile(filepath.Join(overrideDir, name))
+		if err == nil {
+			return content, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read email template %s: %w", name, err)
+		}
+	}
+	return defaultTemplates.ReadFile(templatesDir + "/" + name)
+}
+
+// Render executes the templates of one email kind with data.
+func (t *EmailTemplates) Render(kind string, data any) (RenderedEmail, error) {
+	var subject, text, html bytes.Buffer
+
+	if err := t.text.ExecuteTemplate(&subject, kind+subjectTemplateSuffix, data); err != nil {
+		return RenderedEmail{}, err
+	}
+	if err := t.text.ExecuteTemplate(&text, kind+textTemplateSuffix, data); err != nil {
+		return RenderedEmail{}, err
+	}
+	if err := t.html.ExecuteTemplate(&html, kind+htmlTemplateSuffix, data); err != nil {
+		return RenderedEmail{}, err
+	}
+
+	return RenderedEmail{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,328 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/models"
+)
+
+// Headers sent with every webhook request. The signature is
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookEventHeader     = "X-Webhook-Event"
+)
+
+// Webhook event types
+const (
+	WebhookEventReminderDue = "reminder.due"
+	WebhookEventTest        = "webhook.test"
+	WebhookEventDigest      = "reminder.digest"
+)
+
+// errBlockedAddress is returned for endpoints inside our own network. Webhook
+// URLs come from users, who must not be able to reach internal services.
+var errBlockedAddress = errors.New("webhook address is not public")
+
+type WebhookStore interface {
+	ListEnabledWebhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error)
+	RecordWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+	RecordWebhookOutcome(ctx context.Context, id uuid.UUID, success bool, maxFailures int) (bool, error)
+}
+
+type WebhookConfig struct {
+	// MaxAttempts per delivery, retried with exponential backoff on errors and non-2xx responses
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	// MaxFailures is the number of consecutive failed deliveries after which a webhook is disabled
+	MaxFailures int
+}
+
+// WebhookChannel POSTs signed JSON payloads to every enabled webhook of the user.
+type WebhookChannel struct {
+	store  WebhookStore
+	client *http.Client
+	config WebhookConfig
+}
+
+// NewWebhookChannel creates the channel. The default client only connects to
+// public addresses; redirects are never followed, whatever the client.
+func NewWebhookChannel(store WebhookStore, client *http.Client, config WebhookConfig) *WebhookChannel {
+	if client == nil {
+		client = newWebhookClient()
+	} else {
+		copied := *client
+		client = &copied
+	}
+	// A redirect would take the request to a URL that was never validated
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	return &WebhookChannel{store: store, client: client, config: config}
+}
+
+func (c *WebhookChannel) Name() string {
+	return "webhook"
+}
+
+type WebhookPayload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type webhookReminder struct {
+	ReminderID  string    `json:"reminder_id"`
+	UserID      string    `json:"user_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	RemindAt    time.Time `json:"remind_at"`
+}
+
+type webhookDigest struct {
+	Kind     string            `json:"kind"`
+	UserID   string            `json:"user_id"`
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Upcoming []webhookReminder `json:"upcoming"`
+	Overdue  []webhookReminder `json:"overdue"`
+	Missed   []webhookReminder `json:"missed"`
+}
+
+func toWebhookDigest(userID uuid.UUID, d *Digest) webhookDigest {
+	convert := func(reminders []models.Reminder) []webhookReminder {
+		out := make([]webhookReminder, 0, len(reminders))
+		for _, r := range reminders {
+			out = append(out, webhookReminder{
+				ReminderID:  r.ID.String(),
+				UserID:      userID.String(),
+				Title:       r.Title,
+				Description: r.Description,
+				RemindAt:    r.RemindAt,
+			})
+		}
+		return out
+	}
+
+	return webhookDigest{
+		Kind:     d.Kind,
+		UserID:   userID.String(),
+		From:     d.From,
+		To:       d.To,
+		Upcoming: convert(d.Upcoming),
+		Overdue:  convert(d.Overdue),
+		Missed:   convert(d.Missed),
+	}
+}
+
+func (c *WebhookChannel) Send(ctx context.Context, n Notification) error {
+	webhooks, err := c.store.ListEnabledWebhooks(ctx, n.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to load webhooks: %w", err)
+	}
+
+	eventType := WebhookEventReminderDue
+	var data interface{} = webhookReminder{
+		ReminderID:  n.Reminder.ID.String(),
+		UserID:      n.UserID.String(),
+		Title:       n.Reminder.Title,
+		Description: n.Reminder.Description,
+		RemindAt:    n.Reminder.RemindAt,
+	}
+	if n.Digest != nil {
+		eventType = WebhookEventDigest
+		data = toWebhookDigest(n.UserID, n.Digest)
+	}
+
+	var errs []error
+	for _, webhook := range webhooks {
+		// Derived from the trigger, so a redelivered notification keeps its delivery ID
+		deliveryID := uuid.NewSHA1(webhook.ID, []byte(n.ID))
+		if _, err := c.Deliver(ctx, webhook, deliveryID, eventType, data); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", webhook.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Deliver POSTs one event to webhook, retrying with backoff, and records every
+// attempt in the delivery log. It returns the last attempt.
+func (c *WebhookChannel) Deliver(ctx context.Context, webhook models.Webhook, deliveryID uuid.UUID, eventType string, data interface{}) (models.WebhookDelivery, error) {
+	body, err := json.Marshal(WebhookPayload{
+		ID:        deliveryID.String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	var attempt models.WebhookDelivery
+	backoff := c.config.InitialBackoff
+	for i := 1; ; i++ {
+		attempt = c.post(ctx, webhook, deliveryID, eventType, body)
+		attempt.Attempt = i
+
+		if err := c.store.RecordWebhookDelivery(ctx, attempt); err != nil {
+			slog.Error("Failed to record webhook delivery", "webhook_id", webhook.ID, "error", err)
+		}
+		if attempt.Success || i >= c.config.MaxAttempts || !sleep(ctx, backoff) {
+			break
+		}
+		backoff *= 2
+	}
+
+	disabled, err := c.store.RecordWebhookOutcome(ctx, webhook.ID, attempt.Success, c.config.MaxFailures)
+	if err != nil {
+		slog.Error("Failed to record webhook outcome", "webhook_id", webhook.ID, "error", err)
+	}
+	if disabled {
+		slog.Warn("Webhook disabled after repeated failures", "webhook_id", webhook.ID, "user_id", webhook.UserID)
+	}
+
+	if !attempt.Success {
+		return attempt, errors.New(attempt.Error)
+	}
+	return attempt, nil
+}
+
+func (c *WebhookChannel) post(ctx context.Context, webhook models.Webhook, deliveryID uuid.UUID, eventType string, body []byte) models.WebhookDelivery {
+	result := models.WebhookDelivery{
+		ID:         uuid.Must(uuid.NewV7()),
+		DeliveryID: deliveryID,
+		WebhookID:  webhook.ID,
+		UserID:     webhook.UserID,
+		EventType:  eventType,
+		CreatedAt:  time.Now(),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Reminder-Webhooks/1.0")
+	req.Header.Set(WebhookDeliveryHeader, deliveryID.String())
+	req.Header.Set(WebhookEventHeader, eventType)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(webhook.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if errors.Is(err, errBlockedAddress) {
+		// The dial error would tell the user what the host resolved to
+		result.Error = errBlockedAddress.Error()
+		return result
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("request failed: %v", err)
+		return result
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	result.ResponseStatus = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		result.Error = fmt.Sprintf("endpoint returned status %d", resp.StatusCode)
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: checkWebhookAddress,
+	}
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		// No proxy: the address check has to see the endpoint itself
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// checkWebhookAddress runs on the address being dialled, after DNS
+// resolution, so a host name that resolves to an internal address is caught
+// even if it resolved to a public one when the webhook was registered.
+func checkWebhookAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicIP reports whether webhooks may be delivered to ip. Loopback,
+// private, link-local, multicast and unspecified addresses are refused.
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && (ip4[0] == 0 || sharedAddressSpace.Contains(ip4)) {
+		return false
+	}
+	return true
+}
+
+// SignWebhook computes the signature header value receivers should compare against.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sleep waits for d and reports false if ctx ended first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
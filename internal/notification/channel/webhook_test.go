@@ -0,0 +1,260 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/models"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+type fakeWebhookStore struct {
+	mu         sync.Mutex
+	webhooks   []models.Webhook
+	deliveries []models.WebhookDelivery
+	outcomes   []bool
+}
+
+func (s *fakeWebhookStore) ListEnabledWebhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error) {
+	return s.webhooks, nil
+}
+
+func (s *fakeWebhookStore) RecordWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries = append(s.deliveries, delivery)
+	return nil
+}
+
+func (s *fakeWebhookStore) RecordWebhookOutcome(ctx context.Context, id uuid.UUID, success bool, maxFailures int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.outcomes = append(s.outcomes, success)
+	return false, nil
+}
+
+func newTestWebhook(url string) models.Webhook {
+	return models.Webhook{ID: uuid.New(), UserID: uuid.New(), URL: url, Secret: "s3cret", Enabled: true}
+}
+
+func testNotification(userID uuid.UUID) Notification {
+	return Notification{
+		ID:     "event-1",
+		UserID: userID,
+		Reminder: models.Reminder{
+			ID:       uuid.New(),
+			UserID:   userID,
+			Title:    "Call mom",
+			RemindAt: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestWebhookChannelSendsSignedPayload(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []*http.Request
+		bodies   [][]byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	webhook := newTestWebhook(server.URL)
+	store := &fakeWebhookStore{webhooks: []models.Webhook{webhook}}
+	ch := NewWebhookChannel(store, server.Client(), WebhookConfig{MaxAttempts: 1})
+
+	n := testNotification(webhook.UserID)
+	if err := ch.Send(context.Background(), n); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if err := ch.Send(context.Background(), n); err != nil {
+		t.Fatalf("send again: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+
+	req, body := requests[0], bodies[0]
+	timestamp, err := strconv.ParseInt(req.Header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp header: %v", err)
+	}
+	if got, want := req.Header.Get(WebhookSignatureHeader), SignWebhook(webhook.Secret, timestamp, body); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	if got := req.Header.Get(WebhookEventHeader); got != WebhookEventReminderDue {
+		t.Errorf("event header = %q, want %q", got, WebhookEventReminderDue)
+	}
+
+	var payload struct {
+		WebhookPayload
+		Data webhookReminder `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if payload.ID != req.Header.Get(WebhookDeliveryHeader) {
+		t.Errorf("payload id %q differs from delivery header %q", payload.ID, req.Header.Get(WebhookDeliveryHeader))
+	}
+	if payload.Data.Title != "Call mom" || payload.Data.ReminderID != n.Reminder.ID.String() {
+		t.Errorf("payload data = %+v", payload.Data)
+	}
+
+	// Receivers deduplicate on the delivery ID, so a redelivery must keep it
+	if requests[1].Header.Get(WebhookDeliveryHeader) != req.Header.Get(WebhookDeliveryHeader) {
+		t.Error("redelivered notification got a new delivery ID")
+	}
+	if len(store.deliveries) != 2 || !store.deliveries[0].Success || store.deliveries[0].ResponseStatus != http.StatusOK {
+		t.Errorf("recorded deliveries = %+v", store.deliveries)
+	}
+}
+
+func TestWebhookChannelRetriesFailedAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	webhook := newTestWebhook(server.URL)
+	store := &fakeWebhookStore{}
+	ch := NewWebhookChannel(store, server.Client(), WebhookConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	attempt, err := ch.Deliver(context.Background(), webhook, uuid.New(), WebhookEventTest, nil)
+	if err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if attempt.Attempt != 3 || !attempt.Success {
+		t.Errorf("last attempt = %+v, want a successful third attempt", attempt)
+	}
+	if len(store.deliveries) != 3 || store.deliveries[0].ResponseStatus != http.StatusBadGateway {
+		t.Errorf("recorded deliveries = %+v", store.deliveries)
+	}
+	if len(store.outcomes) != 1 || !store.outcomes[0] {
+		t.Errorf("outcomes = %v, want one success", store.outcomes)
+	}
+}
+
+func TestWebhookChannelReportsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	failing := newTestWebhook(server.URL)
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer working.Close()
+
+	store := &fakeWebhookStore{webhooks: []models.Webhook{failing, newTestWebhook(working.URL)}}
+	ch := NewWebhookChannel(store, server.Client(), WebhookConfig{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+
+	err := ch.Send(context.Background(), testNotification(failing.UserID))
+	if err == nil {
+		t.Fatal("send to a failing endpoint succeeded")
+	}
+	if len(store.outcomes) != 2 || store.outcomes[0] || !store.outcomes[1] {
+		t.Errorf("outcomes = %v, want the failing webhook to fail and the other to succeed", store.outcomes)
+	}
+}
+
+func TestWebhookChannelRefusesInternalAddresses(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	// The default client, as used in production
+	ch := NewWebhookChannel(&fakeWebhookStore{}, nil, WebhookConfig{MaxAttempts: 1})
+
+	for _, url := range []string{server.URL, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)} {
+		attempt, err := ch.Deliver(context.Background(), newTestWebhook(url), uuid.New(), WebhookEventTest, nil)
+		if err == nil {
+			t.Fatalf("delivery to %s succeeded", url)
+		}
+		if attempt.Error != errBlockedAddress.Error() {
+			t.Errorf("delivery to %s failed with %q, want %q", url, attempt.Error, errBlockedAddress)
+		}
+	}
+	if calls.Load() != 0 {
+		t.Errorf("internal endpoint got %d requests", calls.Load())
+	}
+}
+
+func TestWebhookChannelDoesNotFollowRedirects(t *testing.T) {
+	var followed atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed.Store(true)
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	ch := NewWebhookChannel(&fakeWebhookStore{}, server.Client(), WebhookConfig{MaxAttempts: 1})
+	attempt, err := ch.Deliver(context.Background(), newTestWebhook(server.URL), uuid.New(), WebhookEventTest, nil)
+	if err == nil {
+		t.Fatal("redirected delivery succeeded")
+	}
+	if attempt.ResponseStatus != http.StatusTemporaryRedirect {
+		t.Errorf("status = %d, want %d", attempt.ResponseStatus, http.StatusTemporaryRedirect)
+	}
+	if followed.Load() {
+		t.Error("redirect was followed")
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"100.64.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/auth/grpc/pb"
+	"github.com/kiribu/jwt-practice/internal/notification/delivery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// AuthClient resolves users' contact details through the auth service.
+type AuthClient struct {
+	conn   *grpc.ClientConn
+	client pb.AuthServiceClient
+}
+
+// NewAuthClient does not wait for the auth service: notifications that do
+// not need contact details keep flowing while it is unavailable.
+func NewAuthClient(addr string) (*AuthClient, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthClient{
+		conn:   conn,
+		client: pb.NewAuthServiceClient(conn),
+	}, nil
+}
+
+func (c *AuthClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *AuthClient) LookupUser(ctx context.Context, userID uuid.UUID) (delivery.User, error) {
+	resp, err := c.client.GetUserContact(ctx, &pb.GetUserContactRequest{
+		UserId: userID.String(),
+	})
+	if err != nil {
+		return delivery.User{}, fmt.Errorf("failed to get user contact: %w", err)
+	}
+
+	return delivery.User{
+		ID:            userID,
+		Username:      resp.Username,
+		Email:         resp.Email,
+		EmailVerified: resp.EmailVerified,
+		Timezone:      resp.Timezone,
+	}, nil
+}
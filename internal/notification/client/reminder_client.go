@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/reminder/grpc/pb"
+	"github.com/kiribu/jwt-practice/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ReminderClient reads users' reminders from the reminder service for digests.
+type ReminderClient struct {
+	conn   *grpc.ClientConn
+	client pb.ReminderServiceClient
+}
+
+func NewReminderClient(addr string) (*ReminderClient, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReminderClient{
+		conn:   conn,
+		client: pb.NewReminderServiceClient(conn),
+	}, nil
+}
+
+func (c *ReminderClient) Close() error {
+	return c.conn.Close()
+}
+
+// RemindersBetween returns the user's reminders due in [from, to).
+func (c *ReminderClient) RemindersBetween(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]models.Reminder, error) {
+	resp, err := c.client.GetRemindersInRange(ctx, &pb.GetRemindersInRangeRequest{
+		UserId: userID.String(),
+		From:   from.Format(time.RFC3339),
+		To:     to.Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+
+	reminders := make([]models.Reminder, 0, len(resp.Reminders))
+	for _, r := range resp.Reminders {
+		id, err := uuid.Parse(r.Id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reminder id %q: %w", r.Id, err)
+		}
+		remindAt, err := time.Parse(time.RFC3339, r.RemindAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid remind_at of reminder %s: %w", id, err)
+		}
+
+		reminders = append(reminders, models.Reminder{
+			ID:          id,
+			UserID:      userID,
+			Title:       r.Title,
+			Description: r.Description,
+			RemindAt:    remindAt,
+			Priority:    r.Priority,
+			IsSent:      r.IsSent,
+		})
+	}
+	return reminders, nil
+}
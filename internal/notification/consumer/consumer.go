@@ -0,0 +1,294 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kiribu/jwt-practice/internal/notification/channel"
+	"github.com/kiribu/jwt-practice/internal/notification/delivery"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"github.com/kiribu/jwt-practice/pkg/events/pb"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+)
+
+// Consumer processes messages on a pool of workers. Messages with the same
+// key, i.e. of the same user, always go to the same worker and so are
+// handled in order.
+type Consumer struct {
+	subscriber  broker.Subscriber
+	pipeline    *delivery.Pipeline
+	retrier     *Retrier
+	workers     int
+	maxInFlight int
+	offsets     *offsetTracker
+	// Serialises commits, so that an older batch never lands after a newer one
+	commitMu sync.Mutex
+	// Holds one token per fetched message that is not committed yet
+	slots chan struct{}
+}
+
+// NewConsumer creates a consumer for the notifications topic or one of its
+// retry tiers. Messages that fail are handed to retrier before their offset
+// is committed.
+func NewConsumer(subscriber broker.Subscriber, pipeline *delivery.Pipeline, retrier *Retrier, workers int) *Consumer {
+	if workers < 1 {
+		workers = 1
+	}
+	maxInFlight := workers * 16
+
+	return &Consumer{
+		subscriber:  subscriber,
+		pipeline:    pipeline,
+		retrier:     retrier,
+		workers:     workers,
+		maxInFlight: maxInFlight,
+		offsets:     newOffsetTracker(),
+		slots:       make(chan struct{}, maxInFlight),
+	}
+}
+
+// Start fetches messages until ctx is done, then waits up to drainTimeout
+// for the workers to finish what they already have. Deliveries still running
+// after that are cancelled; their messages stay uncommitted and are fetched
+// again by the next consumer of the group.
+func (c *Consumer) Start(ctx context.Context, drainTimeout time.Duration) {
+	slog.Info("Starting notification consumer", "workers", c.workers)
+	defer c.subscriber.Close()
+
+	// Deliveries must not be cut short the moment shutdown begins
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+
+	queues := make([]chan broker.Message, c.workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan broker.Message, c.maxInFlight/c.workers)
+		wg.Add(1)
+		go func(queue <-chan broker.Message) {
+			defer wg.Done()
+			for m := range queue {
+				c.processMessage(ctx, workCtx, m)
+			}
+		}(queues[i])
+	}
+
+	c.fetch(ctx, queues)
+
+	slog.Info("Stopping notification consumer, draining in-flight messages...", "pending", c.offsets.pending())
+	for _, queue := range queues {
+		close(queue)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(drainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+		slog.Info("Notification consumer drained")
+	case <-timer.C:
+		slog.Warn("Drain deadline exceeded, cancelling in-flight deliveries", "pending", c.offsets.pending())
+		cancelWork()
+		<-drained
+	}
+}
+
+func (c *Consumer) fetch(ctx context.Context, queues []chan broker.Message) {
+	for {
+		select {
+		case c.slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		m, err := c.subscriber.Fetch(ctx)
+		if err != nil {
+			<-c.slots
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("Error reading message", "error", err)
+			continue
+		}
+
+		c.offsets.track(m)
+		select {
+		case queues[shard(m.Key, len(queues))] <- m:
+		case <-ctx.Done():
+			// Never handed to a worker, so never committed
+			return
+		}
+	}
+}
+
+// processMessage handles m and commits it once it is done with. stopCtx is
+// done when shutdown begins, workCtx when in-flight work must be abandoned.
+func (c *Consumer) processMessage(stopCtx, workCtx context.Context, m broker.Message) {
+	// A retry still waiting for its time is left for the next consumer
+	if stopCtx.Err() != nil && m.Headers[HeaderRetryNotBefore] != "" {
+		return
+	}
+	if err := waitUntilDue(stopCtx, m); err != nil {
+		return
+	}
+
+	if err := c.handleMessage(workCtx, m); err != nil {
+		if workCtx.Err() != nil {
+			return
+		}
+		if err := c.retrier.Forward(workCtx, m, err); err != nil {
+			return
+		}
+	}
+
+	c.commit(workCtx, m)
+}
+
+func (c *Consumer) commit(ctx context.Context, m broker.Message) {
+	c.commitMu.Lock()
+	defer c.commitMu.Unlock()
+
+	ready := c.offsets.complete(m)
+	if len(ready) == 0 {
+		return
+	}
+
+	if err := c.subscriber.Commit(ctx, ready...); err != nil {
+		slog.Error("Failed to commit notification messages", "error", err, "count", len(ready))
+	}
+	for range ready {
+		<-c.slots
+	}
+}
+
+// shard maps a message key to a worker.
+func shard(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
+
+// handleMessage returns a *PoisonError for messages that can never succeed and
+// a plain error for failures that are worth retrying.
+func (c *Consumer) handleMessage(ctx context.Context, m broker.Message) (err error) {
+	defer recoverPoison(&err)
+
+	env, err := events.Parse(m.Headers)
+	if err != nil {
+		return poison(err)
+	}
+
+	switch env.Type {
+	case events.TypeNotificationRequested:
+		return c.handleNotification(ctx, env, m.Value, Attempt(m)+1)
+	case events.TypeEmailVerificationRequested:
+		return c.handleEmailVerification(ctx, env, m.Value)
+	case events.TypePasswordResetRequested:
+		return c.handlePasswordReset(ctx, env, m.Value)
+	default:
+		return poison(fmt.Errorf("unknown event type %q", env.Type))
+	}
+}
+
+func (c *Consumer) handleNotification(ctx context.Context, env events.Envelope, value []byte, attempt int) error {
+	var data pb.NotificationRequested
+	if err := env.Unmarshal(value, &data); err != nil {
+		return poison(fmt.Errorf("failed to decode notification: %w", err))
+	}
+
+	reminder, err := events.ReminderFromProto(data.GetReminder())
+	if err != nil {
+		return poison(fmt.Errorf("invalid reminder in notification: %w", err))
+	}
+
+	slog.Info("[NOTIFICATION] Sending reminder",
+		"user_id", reminder.UserID,
+		"reminder_id", reminder.ID,
+		"attempt", attempt,
+		"trace", env.TraceParent)
+
+	err = c.pipeline.Deliver(ctx, channel.Notification{
+		ID:       env.ID,
+		Attempt:  attempt,
+		UserID:   reminder.UserID,
+		Reminder: reminder,
+	})
+	if err != nil {
+		slog.Error("Failed to deliver notification", "error", err, "event_id", env.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Consumer) handleEmailVerification(ctx context.Context, env events.Envelope, value []byte) error {
+	var data pb.EmailVerificationRequested
+	if err := env.Unmarshal(value, &data); err != nil {
+		return poison(fmt.Errorf("failed to decode email verification: %w", err))
+	}
+
+	email, ok := c.pipeline.Email()
+	if !ok {
+		slog.Warn("Email channel is not configured, dropping verification email", "user_id", data.UserId, "event_id", env.ID)
+		return nil
+	}
+
+	// Sent before the user can have saved preferences, so in the language they asked in
+	locale, _ := i18n.Parse(data.Locale)
+	err := email.SendVerification(ctx, channel.EmailVerificationRequest{
+		Username:  data.Username,
+		Email:     data.Email,
+		Token:     data.Token,
+		ExpiresAt: data.GetExpiresAt().AsTime(),
+		Timezone:  data.Timezone,
+		Locale:    locale,
+	})
+	if err != nil {
+		slog.Error("Failed to send verification email", "error", err, "user_id", data.UserId, "event_id", env.ID)
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	slog.Info("Verification email sent", "user_id", data.UserId, "event_id", env.ID)
+	return nil
+}
+
+func (c *Consumer) handlePasswordReset(ctx context.Context, env events.Envelope, value []byte) error {
+	var data pb.PasswordResetRequested
+	if err := env.Unmarshal(value, &data); err != nil {
+		return poison(fmt.Errorf("failed to decode password reset: %w", err))
+	}
+
+	email, ok := c.pipeline.Email()
+	if !ok {
+		slog.Warn("Email channel is not configured, dropping password reset email", "user_id", data.UserId, "event_id", env.ID)
+		return nil
+	}
+
+	// The user may not be signed in anywhere, so in the language they asked in
+	locale, _ := i18n.Parse(data.Locale)
+	err := email.SendPasswordReset(ctx, channel.PasswordResetRequest{
+		Username:  data.Username,
+		Email:     data.Email,
+		Token:     data.Token,
+		ExpiresAt: data.GetExpiresAt().AsTime(),
+		Timezone:  data.Timezone,
+		Locale:    locale,
+	})
+	if err != nil {
+		slog.Error("Failed to send password reset email", "error", err, "user_id", data.UserId, "event_id", env.ID)
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	slog.Info("Password reset email sent", "user_id", data.UserId, "event_id", env.ID)
+	return nil
+}
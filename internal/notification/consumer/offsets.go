@@ -0,0 +1,103 @@
+package consumer
+
+import (
+	"sync"
+
+	"github.com/kiribu/jwt-practice/pkg/broker"
+)
+
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+type messageKey struct {
+	partitionKey
+	offset int64
+	id     string
+}
+
+func keyOf(m broker.Message) messageKey {
+	return messageKey{
+		partitionKey: partitionKey{topic: m.Topic, partition: m.Partition},
+		offset:       m.Offset,
+		id:           m.ID,
+	}
+}
+
+type inFlight struct {
+	msg  broker.Message
+	done bool
+}
+
+// offsetTracker remembers fetched messages per partition in fetch order.
+// Messages complete out of order when they are processed concurrently, but
+// only a prefix of completed messages may be committed: committing past an
+// unfinished message would lose it if the process stopped right then.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[partitionKey][]*inFlight
+	index      map[messageKey]*inFlight
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		partitions: make(map[partitionKey][]*inFlight),
+		index:      make(map[messageKey]*inFlight),
+	}
+}
+
+// track must be called in fetch order.
+func (t *offsetTracker) track(m broker.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := &inFlight{msg: m}
+	key := keyOf(m)
+	t.partitions[key.partitionKey] = append(t.partitions[key.partitionKey], entry)
+	t.index[key] = entry
+}
+
+// complete marks m as processed and returns the messages that became
+// committable, oldest first.
+func (t *offsetTracker) complete(m broker.Message) []broker.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := keyOf(m)
+	entry, ok := t.index[key]
+	if !ok {
+		return nil
+	}
+	entry.done = true
+
+	queue := t.partitions[key.partitionKey]
+	n := 0
+	for n < len(queue) && queue[n].done {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+
+	ready := make([]broker.Message, n)
+	for i, e := range queue[:n] {
+		ready[i] = e.msg
+		delete(t.index, keyOf(e.msg))
+	}
+
+	if n == len(queue) {
+		delete(t.partitions, key.partitionKey)
+	} else {
+		t.partitions[key.partitionKey] = queue[n:]
+	}
+	return ready
+}
+
+// pending returns how many fetched messages are not committable yet.
+func (t *offsetTracker) pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.index)
+}
@@ -0,0 +1,74 @@
+package consumer
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/kiribu/jwt-practice/internal/notification/service"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"github.com/kiribu/jwt-practice/pkg/events/pb"
+)
+
+// PreferencesConsumer keeps the preferences cache of this instance in sync
+// with updates made through any instance. Each instance needs its own group
+// so that every one of them sees every update.
+type PreferencesConsumer struct {
+	subscriber  broker.Subscriber
+	preferences *service.PreferencesService
+}
+
+func NewPreferencesConsumer(subscriber broker.Subscriber, preferences *service.PreferencesService) *PreferencesConsumer {
+	return &PreferencesConsumer{
+		subscriber:  subscriber,
+		preferences: preferences,
+	}
+}
+
+func (c *PreferencesConsumer) Start(ctx context.Context) {
+	slog.Info("Starting preferences consumer")
+	defer c.subscriber.Close()
+
+	for {
+		m, err := c.subscriber.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				slog.Info("Stopping preferences consumer...")
+				return
+			}
+			slog.Error("Error reading preferences update", "error", err)
+			continue
+		}
+
+		c.handleMessage(m)
+		c.subscriber.Commit(ctx, m)
+	}
+}
+
+func (c *PreferencesConsumer) handleMessage(m broker.Message) {
+	env, err := events.Parse(m.Headers)
+	if err != nil {
+		slog.Error("Rejecting preferences message", "error", err, "offset", m.Offset)
+		return
+	}
+
+	if env.Type != events.TypePreferencesUpdated {
+		slog.Warn("Unknown event type", "type", env.Type, "event_id", env.ID)
+		return
+	}
+
+	var data pb.NotificationPreferences
+	if err := env.Unmarshal(m.Value, &data); err != nil {
+		slog.Error("Failed to decode preferences update", "error", err, "event_id", env.ID)
+		return
+	}
+
+	prefs, err := events.PreferencesFromProto(&data)
+	if err != nil {
+		slog.Error("Invalid preferences update", "error", err, "event_id", env.ID)
+		return
+	}
+
+	c.preferences.Apply(prefs)
+	slog.Debug("Preferences cache updated", "user_id", prefs.UserID)
+}
@@ -0,0 +1,77 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/notification/service"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+)
+
+// notifyingSubscriber reports every commit, so a test knows when a message
+// has been handled.
+type notifyingSubscriber struct {
+	broker.Subscriber
+	committed chan broker.Message
+}
+
+func (s *notifyingSubscriber) Commit(ctx context.Context, msgs ...broker.Message) error {
+	if err := s.Subscriber.Commit(ctx, msgs...); err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		s.committed <- msg
+	}
+	return nil
+}
+
+// An update published by one instance reaches the cache of another through
+// the preferences topic, without the second instance asking its database.
+func TestPreferencesConsumerAppliesUpdates(t *testing.T) {
+	mem := broker.NewMemory()
+	publisher := service.NewEventPublisher(mem.Publisher(), "preferences", "lifecycle", events.ContentTypeJSON)
+
+	// No storage: a cache miss would panic
+	other := service.NewPreferencesService(nil, nil, []string{"console", "email"}, []string{"console"})
+	subscriber := &notifyingSubscriber{
+		Subscriber: mem.Subscriber("preferences", "instance-2"),
+		committed:  make(chan broker.Message, 1),
+	}
+	consumer := NewPreferencesConsumer(subscriber, other)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go consumer.Start(ctx)
+
+	prefs := models.NotificationPreferences{
+		UserID:           uuid.New(),
+		EnabledChannels:  models.ChannelList{"email"},
+		PriorityChannels: models.PriorityChannels{"high": "email"},
+		Locale:           "ru",
+		UpdatedAt:        time.Now(),
+	}
+	if err := publisher.PreferencesUpdated(ctx, prefs); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	select {
+	case <-subscriber.committed:
+	case <-time.After(time.Second):
+		t.Fatal("update was not consumed")
+	}
+
+	got, err := other.Preferences(ctx, prefs.UserID)
+	if err != nil {
+		t.Fatalf("preferences: %v", err)
+	}
+	if got.Locale != "ru" || len(got.EnabledChannels) != 1 || got.EnabledChannels[0] != "email" {
+		t.Fatalf("got %+v, want the published preferences", got)
+	}
+	if got.PriorityChannels["high"] != "email" {
+		t.Errorf("priority channels = %v, want high: email", got.PriorityChannels)
+	}
+}
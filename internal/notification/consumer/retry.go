@@ -0,0 +1,186 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/kiribu/jwt-practice/pkg/broker"
+)
+
+// Headers added to messages that failed processing. The event envelope
+// headers are left untouched, so a retried message is the same event.
+const (
+	HeaderRetryAttempt   = "x-retry-attempt"    // retries so far, absent on the main topic
+	HeaderRetryReason    = "x-retry-reason"     // error of the last failed attempt
+	HeaderRetryNotBefore = "x-retry-not-before" // RFC 3339, the retry consumer waits until then
+	HeaderOriginalTopic  = "x-original-topic"
+	HeaderFailedAt       = "x-failed-at"
+	HeaderDLQReason      = "x-dlq-reason"
+	HeaderDLQPoison      = "x-dlq-poison" // "true" when retrying could never succeed
+)
+
+// PoisonError marks a message that can never be processed, such as one that
+// does not decode. It skips the retry topics and goes straight to the DLQ.
+type PoisonError struct {
+	Err error
+}
+
+func (e *PoisonError) Error() string {
+	return "poison message: " + e.Err.Error()
+}
+
+func (e *PoisonError) Unwrap() error {
+	return e.Err
+}
+
+func poison(err error) error {
+	return &PoisonError{Err: err}
+}
+
+// RetryTier is a topic where failed messages wait before the next attempt.
+type RetryTier struct {
+	Topic string
+	Delay time.Duration
+}
+
+// Retrier moves failed messages to the next retry tier, and to the dead
+// letter topic once the tiers are exhausted.
+type Retrier struct {
+	publisher broker.Publisher
+	tiers     []RetryTier
+	dlqTopic  string
+	backoff   time.Duration
+}
+
+func NewRetrier(publisher broker.Publisher, dlqTopic string, tiers ...RetryTier) *Retrier {
+	return &Retrier{
+		publisher: publisher,
+		tiers:     tiers,
+		dlqTopic:  dlqTopic,
+		backoff:   time.Second,
+	}
+}
+
+// Attempt returns how many times m has been retried.
+func Attempt(m broker.Message) int {
+	n, _ := strconv.Atoi(m.Headers[HeaderRetryAttempt])
+	return n
+}
+
+// Forward publishes the failed message m to the next tier. It keeps trying
+// until the message is written or ctx is done, since committing m before
+// that would lose it.
+func (r *Retrier) Forward(ctx context.Context, m broker.Message, cause error) error {
+	next := r.next(m, cause, time.Now())
+
+	for {
+		err := r.publisher.Publish(ctx, next)
+		if err == nil {
+			slog.Warn("Notification message forwarded", "topic", next.Topic, "attempt", Attempt(m), "reason", cause)
+			return nil
+		}
+
+		slog.Error("Failed to forward notification message", "topic", next.Topic, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.backoff):
+		}
+	}
+}
+
+func (r *Retrier) next(m broker.Message, cause error, now time.Time) broker.Message {
+	headers := maps.Clone(m.Headers)
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	if headers[HeaderOriginalTopic] == "" {
+		headers[HeaderOriginalTopic] = m.Topic
+	}
+	headers[HeaderFailedAt] = now.UTC().Format(time.RFC3339)
+
+	next := broker.Message{
+		Key:     m.Key,
+		Value:   m.Value,
+		Headers: headers,
+		Time:    now,
+	}
+
+	attempt := Attempt(m)
+	var poisonErr *PoisonError
+	if !errors.As(cause, &poisonErr) && attempt < len(r.tiers) {
+		tier := r.tiers[attempt]
+		next.Topic = tier.Topic
+		headers[HeaderRetryAttempt] = strconv.Itoa(attempt + 1)
+		headers[HeaderRetryReason] = cause.Error()
+		headers[HeaderRetryNotBefore] = now.Add(tier.Delay).UTC().Format(time.RFC3339)
+		return next
+	}
+
+	next.Topic = r.dlqTopic
+	headers[HeaderDLQReason] = cause.Error()
+	headers[HeaderDLQPoison] = strconv.FormatBool(poisonErr != nil)
+	delete(headers, HeaderRetryNotBefore)
+	return next
+}
+
+// Redrive turns a dead letter back into a fresh message for its original
+// topic, or for fallbackTopic when that is unknown.
+func Redrive(m broker.Message, fallbackTopic string) broker.Message {
+	headers := maps.Clone(m.Headers)
+	topic := headers[HeaderOriginalTopic]
+	if topic == "" {
+		topic = fallbackTopic
+	}
+
+	for _, h := range []string{HeaderRetryAttempt, HeaderRetryReason, HeaderRetryNotBefore, HeaderOriginalTopic, HeaderFailedAt, HeaderDLQReason, HeaderDLQPoison} {
+		delete(headers, h)
+	}
+
+	return broker.Message{
+		Topic:   topic,
+		Key:     m.Key,
+		Value:   m.Value,
+		Headers: headers,
+		Time:    time.Now(),
+	}
+}
+
+// waitUntilDue blocks until a retried message may be processed again.
+func waitUntilDue(ctx context.Context, m broker.Message) error {
+	raw := m.Headers[HeaderRetryNotBefore]
+	if raw == "" {
+		return nil
+	}
+	notBefore, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil
+	}
+
+	wait := time.Until(notBefore)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// recoverPoison turns a panic in a handler into a poison error, so that one
+// bad message cannot crash the consumer over and over again.
+func recoverPoison(err *error) {
+	if r := recover(); r != nil {
+		*err = poison(fmt.Errorf("handler panicked: %v", r))
+	}
+}
@@ -0,0 +1,60 @@
+package dedupe
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps delivery records in the process. It only deduplicates
+// within one instance and forgets everything on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[string]time.Time
+	maxSize int
+}
+
+func NewMemoryStore(window time.Duration) *MemoryStore {
+	return &MemoryStore{
+		window:  window,
+		entries: make(map[string]time.Time),
+		maxSize: 100000,
+	}
+}
+
+func (s *MemoryStore) Seen(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.entries[key]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(expiresAt) {
+		delete(s.entries, key)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *MemoryStore) Record(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.entries) >= s.maxSize {
+		s.sweep(now)
+	}
+	s.entries[key] = now.Add(s.window)
+	return nil
+}
+
+// sweep drops expired entries. Must be called with mu held.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, expiresAt := range s.entries {
+		if now.After(expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
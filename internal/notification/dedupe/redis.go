@@ -0,0 +1,35 @@
+package dedupe
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore shares delivery records between all notification-service instances.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+	window time.Duration
+}
+
+func NewRedisStore(client *redis.Client, window time.Duration) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: "notification:delivered:",
+		window: window,
+	}
+}
+
+func (s *RedisStore) Seen(ctx context.Context, key string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.prefix+key).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *RedisStore) Record(ctx context.Context, key string) error {
+	return s.client.Set(ctx, s.prefix+key, 1, s.window).Err()
+}
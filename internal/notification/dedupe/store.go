@@ -0,0 +1,31 @@
+// Package dedupe remembers which notifications have already been delivered,
+// so that redelivered messages and duplicate triggers reach the user once.
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DriverRedis  = "redis"
+	DriverMemory = "memory"
+)
+
+// Store records delivered notifications for a fixed window.
+type Store interface {
+	// Seen reports whether key was recorded within the window.
+	Seen(ctx context.Context, key string) (bool, error)
+	// Record marks key as delivered.
+	Record(ctx context.Context, key string) error
+}
+
+// Key identifies one occurrence of a reminder on one channel. The occurrence
+// is the time the reminder was due, so duplicate triggers for the same
+// occurrence share a key while a rescheduled reminder gets a new one.
+func Key(reminderID uuid.UUID, occurrence time.Time, channel string) string {
+	return fmt.Sprintf("%s:%d:%s", reminderID, occurrence.Unix(), channel)
+}
@@ -0,0 +1,249 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/notification/channel"
+	"github.com/kiribu/jwt-practice/internal/notification/dedupe"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+)
+
+// Route sends a user's notifications over one channel to one recipient, in
+// the user's language.
+type Route struct {
+	Channel   string
+	Recipient string
+	Locale    i18n.Locale
+}
+
+// User is what the pipeline knows about the recipient of a notification.
+type User struct {
+	ID            uuid.UUID
+	Username      string
+	Email         string
+	EmailVerified bool
+	Timezone      string
+}
+
+// Directory looks up users' contact details.
+type Directory interface {
+	LookupUser(ctx context.Context, userID uuid.UUID) (User, error)
+}
+
+// Router decides where a user's notifications go.
+type Router interface {
+	Routes(ctx context.Context, user User, n channel.Notification) ([]Route, error)
+}
+
+// Inbox keeps every notification for the user to read in the app.
+type Inbox interface {
+	Store(ctx context.Context, n channel.Notification) error
+}
+
+// Receipt is the outcome of sending a notification over one channel.
+type Receipt struct {
+	Notification channel.Notification
+	Channel      string
+	Duration     time.Duration
+	At           time.Time
+	Err          error
+}
+
+// ReceiptPublisher reports delivery outcomes to the rest of the system.
+type ReceiptPublisher interface {
+	PublishReceipt(ctx context.Context, r Receipt) error
+}
+
+// Pipeline routes each notification to the user's channels.
+type Pipeline struct {
+	registry    *channel.Registry
+	router      Router
+	directory   Directory
+	receipts    ReceiptPublisher
+	dedupe      dedupe.Store
+	inbox       Inbox
+	sendTimeout time.Duration
+}
+
+// NewPipeline creates the pipeline. Without a directory users are known by
+// ID only, so channels that need an address are never routed to; without
+// receipts delivery outcomes are only logged; without a dedupe store every
+// redelivered notification is sent again; without an inbox notifications
+// are only pushed.
+func NewPipeline(registry *channel.Registry, router Router, directory Directory, receipts ReceiptPublisher, deliveries dedupe.Store, inbox Inbox) *Pipeline {
+	return &Pipeline{
+		registry:    registry,
+		router:      router,
+		directory:   directory,
+		receipts:    receipts,
+		dedupe:      deliveries,
+		inbox:       inbox,
+		sendTimeout: 30 * time.Second,
+	}
+}
+
+// Deliver sends n over every route of its user. Routes are independent:
+// a failing channel does not stop the others, and all failures are returned.
+// Routes that already delivered this occurrence of the reminder are skipped,
+// so a retried notification only goes to the channels that failed. The inbox
+// is written first and must succeed, since it is the copy users can go back to.
+func (p *Pipeline) Deliver(ctx context.Context, n channel.Notification) error {
+	user := p.lookupUser(ctx, n.UserID)
+	n.Username = user.Username
+	n.Timezone = user.Timezone
+
+	// The inbox gets every notification, whatever channels the user enabled
+	if p.inbox != nil {
+		if err := p.inbox.Store(ctx, n); err != nil {
+			return fmt.Errorf("failed to store notification in inbox: %w", err)
+		}
+	}
+
+	routes, err := p.router.Routes(ctx, user, n)
+	if err != nil {
+		return fmt.Errorf("failed to resolve routes: %w", err)
+	}
+
+	if len(routes) == 0 {
+		slog.Warn("No notification channels configured for user", "user_id", n.UserID)
+		return nil
+	}
+
+	var errs []error
+	for _, route := range routes {
+		key := dedupe.Key(n.Reminder.ID, n.Reminder.RemindAt, route.Channel)
+		if p.delivered(ctx, key) {
+			slog.Info("Skipping already delivered notification", "channel", route.Channel, "user_id", n.UserID, "reminder_id", n.Reminder.ID)
+			continue
+		}
+
+		start := time.Now()
+		err := p.send(ctx, route, n)
+		if err == nil {
+			p.recordDelivered(ctx, key)
+		}
+		p.publishReceipt(ctx, Receipt{
+			Notification: n,
+			Channel:      route.Channel,
+			Duration:     time.Since(start),
+			At:           time.Now(),
+			Err:          err,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", route.Channel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeliverDigest sends a digest over the routes of user. Digests bypass the
+// inbox, receipts and the dedupe store, which are about single reminders;
+// the digest scheduler keeps track of sent periods itself. It returns how
+// many routes got the digest.
+func (p *Pipeline) DeliverDigest(ctx context.Context, user User, n channel.Notification) (int, error) {
+	n.UserID = user.ID
+	n.Username = user.Username
+	n.Timezone = user.Timezone
+
+	routes, err := p.router.Routes(ctx, user, n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve routes: %w", err)
+	}
+
+	sent := 0
+	var errs []error
+	for _, route := range routes {
+		if err := p.send(ctx, route, n); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", route.Channel, err))
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// delivered errs on the side of sending: when the store is unavailable a
+// duplicate is better than a lost reminder.
+func (p *Pipeline) delivered(ctx context.Context, key string) bool {
+	if p.dedupe == nil {
+		return false
+	}
+	seen, err := p.dedupe.Seen(ctx, key)
+	if err != nil {
+		slog.Warn("Failed to check delivery record, sending anyway", "key", key, "error", err)
+		return false
+	}
+	return seen
+}
+
+func (p *Pipeline) recordDelivered(ctx context.Context, key string) {
+	if p.dedupe == nil {
+		return
+	}
+	if err := p.dedupe.Record(ctx, key); err != nil {
+		slog.Error("Failed to record delivered notification", "key", key, "error", err)
+	}
+}
+
+// publishReceipt is best effort: a lost receipt skews statistics but must
+// not turn a delivered notification into a failed one.
+func (p *Pipeline) publishReceipt(ctx context.Context, r Receipt) {
+	if p.receipts == nil {
+		return
+	}
+	if err := p.receipts.PublishReceipt(ctx, r); err != nil {
+		slog.Error("Failed to publish delivery receipt", "channel", r.Channel, "reminder_id", r.Notification.Reminder.ID, "error", err)
+	}
+}
+
+// lookupUser falls back to a bare user when the directory is unavailable,
+// so that channels without an address still get the notification.
+func (p *Pipeline) lookupUser(ctx context.Context, userID uuid.UUID) User {
+	if p.directory == nil {
+		return User{ID: userID}
+	}
+
+	user, err := p.directory.LookupUser(ctx, userID)
+	if err != nil {
+		slog.Warn("Failed to look up user, delivering without contact details", "user_id", userID, "error", err)
+		return User{ID: userID}
+	}
+	return user
+}
+
+// Email returns the registered email channel, for messages that are not
+// reminders such as address confirmations.
+func (p *Pipeline) Email() (*channel.EmailChannel, bool) {
+	ch, ok := p.registry.Get("email")
+	if !ok {
+		return nil, false
+	}
+	email, ok := ch.(*channel.EmailChannel)
+	return email, ok
+}
+
+func (p *Pipeline) send(ctx context.Context, route Route, n channel.Notification) error {
+	ch, ok := p.registry.Get(route.Channel)
+	if !ok {
+		return errors.New("channel is not registered")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.sendTimeout)
+	defer cancel()
+
+	n.Recipient = route.Recipient
+	n.Locale = route.Locale
+	start := time.Now()
+	if err := ch.Send(ctx, n); err != nil {
+		slog.Error("Notification delivery failed", "channel", route.Channel, "user_id", n.UserID, "reminder_id", n.Reminder.ID, "error", err)
+		return err
+	}
+
+	slog.Info("Notification delivered", "channel", route.Channel, "user_id", n.UserID, "reminder_id", n.Reminder.ID, "duration", time.Since(start).String())
+	return nil
+}
@@ -0,0 +1,198 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/notification/channel"
+	"github.com/kiribu/jwt-practice/internal/notification/dedupe"
+	"github.com/kiribu/jwt-practice/models"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// recordingChannel keeps what it was asked to send and fails while err is set.
+type recordingChannel struct {
+	name string
+	err  error
+	sent []channel.Notification
+}
+
+func (c *recordingChannel) Name() string { return c.name }
+
+func (c *recordingChannel) Send(ctx context.Context, n channel.Notification) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.sent = append(c.sent, n)
+	return nil
+}
+
+type staticPreferences models.NotificationPreferences
+
+func (p *staticPreferences) Preferences(ctx context.Context, userID uuid.UUID) (*models.NotificationPreferences, error) {
+	prefs := models.NotificationPreferences(*p)
+	prefs.UserID = userID
+	return &prefs, nil
+}
+
+type staticDirectory User
+
+func (d staticDirectory) LookupUser(ctx context.Context, userID uuid.UUID) (User, error) {
+	user := User(d)
+	user.ID = userID
+	return user, nil
+}
+
+type receiptLog []Receipt
+
+func (l *receiptLog) PublishReceipt(ctx context.Context, r Receipt) error {
+	*l = append(*l, r)
+	return nil
+}
+
+func newTestPipeline(prefs models.NotificationPreferences, user User, deliveries dedupe.Store, channels ...channel.Channel) (*Pipeline, *receiptLog) {
+	registry := channel.NewRegistry()
+	for _, ch := range channels {
+		registry.Register(ch)
+	}
+	source := staticPreferences(prefs)
+	receipts := &receiptLog{}
+	return NewPipeline(registry, NewPreferenceRouter(&source), staticDirectory(user), receipts, deliveries, nil), receipts
+}
+
+func reminderNotification(priority string) channel.Notification {
+	userID := uuid.New()
+	return channel.Notification{
+		ID:     "event-1",
+		UserID: userID,
+		Reminder: models.Reminder{
+			ID:       uuid.New(),
+			UserID:   userID,
+			Title:    "Pay rent",
+			Priority: priority,
+			RemindAt: time.Now(),
+		},
+	}
+}
+
+func TestDeliverRoutesByPreferences(t *testing.T) {
+	console := &recordingChannel{name: "console"}
+	email := &recordingChannel{name: "email"}
+	webhook := &recordingChannel{name: "webhook"}
+
+	prefs := models.NotificationPreferences{
+		EnabledChannels:  models.ChannelList{"console", "email", "webhook"},
+		PriorityChannels: models.PriorityChannels{models.PriorityHigh: "webhook"},
+		Locale:           "ru",
+	}
+	user := User{Username: "anna", Email: "anna@example.com", EmailVerified: true}
+	p, _ := newTestPipeline(prefs, user, nil, console, email, webhook)
+
+	if err := p.Deliver(context.Background(), reminderNotification(models.PriorityNormal)); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if len(console.sent) != 1 || len(email.sent) != 1 || len(webhook.sent) != 1 {
+		t.Fatalf("sent console=%d email=%d webhook=%d, want one each", len(console.sent), len(email.sent), len(webhook.sent))
+	}
+	if got := email.sent[0]; got.Recipient != user.Email || got.Locale != "ru" || got.Username != "anna" {
+		t.Errorf("email notification = %+v", got)
+	}
+
+	// A high priority reminder goes to its default channel only
+	if err := p.Deliver(context.Background(), reminderNotification(models.PriorityHigh)); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if len(console.sent) != 1 || len(email.sent) != 1 || len(webhook.sent) != 2 {
+		t.Errorf("sent console=%d email=%d webhook=%d, want only the webhook", len(console.sent), len(email.sent), len(webhook.sent))
+	}
+}
+
+func TestDeliverSkipsUnverifiedEmail(t *testing.T) {
+	console := &recordingChannel{name: "console"}
+	email := &recordingChannel{name: "email"}
+
+	prefs := models.NotificationPreferences{EnabledChannels: models.ChannelList{"console", "email"}}
+	p, _ := newTestPipeline(prefs, User{Email: "anna@example.com"}, nil, console, email)
+
+	if err := p.Deliver(context.Background(), reminderNotification("")); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if len(console.sent) != 1 || len(email.sent) != 0 {
+		t.Errorf("sent console=%d email=%d, want console only", len(console.sent), len(email.sent))
+	}
+}
+
+func TestDeliverAggregatesRouteFailures(t *testing.T) {
+	console := &recordingChannel{name: "console"}
+	webhook := &recordingChannel{name: "webhook", err: errors.New("endpoint down")}
+
+	prefs := models.NotificationPreferences{EnabledChannels: models.ChannelList{"webhook", "file", "console"}}
+	p, receipts := newTestPipeline(prefs, User{}, nil, console, webhook)
+
+	err := p.Deliver(context.Background(), reminderNotification(""))
+	if err == nil {
+		t.Fatal("deliver succeeded with failing routes")
+	}
+	for _, want := range []string{"webhook: endpoint down", "file: channel is not registered"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	// A failing route does not stop the ones after it
+	if len(console.sent) != 1 {
+		t.Errorf("console got %d notifications, want 1", len(console.sent))
+	}
+	if len(*receipts) != 3 {
+		t.Fatalf("got %d receipts, want 3", len(*receipts))
+	}
+	for _, r := range *receipts {
+		if (r.Err == nil) != (r.Channel == "console") {
+			t.Errorf("receipt for %s has error %v", r.Channel, r.Err)
+		}
+	}
+}
+
+func TestDeliverSkipsDeliveredRoutesOnRetry(t *testing.T) {
+	console := &recordingChannel{name: "console"}
+	webhook := &recordingChannel{name: "webhook", err: errors.New("endpoint down")}
+
+	prefs := models.NotificationPreferences{EnabledChannels: models.ChannelList{"console", "webhook"}}
+	p, _ := newTestPipeline(prefs, User{}, dedupe.NewMemoryStore(time.Hour), console, webhook)
+
+	n := reminderNotification("")
+	if err := p.Deliver(context.Background(), n); err == nil {
+		t.Fatal("deliver succeeded with a failing route")
+	}
+
+	webhook.err = nil
+	n.Attempt = 2
+	if err := p.Deliver(context.Background(), n); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if len(console.sent) != 1 {
+		t.Errorf("console got the notification %d times, want once", len(console.sent))
+	}
+	if len(webhook.sent) != 1 {
+		t.Errorf("webhook got the notification %d times, want once", len(webhook.sent))
+	}
+
+	// Once every route has it, a redelivery sends nothing
+	if err := p.Deliver(context.Background(), n); err != nil {
+		t.Fatalf("redelivery: %v", err)
+	}
+	if len(console.sent) != 1 || len(webhook.sent) != 1 {
+		t.Errorf("redelivery sent again: console=%d webhook=%d", len(console.sent), len(webhook.sent))
+	}
+}
@@ -0,0 +1,67 @@
+package delivery
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/notification/channel"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+)
+
+// PreferenceSource provides users' notification preferences.
+type PreferenceSource interface {
+	Preferences(ctx context.Context, userID uuid.UUID) (*models.NotificationPreferences, error)
+}
+
+// PreferenceRouter routes notifications according to the user's preferences:
+// a reminder goes to the default channel of its priority when one is set,
+// otherwise to every enabled channel.
+type PreferenceRouter struct {
+	source PreferenceSource
+}
+
+func NewPreferenceRouter(source PreferenceSource) *PreferenceRouter {
+	return &PreferenceRouter{source: source}
+}
+
+func (r *PreferenceRouter) Routes(ctx context.Context, user User, n channel.Notification) ([]Route, error) {
+	prefs, err := r.source.Preferences(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// An unknown locale, saved before it was validated, renders in the default
+	locale, _ := i18n.Parse(prefs.Locale)
+
+	priority := n.Reminder.Priority
+	if priority == "" {
+		priority = models.PriorityNormal
+	}
+
+	// A default channel that cannot reach the user (email not verified yet)
+	// falls back to the enabled channels rather than dropping the reminder
+	if name, ok := prefs.PriorityChannels[priority]; ok && prefs.EnabledChannels.Contains(name) {
+		if routes := routesFor(user, locale, name); len(routes) > 0 {
+			return routes, nil
+		}
+	}
+
+	return routesFor(user, locale, prefs.EnabledChannels...), nil
+}
+
+func routesFor(user User, locale i18n.Locale, channels ...string) []Route {
+	routes := make([]Route, 0, len(channels))
+	for _, name := range channels {
+		route := Route{Channel: name, Locale: locale}
+		// Email goes to the user's address once it is verified and is skipped before that
+		if name == "email" {
+			if user.Email == "" || !user.EmailVerified {
+				continue
+			}
+			route.Recipient = user.Email
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
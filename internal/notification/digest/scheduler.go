@@ -0,0 +1,232 @@
+// Package digest sends users who enabled digests one message a day with
+// their reminders for the day, and one a week summarising the past week.
+package digest
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/notification/channel"
+	"github.com/kiribu/jwt-practice/internal/notification/delivery"
+	"github.com/kiribu/jwt-practice/internal/notification/storage"
+	"github.com/kiribu/jwt-practice/models"
+)
+
+// ReminderSource lists users' reminders.
+type ReminderSource interface {
+	RemindersBetween(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]models.Reminder, error)
+}
+
+type Config struct {
+	// Interval is how often the scheduler looks for due digests.
+	Interval time.Duration
+	// Hour is the local hour from which the day's digests are sent.
+	Hour int
+	// WeeklyDay is the day the weekly summary is sent on.
+	WeeklyDay time.Weekday
+}
+
+// Scheduler sends digests once their local time has come. A digest is due on
+// its own day only: one missed while the service was down is skipped rather
+// than sent late with stale content.
+type Scheduler struct {
+	storage   storage.NotificationStorage
+	reminders ReminderSource
+	directory delivery.Directory
+	pipeline  *delivery.Pipeline
+	config    Config
+}
+
+func NewScheduler(
+	storage storage.NotificationStorage,
+	reminders ReminderSource,
+	directory delivery.Directory,
+	pipeline *delivery.Pipeline,
+	config Config,
+) *Scheduler {
+	return &Scheduler{
+		storage:   storage,
+		reminders: reminders,
+		directory: directory,
+		pipeline:  pipeline,
+		config:    config,
+	}
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	slog.Info("Digest scheduler started", "interval", s.config.Interval, "hour", s.config.Hour, "weekly_day", s.config.WeeklyDay)
+
+	s.run(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping digest scheduler...")
+			return
+		case <-ticker.C:
+			s.run(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	userIDs, err := s.storage.ListDigestSubscribers(ctx)
+	if err != nil {
+		slog.Error("Failed to list digest subscribers", "error", err)
+		return
+	}
+
+	now := time.Now()
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		s.runUser(ctx, userID, now)
+	}
+}
+
+func (s *Scheduler) runUser(ctx context.Context, userID uuid.UUID, now time.Time) {
+	// Without the user's timezone there is no telling when their morning is
+	user, err := s.directory.LookupUser(ctx, userID)
+	if err != nil {
+		slog.Warn("Failed to look up digest subscriber", "user_id", userID, "error", err)
+		return
+	}
+
+	local := now.In(location(user.Timezone))
+	if local.Hour() < s.config.Hour {
+		return
+	}
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+
+	s.send(ctx, user, channel.DigestDaily, today, s.daily)
+	if local.Weekday() == s.config.WeeklyDay {
+		s.send(ctx, user, channel.DigestWeekly, today, s.weekly)
+	}
+}
+
+// send claims the period before building the digest, so that instances
+// running side by side never both send it. The claim is released only when
+// no channel got the digest, a partly delivered one is not sent again.
+func (s *Scheduler) send(ctx context.Context, user delivery.User, kind string, day time.Time, build func(context.Context, uuid.UUID, time.Time) (*channel.Digest, error)) {
+	period := day.Format(time.DateOnly)
+	log := slog.With("user_id", user.ID, "digest", kind, "period", period)
+
+	claimed, err := s.storage.ClaimDigest(ctx, user.ID, kind, period)
+	if err != nil {
+		log.Error("Failed to claim digest", "error", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	digest, err := build(ctx, user.ID, day)
+	if err != nil {
+		log.Error("Failed to build digest", "error", err)
+		s.release(ctx, user.ID, kind, period)
+		return
+	}
+	if digest.Empty() {
+		log.Debug("Nothing to put in digest")
+		return
+	}
+
+	sent, err := s.pipeline.DeliverDigest(ctx, user, channel.Notification{
+		// Stable per period, so webhook receivers can deduplicate
+		ID:      uuid.NewSHA1(user.ID, []byte(kind+"/"+period)).String(),
+		Attempt: 1,
+		Digest:  digest,
+	})
+	if err != nil && sent == 0 {
+		log.Error("Failed to deliver digest", "error", err)
+		s.release(ctx, user.ID, kind, period)
+		return
+	}
+	if err != nil {
+		log.Warn("Digest delivered to some channels only", "sent", sent, "error", err)
+		return
+	}
+	log.Info("Digest delivered", "channels", sent)
+}
+
+func (s *Scheduler) release(ctx context.Context, userID uuid.UUID, kind, period string) {
+	if err := s.storage.ReleaseDigest(ctx, userID, kind, period); err != nil {
+		slog.Error("Failed to release digest claim", "user_id", userID, "digest", kind, "period", period, "error", err)
+	}
+}
+
+// daily lists the reminders of day.
+func (s *Scheduler) daily(ctx context.Context, userID uuid.UUID, day time.Time) (*channel.Digest, error) {
+	end := day.AddDate(0, 0, 1)
+	upcoming, err := s.reminders.RemindersBetween(ctx, userID, day, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return &channel.Digest{
+		Kind:     channel.DigestDaily,
+		From:     day,
+		To:       end,
+		Upcoming: upcoming,
+	}, nil
+}
+
+// weekly looks back at the seven days before day and ahead at the next seven.
+func (s *Scheduler) weekly(ctx context.Context, userID uuid.UUID, day time.Time) (*channel.Digest, error) {
+	start := day.AddDate(0, 0, -7)
+	past, err := s.reminders.RemindersBetween(ctx, userID, start, day)
+	if err != nil {
+		return nil, err
+	}
+
+	upcoming, err := s.reminders.RemindersBetween(ctx, userID, day, day.AddDate(0, 0, 7))
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := s.storage.ListUnreadInboxBetween(ctx, userID, start, day)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &channel.Digest{
+		Kind:     channel.DigestWeekly,
+		From:     start,
+		To:       day,
+		Upcoming: upcoming,
+	}
+	for _, r := range past {
+		if !r.IsSent {
+			digest.Overdue = append(digest.Overdue, r)
+		}
+	}
+	for _, item := range unread {
+		digest.Missed = append(digest.Missed, models.Reminder{
+			ID:          item.ReminderID,
+			UserID:      item.UserID,
+			Title:       item.Title,
+			Description: item.Body,
+			RemindAt:    item.Occurrence,
+			Priority:    item.Priority,
+			IsSent:      true,
+		})
+	}
+	return digest, nil
+}
+
+// location falls back to UTC for users without a (valid) timezone.
+func location(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
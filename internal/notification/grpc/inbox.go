@@ -0,0 +1,124 @@
+package notificationgrpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/notification/grpc/pb"
+	"github.com/kiribu/jwt-practice/internal/notification/service"
+	"github.com/kiribu/jwt-practice/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *NotificationServer) ListInbox(ctx context.Context, req *pb.ListInboxRequest) (*pb.ListInboxResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	var cursor uuid.UUID
+	if req.Cursor != "" {
+		cursor, err = uuid.Parse(req.Cursor)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, translate(ctx, "common.invalid_cursor"))
+		}
+	}
+
+	page, err := s.inbox.List(ctx, userID, cursor, int(req.Limit), req.UnreadOnly)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &pb.ListInboxResponse{
+		Items:       make([]*pb.InboxItemResponse, 0, len(page.Items)),
+		UnreadCount: int32(page.UnreadCount),
+	}
+	for i := range page.Items {
+		resp.Items = append(resp.Items, toProtoInboxItem(&page.Items[i]))
+	}
+	if page.NextCursor != uuid.Nil {
+		resp.NextCursor = page.NextCursor.String()
+	}
+	return resp, nil
+}
+
+func (s *NotificationServer) MarkInboxItemRead(ctx context.Context, req *pb.InboxItemRequest) (*pb.InboxItemResponse, error) {
+	userID, id, err := parseInboxItemRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.inbox.MarkRead(ctx, userID, id)
+	if err != nil {
+		return nil, inboxStatus(ctx, err)
+	}
+	return toProtoInboxItem(item), nil
+}
+
+func (s *NotificationServer) MarkAllInboxRead(ctx context.Context, req *pb.MarkAllInboxReadRequest) (*pb.MarkAllInboxReadResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	updated, err := s.inbox.MarkAllRead(ctx, userID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.MarkAllInboxReadResponse{Updated: int32(updated)}, nil
+}
+
+func (s *NotificationServer) DeleteInboxItem(ctx context.Context, req *pb.InboxItemRequest) (*pb.DeleteInboxItemResponse, error) {
+	userID, id, err := parseInboxItemRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.inbox.Delete(ctx, userID, id); err != nil {
+		return nil, inboxStatus(ctx, err)
+	}
+
+	return &pb.DeleteInboxItemResponse{
+		Success: true,
+		Message: translate(ctx, "inbox.deleted"),
+	}, nil
+}
+
+func parseInboxItemRequest(ctx context.Context, req *pb.InboxItemRequest) (uuid.UUID, uuid.UUID, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, status.Error(codes.InvalidArgument, translate(ctx, "common.invalid_id"))
+	}
+	return userID, id, nil
+}
+
+func inboxStatus(ctx context.Context, err error) error {
+	if errors.Is(err, service.ErrInboxItemNotFound) {
+		return status.Error(codes.NotFound, localize(ctx, err))
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func toProtoInboxItem(item *models.InboxItem) *pb.InboxItemResponse {
+	resp := &pb.InboxItemResponse{
+		Id:         item.ID.String(),
+		ReminderId: item.ReminderID.String(),
+		Title:      item.Title,
+		Body:       item.Body,
+		Priority:   item.Priority,
+		Occurrence: item.Occurrence.Format(time.RFC3339),
+		Read:       item.ReadAt != nil,
+		CreatedAt:  item.CreatedAt.Format(time.RFC3339),
+	}
+	if item.ReadAt != nil {
+		resp.ReadAt = item.ReadAt.Format(time.RFC3339)
+	}
+	return resp
+}
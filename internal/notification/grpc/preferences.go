@@ -0,0 +1,65 @@
+package notificationgrpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/notification/grpc/pb"
+	"github.com/kiribu/jwt-practice/internal/notification/service"
+	"github.com/kiribu/jwt-practice/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *NotificationServer) GetPreferences(ctx context.Context, req *pb.GetPreferencesRequest) (*pb.PreferencesResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	prefs, err := s.preferences.Preferences(ctx, userID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return s.toProtoPreferences(prefs), nil
+}
+
+func (s *NotificationServer) UpdatePreferences(ctx context.Context, req *pb.UpdatePreferencesRequest) (*pb.PreferencesResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	prefs, err := s.preferences.Update(ctx, models.NotificationPreferences{
+		UserID:           userID,
+		EnabledChannels:  req.EnabledChannels,
+		PriorityChannels: req.PriorityChannels,
+		Locale:           req.Locale,
+		DigestEnabled:    req.DigestEnabled,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPreferences) {
+			return nil, status.Error(codes.InvalidArgument, localize(ctx, err))
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return s.toProtoPreferences(prefs), nil
+}
+
+func (s *NotificationServer) toProtoPreferences(p *models.NotificationPreferences) *pb.PreferencesResponse {
+	resp := &pb.PreferencesResponse{
+		EnabledChannels:   p.EnabledChannels,
+		PriorityChannels:  p.PriorityChannels,
+		Locale:            p.Locale,
+		DigestEnabled:     p.DigestEnabled,
+		AvailableChannels: s.preferences.Channels(),
+	}
+	if !p.UpdatedAt.IsZero() {
+		resp.UpdatedAt = p.UpdatedAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,182 @@
+package notificationgrpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/notification/grpc/pb"
+	"github.com/kiribu/jwt-practice/internal/notification/service"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type NotificationServer struct {
+	pb.UnimplementedNotificationServiceServer
+	webhooks    *service.WebhookService
+	preferences *service.PreferencesService
+	inbox       *service.InboxService
+}
+
+func NewNotificationServer(webhooks *service.WebhookService, preferences *service.PreferencesService, inbox *service.InboxService) *NotificationServer {
+	return &NotificationServer{
+		webhooks:    webhooks,
+		preferences: preferences,
+		inbox:       inbox,
+	}
+}
+
+func (s *NotificationServer) CreateWebhook(ctx context.Context, req *pb.CreateWebhookRequest) (*pb.WebhookResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	webhook, err := s.webhooks.Create(ctx, userID, req.Url, req.Secret)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, localize(ctx, err))
+	}
+
+	resp := toProtoWebhook(webhook)
+	resp.Secret = webhook.Secret
+	return resp, nil
+}
+
+func (s *NotificationServer) ListWebhooks(ctx context.Context, req *pb.ListWebhooksRequest) (*pb.ListWebhooksResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	webhooks, err := s.webhooks.List(ctx, userID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &pb.ListWebhooksResponse{Webhooks: make([]*pb.WebhookResponse, 0, len(webhooks))}
+	for i := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, toProtoWebhook(&webhooks[i]))
+	}
+	return resp, nil
+}
+
+func (s *NotificationServer) DeleteWebhook(ctx context.Context, req *pb.WebhookRequest) (*pb.DeleteWebhookResponse, error) {
+	userID, id, err := parseWebhookRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.webhooks.Delete(ctx, userID, id); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &pb.DeleteWebhookResponse{
+		Success: true,
+		Message: translate(ctx, "webhook.deleted"),
+	}, nil
+}
+
+func (s *NotificationServer) EnableWebhook(ctx context.Context, req *pb.WebhookRequest) (*pb.WebhookResponse, error) {
+	userID, id, err := parseWebhookRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.webhooks.Enable(ctx, userID, id)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toProtoWebhook(webhook), nil
+}
+
+func (s *NotificationServer) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	userID, id, err := parseWebhookRequest(ctx, &pb.WebhookRequest{UserId: req.UserId, Id: req.WebhookId})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.webhooks.Deliveries(ctx, userID, id, int(req.Limit))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &pb.ListWebhookDeliveriesResponse{Deliveries: make([]*pb.WebhookDeliveryResponse, 0, len(deliveries))}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, toProtoDelivery(d))
+	}
+	return resp, nil
+}
+
+func (s *NotificationServer) SendTestWebhook(ctx context.Context, req *pb.WebhookRequest) (*pb.WebhookDeliveryResponse, error) {
+	userID, id, err := parseWebhookRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.webhooks.SendTest(ctx, userID, id)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toProtoDelivery(delivery), nil
+}
+
+func parseWebhookRequest(ctx context.Context, req *pb.WebhookRequest) (uuid.UUID, uuid.UUID, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+	id, err := uuid.Parse(req.Id)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, status.Error(codes.InvalidArgument, translate(ctx, "common.invalid_id"))
+	}
+	return userID, id, nil
+}
+
+func toStatus(ctx context.Context, err error) error {
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		return status.Error(codes.NotFound, localize(ctx, err))
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func toProtoWebhook(w *models.Webhook) *pb.WebhookResponse {
+	resp := &pb.WebhookResponse{
+		Id:                  w.ID.String(),
+		Url:                 w.URL,
+		Enabled:             w.Enabled,
+		ConsecutiveFailures: int32(w.ConsecutiveFailures),
+		CreatedAt:           w.CreatedAt.Format(time.RFC3339),
+	}
+	if w.DisabledAt != nil {
+		resp.DisabledAt = w.DisabledAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+func toProtoDelivery(d models.WebhookDelivery) *pb.WebhookDeliveryResponse {
+	return &pb.WebhookDeliveryResponse{
+		Id:             d.ID.String(),
+		DeliveryId:     d.DeliveryID.String(),
+		WebhookId:      d.WebhookID.String(),
+		EventType:      d.EventType,
+		Attempt:        int32(d.Attempt),
+		Success:        d.Success,
+		ResponseStatus: int32(d.ResponseStatus),
+		Error:          d.Error,
+		DurationMs:     d.DurationMs,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// translate renders a catalog message in the caller's language.
+func translate(ctx context.Context, key string, args ...any) string {
+	return i18n.T(i18n.FromContext(ctx), key, args...)
+}
+
+// localize renders err in the caller's language.
+func localize(ctx context.Context, err error) string {
+	return i18n.Message(i18n.FromContext(ctx), err)
+}
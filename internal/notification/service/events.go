@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/notification/delivery"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// eventSource identifies this service in the envelope of published events.
+const eventSource = "/notification-service"
+
+// EventPublisher publishes the notification service's own events.
+type EventPublisher struct {
+	publisher        broker.Publisher
+	preferencesTopic string
+	lifecycleTopic   string
+	contentType      string
+}
+
+func NewEventPublisher(publisher broker.Publisher, preferencesTopic, lifecycleTopic, contentType string) *EventPublisher {
+	return &EventPublisher{
+		publisher:        publisher,
+		preferencesTopic: preferencesTopic,
+		lifecycleTopic:   lifecycleTopic,
+		contentType:      contentType,
+	}
+}
+
+func (p *EventPublisher) PreferencesUpdated(ctx context.Context, prefs models.NotificationPreferences) error {
+	id := uuid.Must(uuid.NewV7())
+	return p.publish(ctx, p.preferencesTopic, id, events.TypePreferencesUpdated, prefs.UserID, prefs.UpdatedAt, events.PreferencesToProto(prefs))
+}
+
+// PublishReceipt reports the outcome of a channel send on the lifecycle topic.
+func (p *EventPublisher) PublishReceipt(ctx context.Context, r delivery.Receipt) error {
+	n := r.Notification
+	attempt := max(n.Attempt, 1)
+
+	eventType := events.TypeReminderNotificationDelivered
+	receipt := &models.DeliveryReceipt{
+		NotificationID: n.ID,
+		Channel:        r.Channel,
+		Attempt:        attempt,
+		LatencyMs:      r.At.Sub(n.Reminder.RemindAt).Milliseconds(),
+		DurationMs:     r.Duration.Milliseconds(),
+	}
+
+	// Receipt IDs are derived from the trigger, so a redelivered notification
+	// is counted as delivered once per channel and as failed once per attempt
+	name := n.ID + "/" + r.Channel + "/delivered"
+	if r.Err != nil {
+		eventType = events.TypeReminderNotificationFailed
+		receipt.Error = r.Err.Error()
+		name = fmt.Sprintf("%s/%s/failed/%d", n.ID, r.Channel, attempt)
+	}
+
+	event := models.LifecycleEvent{
+		ReminderID: n.Reminder.ID,
+		UserID:     n.UserID,
+		Delivery:   receipt,
+	}
+	id := uuid.NewSHA1(n.Reminder.ID, []byte(name))
+	return p.publish(ctx, p.lifecycleTopic, id, eventType, n.UserID, r.At, events.DeliveryToProto(event))
+}
+
+func (p *EventPublisher) publish(ctx context.Context, topic string, id uuid.UUID, eventType string, userID uuid.UUID, at time.Time, data proto.Message) error {
+	env := events.New(id.String(), eventType, eventSource, userID.String(), at)
+	env.ContentType = p.contentType
+
+	value, headers, err := events.Encode(env, data)
+	if err != nil {
+		return err
+	}
+
+	return p.publisher.Publish(ctx, broker.Message{
+		Topic:   topic,
+		Key:     userID.String(),
+		Value:   value,
+		Headers: headers,
+		Time:    time.Now(),
+	})
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/notification/channel"
+	"github.com/kiribu/jwt-practice/internal/notification/storage"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+	"github.com/kiribu/jwt-practice/pkg/live"
+)
+
+const (
+	defaultInboxPage = 20
+	maxInboxPage     = 100
+)
+
+var ErrInboxItemNotFound = i18n.NewError("inbox.not_found")
+
+// InboxPage is one page of a user's inbox. NextCursor is uuid.Nil on the last page.
+type InboxPage struct {
+	Items       []models.InboxItem
+	UnreadCount int
+	NextCursor  uuid.UUID
+}
+
+// LiveEventNotification is the live event carrying a new inbox item.
+const LiveEventNotification = "notification"
+
+type InboxService struct {
+	storage storage.NotificationStorage
+	live    *live.Publisher
+}
+
+// NewInboxService creates the inbox. New items are pushed to connected
+// clients through live, unless it is nil.
+func NewInboxService(storage storage.NotificationStorage, live *live.Publisher) *InboxService {
+	return &InboxService{
+		storage: storage,
+		live:    live,
+	}
+}
+
+// Store adds a delivered notification to its user's inbox and pushes it to
+// the user's open sessions. Pushing is best effort: clients that miss it
+// still find the item in the inbox.
+func (s *InboxService) Store(ctx context.Context, n channel.Notification) error {
+	item, err := s.storage.AddInboxItem(ctx, models.InboxItem{
+		ID:         uuid.Must(uuid.NewV7()),
+		UserID:     n.UserID,
+		ReminderID: n.Reminder.ID,
+		Occurrence: n.Reminder.RemindAt,
+		Title:      n.Reminder.Title,
+		Body:       n.Reminder.Description,
+		Priority:   n.Reminder.Priority,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Already stored by an earlier delivery, which also pushed it
+	if item == nil || s.live == nil {
+		return nil
+	}
+
+	if err := s.live.Publish(ctx, item.UserID, LiveEventNotification, item); err != nil {
+		slog.Error("Failed to push inbox item", "user_id", item.UserID, "id", item.ID, "error", err)
+	}
+	return nil
+}
+
+func (s *InboxService) List(ctx context.Context, userID, cursor uuid.UUID, limit int, unreadOnly bool) (InboxPage, error) {
+	if limit <= 0 {
+		limit = defaultInboxPage
+	}
+	if limit > maxInboxPage {
+		limit = maxInboxPage
+	}
+
+	// One extra item tells whether there is a next page
+	items, err := s.storage.ListInbox(ctx, userID, cursor, limit+1, unreadOnly)
+	if err != nil {
+		return InboxPage{}, err
+	}
+
+	unread, err := s.storage.CountUnreadInbox(ctx, userID)
+	if err != nil {
+		return InboxPage{}, err
+	}
+
+	page := InboxPage{Items: items, UnreadCount: unread}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = items[limit-1].ID
+	}
+	return page, nil
+}
+
+func (s *InboxService) MarkRead(ctx context.Context, userID, id uuid.UUID) (*models.InboxItem, error) {
+	item, err := s.storage.MarkInboxItemRead(ctx, userID, id)
+	return item, inboxNotFound(err)
+}
+
+// MarkAllRead returns how many items were unread.
+func (s *InboxService) MarkAllRead(ctx context.Context, userID uuid.UUID) (int, error) {
+	return s.storage.MarkAllInboxRead(ctx, userID)
+}
+
+func (s *InboxService) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	return inboxNotFound(s.storage.DeleteInboxItem(ctx, userID, id))
+}
+
+func inboxNotFound(err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrInboxItemNotFound
+	}
+	return err
+}
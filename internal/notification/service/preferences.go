@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/notification/storage"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+)
+
+const (
+	maxCachedPreferences = 10000
+
+	// preferencesCacheTTL only bounds staleness when an update event is lost;
+	// changes normally reach the cache through the preferences topic.
+	preferencesCacheTTL = 10 * time.Minute
+)
+
+var ErrInvalidPreferences = errors.New("invalid preferences")
+
+// PreferencesService stores users' notification preferences and keeps a
+// cache of them for the delivery pipeline.
+type PreferencesService struct {
+	storage         storage.NotificationStorage
+	events          *EventPublisher
+	channels        []string
+	defaultChannels []string
+	cache           *preferencesCache
+}
+
+// NewPreferencesService creates the service. channels are the channel names a
+// user may enable; defaultChannels apply to users who never saved preferences.
+func NewPreferencesService(storage storage.NotificationStorage, events *EventPublisher, channels, defaultChannels []string) *PreferencesService {
+	return &PreferencesService{
+		storage:         storage,
+		events:          events,
+		channels:        channels,
+		defaultChannels: defaultChannels,
+		cache:           newPreferencesCache(preferencesCacheTTL),
+	}
+}
+
+// Channels returns the channel names a user may enable.
+func (s *PreferencesService) Channels() []string {
+	return s.channels
+}
+
+// Preferences returns the user's preferences, from the cache when possible.
+func (s *PreferencesService) Preferences(ctx context.Context, userID uuid.UUID) (*models.NotificationPreferences, error) {
+	if prefs, ok := s.cache.get(userID); ok {
+		return prefs, nil
+	}
+
+	prefs, err := s.storage.GetPreferences(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		prefs = s.defaults(userID)
+	} else if err != nil {
+		return nil, err
+	}
+
+	s.cache.set(prefs)
+	return prefs, nil
+}
+
+func (s *PreferencesService) Update(ctx context.Context, prefs models.NotificationPreferences) (*models.NotificationPreferences, error) {
+	if prefs.Locale == "" {
+		prefs.Locale = string(i18n.Default)
+	}
+	if err := s.validate(prefs); err != nil {
+		return nil, i18n.Wrap(ErrInvalidPreferences, "preferences.invalid", err)
+	}
+
+	saved, err := s.storage.UpsertPreferences(ctx, prefs)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.set(saved)
+
+	// Other instances learn about the change from this event; ours is already up to date
+	if err := s.events.PreferencesUpdated(ctx, *saved); err != nil {
+		slog.Error("Failed to publish preferences update", "user_id", saved.UserID, "error", err)
+	}
+
+	return saved, nil
+}
+
+// Apply refreshes the cache from a preferences_updated event.
+func (s *PreferencesService) Apply(prefs models.NotificationPreferences) {
+	s.cache.set(&prefs)
+}
+
+func (s *PreferencesService) defaults(userID uuid.UUID) *models.NotificationPreferences {
+	return &models.NotificationPreferences{
+		UserID:           userID,
+		EnabledChannels:  append(models.ChannelList{}, s.defaultChannels...),
+		PriorityChannels: models.PriorityChannels{},
+		Locale:           string(i18n.Default),
+	}
+}
+
+func (s *PreferencesService) validate(prefs models.NotificationPreferences) error {
+	seen := make(map[string]bool)
+	for _, name := range prefs.EnabledChannels {
+		if !models.ChannelList(s.channels).Contains(name) {
+			return i18n.NewError("preferences.unknown_channel", name)
+		}
+		if seen[name] {
+			return i18n.NewError("preferences.duplicate_channel", name)
+		}
+		seen[name] = true
+	}
+
+	for priority, name := range prefs.PriorityChannels {
+		if !models.ValidPriority(priority) {
+			return i18n.NewError("preferences.unknown_priority", priority)
+		}
+		if !seen[name] {
+			return i18n.NewError("preferences.priority_channel_disabled", name, priority)
+		}
+	}
+
+	if l, ok := i18n.Parse(prefs.Locale); !ok || string(l) != prefs.Locale {
+		return i18n.NewError("preferences.unsupported_locale", prefs.Locale)
+	}
+
+	return nil
+}
+
+type cachedPreferences struct {
+	prefs     *models.NotificationPreferences
+	expiresAt time.Time
+}
+
+type preferencesCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[uuid.UUID]cachedPreferences
+}
+
+func newPreferencesCache(ttl time.Duration) *preferencesCache {
+	return &preferencesCache{
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]cachedPreferences),
+	}
+}
+
+func (c *preferencesCache) get(userID uuid.UUID) (*models.NotificationPreferences, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[userID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(c.entries, userID)
+		return nil, false
+	}
+	return entry.prefs, true
+}
+
+// set stores prefs unless the cache already holds a newer version, which
+// happens when update events arrive out of order.
+func (c *preferencesCache) set(prefs *models.NotificationPreferences) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[prefs.UserID]; ok && entry.prefs.UpdatedAt.After(prefs.UpdatedAt) {
+		return
+	}
+
+	now := time.Now()
+	if len(c.entries) >= maxCachedPreferences {
+		for userID, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, userID)
+			}
+		}
+	}
+
+	c.entries[prefs.UserID] = cachedPreferences{
+		prefs:     prefs,
+		expiresAt: now.Add(c.ttl),
+	}
+}
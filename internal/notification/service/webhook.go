@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/notification/channel"
+	"github.com/kiribu/jwt-practice/internal/notification/storage"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+)
+
+const (
+	minSecretLength     = 16
+	maxWebhooksPerUser  = 10
+	defaultDeliveryPage = 50
+	maxDeliveryPage     = 200
+)
+
+var ErrWebhookNotFound = i18n.NewError("webhook.not_found")
+
+type WebhookService struct {
+	storage storage.NotificationStorage
+	channel *channel.WebhookChannel
+}
+
+func NewWebhookService(storage storage.NotificationStorage, webhookChannel *channel.WebhookChannel) *WebhookService {
+	return &WebhookService{
+		storage: storage,
+		channel: webhookChannel,
+	}
+}
+
+func (s *WebhookService) Create(ctx context.Context, userID uuid.UUID, rawURL, secret string) (*models.Webhook, error) {
+	if err := validateWebhookURL(rawURL); err != nil {
+		return nil, err
+	}
+
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	} else if len(secret) < minSecretLength {
+		return nil, i18n.NewError("webhook.secret_too_short", minSecretLength)
+	}
+
+	existing, err := s.storage.ListWebhooks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxWebhooksPerUser {
+		return nil, i18n.NewError("webhook.limit_reached", maxWebhooksPerUser)
+	}
+
+	return s.storage.CreateWebhook(ctx, userID, rawURL, secret)
+}
+
+func (s *WebhookService) List(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error) {
+	return s.storage.ListWebhooks(ctx, userID)
+}
+
+func (s *WebhookService) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	return notFound(s.storage.DeleteWebhook(ctx, userID, id))
+}
+
+func (s *WebhookService) Enable(ctx context.Context, userID, id uuid.UUID) (*models.Webhook, error) {
+	webhook, err := s.storage.EnableWebhook(ctx, userID, id)
+	return webhook, notFound(err)
+}
+
+func (s *WebhookService) Deliveries(ctx context.Context, userID, id uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.storage.GetWebhook(ctx, userID, id); err != nil {
+		return nil, notFound(err)
+	}
+
+	if limit <= 0 {
+		limit = defaultDeliveryPage
+	}
+	if limit > maxDeliveryPage {
+		limit = maxDeliveryPage
+	}
+	return s.storage.ListWebhookDeliveries(ctx, userID, id, limit)
+}
+
+// SendTest delivers a webhook.test event right away, also to a disabled webhook,
+// so users can check their endpoint before enabling it again.
+func (s *WebhookService) SendTest(ctx context.Context, userID, id uuid.UUID) (models.WebhookDelivery, error) {
+	webhook, err := s.storage.GetWebhook(ctx, userID, id)
+	if err != nil {
+		return models.WebhookDelivery{}, notFound(err)
+	}
+
+	data := map[string]string{
+		"message": "This is a test event",
+		"sent_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	delivery, _ := s.channel.Deliver(ctx, *webhook, uuid.Must(uuid.NewV7()), channel.WebhookEventTest, data)
+	return delivery, nil
+}
+
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return i18n.NewError("webhook.invalid_url")
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return i18n.NewError("webhook.invalid_scheme")
+	}
+
+	// Host names are checked again on every delivery, after they resolve
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return i18n.NewError("webhook.private_address")
+	}
+	if ip := net.ParseIP(host); ip != nil && !channel.IsPublicIP(ip) {
+		return i18n.NewError("webhook.private_address")
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+package service
+
+import "testing"
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://example.com/hooks", true},
+		{"http://93.184.216.34:8080/hooks", true},
+		{"ftp://example.com/hooks", false},
+		{"https://", false},
+		{"http://localhost:8080/hooks", false},
+		{"http://api.localhost/hooks", false},
+		{"http://127.0.0.1/hooks", false},
+		{"http://[::1]/hooks", false},
+		{"http://10.0.0.5/hooks", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+	}
+	for _, tt := range tests {
+		if err := validateWebhookURL(tt.url); (err == nil) != tt.valid {
+			t.Errorf("validateWebhookURL(%q) = %v, want valid %v", tt.url, err, tt.valid)
+		}
+	}
+}
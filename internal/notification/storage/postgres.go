@@ -0,0 +1,371 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/kiribu/jwt-practice/models"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type NotificationStorage interface {
+	CreateWebhook(ctx context.Context, userID uuid.UUID, url, secret string) (*models.Webhook, error)
+	GetWebhook(ctx context.Context, userID, id uuid.UUID) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error)
+	ListEnabledWebhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID, id uuid.UUID) error
+	EnableWebhook(ctx context.Context, userID, id uuid.UUID) (*models.Webhook, error)
+	RecordWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+	RecordWebhookOutcome(ctx context.Context, id uuid.UUID, success bool, maxFailures int) (bool, error)
+	ListWebhookDeliveries(ctx context.Context, userID, webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error)
+	GetPreferences(ctx context.Context, userID uuid.UUID) (*models.NotificationPreferences, error)
+	UpsertPreferences(ctx context.Context, prefs models.NotificationPreferences) (*models.NotificationPreferences, error)
+	AddInboxItem(ctx context.Context, item models.InboxItem) (*models.InboxItem, error)
+	ListInbox(ctx context.Context, userID uuid.UUID, before uuid.UUID, limit int, unreadOnly bool) ([]models.InboxItem, error)
+	CountUnreadInbox(ctx context.Context, userID uuid.UUID) (int, error)
+	MarkInboxItemRead(ctx context.Context, userID, id uuid.UUID) (*models.InboxItem, error)
+	MarkAllInboxRead(ctx context.Context, userID uuid.UUID) (int, error)
+	DeleteInboxItem(ctx context.Context, userID, id uuid.UUID) error
+	ListUnreadInboxBetween(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]models.InboxItem, error)
+	ListDigestSubscribers(ctx context.Context) ([]uuid.UUID, error)
+	ClaimDigest(ctx context.Context, userID uuid.UUID, kind, period string) (bool, error)
+	ReleaseDigest(ctx context.Context, userID uuid.UUID, kind, period string) error
+}
+
+type PostgresStorage struct {
+	db *sqlx.DB
+}
+
+func NewPostgresStorage(db *sqlx.DB) *PostgresStorage {
+	return &PostgresStorage{db: db}
+}
+
+const webhookColumns = `id, user_id, url, secret, enabled, consecutive_failures, disabled_at, created_at, updated_at`
+
+func (s *PostgresStorage) CreateWebhook(ctx context.Context, userID uuid.UUID, url, secret string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := s.db.QueryRowxContext(ctx, `
+		INSERT INTO notification.webhooks (id, user_id, url, secret)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+webhookColumns,
+		uuid.Must(uuid.NewV7()), userID, url, secret,
+	).StructScan(&webhook)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (s *PostgresStorage) GetWebhook(ctx context.Context, userID, id uuid.UUID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := s.db.GetContext(ctx, &webhook,
+		`SELECT `+webhookColumns+` FROM notification.webhooks WHERE user_id = $1 AND id = $2`,
+		userID, id,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (s *PostgresStorage) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+	err := s.db.SelectContext(ctx, &webhooks,
+		`SELECT `+webhookColumns+` FROM notification.webhooks WHERE user_id = $1 ORDER BY created_at ASC`,
+		userID,
+	)
+	return webhooks, err
+}
+
+func (s *PostgresStorage) ListEnabledWebhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := s.db.SelectContext(ctx, &webhooks,
+		`SELECT `+webhookColumns+` FROM notification.webhooks WHERE user_id = $1 AND enabled = TRUE`,
+		userID,
+	)
+	return webhooks, err
+}
+
+func (s *PostgresStorage) DeleteWebhook(ctx context.Context, userID, id uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM notification.webhooks WHERE user_id = $1 AND id = $2`,
+		userID, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStorage) EnableWebhook(ctx context.Context, userID, id uuid.UUID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := s.db.QueryRowxContext(ctx, `
+		UPDATE notification.webhooks
+		SET enabled = TRUE, consecutive_failures = 0, disabled_at = NULL, updated_at = NOW()
+		WHERE user_id = $1 AND id = $2
+		RETURNING `+webhookColumns,
+		userID, id,
+	).StructScan(&webhook)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (s *PostgresStorage) RecordWebhookDelivery(ctx context.Context, d models.WebhookDelivery) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification.webhook_deliveries
+			(id, delivery_id, webhook_id, user_id, event_type, attempt, success, response_status, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		d.ID, d.DeliveryID, d.WebhookID, d.UserID, d.EventType,
+		d.Attempt, d.Success, d.ResponseStatus, d.Error, d.DurationMs,
+	)
+	return err
+}
+
+// RecordWebhookOutcome resets the failure streak on success, or extends it and
+// disables the webhook once it reaches maxFailures. It reports whether the
+// webhook was disabled by this call.
+func (s *PostgresStorage) RecordWebhookOutcome(ctx context.Context, id uuid.UUID, success bool, maxFailures int) (bool, error) {
+	if success {
+		_, err := s.db.ExecContext(ctx, `
+			UPDATE notification.webhooks
+			SET consecutive_failures = 0, updated_at = NOW()
+			WHERE id = $1 AND consecutive_failures <> 0`,
+			id,
+		)
+		return false, err
+	}
+
+	var disabled bool
+	err := s.db.GetContext(ctx, &disabled, `
+		UPDATE notification.webhooks
+		SET consecutive_failures = consecutive_failures + 1,
+		    enabled = consecutive_failures + 1 < $2,
+		    disabled_at = CASE WHEN consecutive_failures + 1 >= $2 THEN NOW() ELSE disabled_at END,
+		    updated_at = NOW()
+		WHERE id = $1 AND enabled = TRUE
+		RETURNING NOT enabled`,
+		id, maxFailures,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return disabled, err
+}
+
+func (s *PostgresStorage) ListWebhookDeliveries(ctx context.Context, userID, webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+	err := s.db.SelectContext(ctx, &deliveries, `
+		SELECT id, delivery_id, webhook_id, user_id, event_type, attempt, success, response_status, error, duration_ms, created_at
+		FROM notification.webhook_deliveries
+		WHERE user_id = $1 AND webhook_id = $2
+		ORDER BY created_at DESC
+		LIMIT $3`,
+		userID, webhookID, limit,
+	)
+	return deliveries, err
+}
+
+const preferencesColumns = `user_id, enabled_channels, priority_channels, locale, digest_enabled, updated_at`
+
+func (s *PostgresStorage) GetPreferences(ctx context.Context, userID uuid.UUID) (*models.NotificationPreferences, error) {
+	var prefs models.NotificationPreferences
+	err := s.db.GetContext(ctx, &prefs,
+		`SELECT `+preferencesColumns+` FROM notification.preferences WHERE user_id = $1`,
+		userID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+func (s *PostgresStorage) UpsertPreferences(ctx context.Context, prefs models.NotificationPreferences) (*models.NotificationPreferences, error) {
+	var saved models.NotificationPreferences
+	err := s.db.QueryRowxContext(ctx, `
+		INSERT INTO notification.preferences (user_id, enabled_channels, priority_channels, locale, digest_enabled)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			enabled_channels = EXCLUDED.enabled_channels,
+			priority_channels = EXCLUDED.priority_channels,
+			locale = EXCLUDED.locale,
+			digest_enabled = EXCLUDED.digest_enabled,
+			updated_at = NOW()
+		RETURNING `+preferencesColumns,
+		prefs.UserID, prefs.EnabledChannels, prefs.PriorityChannels, prefs.Locale, prefs.DigestEnabled,
+	).StructScan(&saved)
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+const inboxColumns = `id, user_id, reminder_id, occurrence, title, body, priority, read_at, created_at`
+
+// AddInboxItem ignores an item for an occurrence that is already in the
+// inbox, so that redelivered notifications are stored once. It returns the
+// stored item, or nil when the item was a duplicate.
+func (s *PostgresStorage) AddInboxItem(ctx context.Context, item models.InboxItem) (*models.InboxItem, error) {
+	var stored models.InboxItem
+	err := s.db.QueryRowxContext(ctx, `
+		INSERT INTO notification.inbox (id, user_id, reminder_id, occurrence, title, body, priority)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, reminder_id, occurrence) DO NOTHING
+		RETURNING `+inboxColumns,
+		item.ID, item.UserID, item.ReminderID, item.Occurrence, item.Title, item.Body, item.Priority,
+	).StructScan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// ListInbox returns the newest items first. A non-nil before continues a
+// previous page after the item with that ID.
+func (s *PostgresStorage) ListInbox(ctx context.Context, userID uuid.UUID, before uuid.UUID, limit int, unreadOnly bool) ([]models.InboxItem, error) {
+	query := `SELECT ` + inboxColumns + ` FROM notification.inbox WHERE user_id = $1`
+	args := []any{userID}
+
+	if before != uuid.Nil {
+		args = append(args, before)
+		query += fmt.Sprintf(" AND id < $%d", len(args))
+	}
+	if unreadOnly {
+		query += " AND read_at IS NULL"
+	}
+
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	items := []models.InboxItem{}
+	err := s.db.SelectContext(ctx, &items, query, args...)
+	return items, err
+}
+
+func (s *PostgresStorage) CountUnreadInbox(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := s.db.GetContext(ctx, &count,
+		`SELECT COUNT(*) FROM notification.inbox WHERE user_id = $1 AND read_at IS NULL`,
+		userID,
+	)
+	return count, err
+}
+
+// MarkInboxItemRead keeps the time an item was first read.
+func (s *PostgresStorage) MarkInboxItemRead(ctx context.Context, userID, id uuid.UUID) (*models.InboxItem, error) {
+	var item models.InboxItem
+	err := s.db.QueryRowxContext(ctx, `
+		UPDATE notification.inbox
+		SET read_at = COALESCE(read_at, NOW())
+		WHERE user_id = $1 AND id = $2
+		RETURNING `+inboxColumns,
+		userID, id,
+	).StructScan(&item)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *PostgresStorage) MarkAllInboxRead(ctx context.Context, userID uuid.UUID) (int, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE notification.inbox SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return int(rowsAffected), nil
+}
+
+func (s *PostgresStorage) DeleteInboxItem(ctx context.Context, userID, id uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM notification.inbox WHERE user_id = $1 AND id = $2`,
+		userID, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListUnreadInboxBetween returns unread items for occurrences in [from, to).
+func (s *PostgresStorage) ListUnreadInboxBetween(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]models.InboxItem, error) {
+	items := []models.InboxItem{}
+	err := s.db.SelectContext(ctx, &items, `
+		SELECT `+inboxColumns+`
+		FROM notification.inbox
+		WHERE user_id = $1 AND read_at IS NULL AND occurrence >= $2 AND occurrence < $3
+		ORDER BY occurrence ASC`,
+		userID, from, to,
+	)
+	return items, err
+}
+
+func (s *PostgresStorage) ListDigestSubscribers(ctx context.Context) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	err := s.db.SelectContext(ctx, &userIDs,
+		`SELECT user_id FROM notification.preferences WHERE digest_enabled = TRUE`,
+	)
+	return userIDs, err
+}
+
+// ClaimDigest records the digest of kind for period (a date) as sent. It
+// reports false when it was already claimed, by this or another instance.
+func (s *PostgresStorage) ClaimDigest(ctx context.Context, userID uuid.UUID, kind, period string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification.digests (user_id, kind, period)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`,
+		userID, kind, period,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// ReleaseDigest undoes a claim for a digest that could not be sent at all.
+func (s *PostgresStorage) ReleaseDigest(ctx context.Context, userID uuid.UUID, kind, period string) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM notification.digests WHERE user_id = $1 AND kind = $2 AND period = $3`,
+		userID, kind, period,
+	)
+	return err
+}
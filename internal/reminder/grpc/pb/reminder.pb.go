@@ -27,6 +27,7 @@ type CreateReminderRequest struct {
 	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
 	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
 	RemindAt      string                 `protobuf:"bytes,4,opt,name=remind_at,json=remindAt,proto3" json:"remind_at,omitempty"`
+	Priority      string                 `protobuf:"bytes,5,opt,name=priority,proto3" json:"priority,omitempty"` // "low", "normal" (default) or "high"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -89,6 +90,13 @@ func (x *CreateReminderRequest) GetRemindAt() string {
 	return ""
 }
 
+func (x *CreateReminderRequest) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
 type GetRemindersRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // UUID as string
@@ -141,6 +149,66 @@ func (x *GetRemindersRequest) GetStatus() string {
 	return ""
 }
 
+type GetRemindersInRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // UUID as string
+	From          string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`                   // RFC 3339, inclusive
+	To            string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`                       // RFC 3339, exclusive
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRemindersInRangeRequest) Reset() {
+	*x = GetRemindersInRangeRequest{}
+	mi := &file_proto_reminder_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRemindersInRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRemindersInRangeRequest) ProtoMessage() {}
+
+func (x *GetRemindersInRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_reminder_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRemindersInRangeRequest.ProtoReflect.Descriptor instead.
+func (*GetRemindersInRangeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_reminder_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetRemindersInRangeRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetRemindersInRangeRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *GetRemindersInRangeRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
 type GetReminderRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // UUID as string
@@ -151,7 +219,7 @@ type GetReminderRequest struct {
 
 func (x *GetReminderRequest) Reset() {
 	*x = GetReminderRequest{}
-	mi := &file_proto_reminder_proto_msgTypes[2]
+	mi := &file_proto_reminder_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -163,7 +231,7 @@ func (x *GetReminderRequest) String() string {
 func (*GetReminderRequest) ProtoMessage() {}
 
 func (x *GetReminderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_reminder_proto_msgTypes[2]
+	mi := &file_proto_reminder_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -176,7 +244,7 @@ func (x *GetReminderRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetReminderRequest.ProtoReflect.Descriptor instead.
 func (*GetReminderRequest) Descriptor() ([]byte, []int) {
-	return file_proto_reminder_proto_rawDescGZIP(), []int{2}
+	return file_proto_reminder_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *GetReminderRequest) GetUserId() string {
@@ -200,13 +268,14 @@ type UpdateReminderRequest struct {
 	Title         string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
 	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
 	RemindAt      string                 `protobuf:"bytes,5,opt,name=remind_at,json=remindAt,proto3" json:"remind_at,omitempty"`
+	Priority      string                 `protobuf:"bytes,6,opt,name=priority,proto3" json:"priority,omitempty"` // "low", "normal" (default) or "high"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UpdateReminderRequest) Reset() {
 	*x = UpdateReminderRequest{}
-	mi := &file_proto_reminder_proto_msgTypes[3]
+	mi := &file_proto_reminder_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -218,7 +287,7 @@ func (x *UpdateReminderRequest) String() string {
 func (*UpdateReminderRequest) ProtoMessage() {}
 
 func (x *UpdateReminderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_reminder_proto_msgTypes[3]
+	mi := &file_proto_reminder_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -231,7 +300,7 @@ func (x *UpdateReminderRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateReminderRequest.ProtoReflect.Descriptor instead.
 func (*UpdateReminderRequest) Descriptor() ([]byte, []int) {
-	return file_proto_reminder_proto_rawDescGZIP(), []int{3}
+	return file_proto_reminder_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *UpdateReminderRequest) GetUserId() string {
@@ -269,6 +338,13 @@ func (x *UpdateReminderRequest) GetRemindAt() string {
 	return ""
 }
 
+func (x *UpdateReminderRequest) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
 type DeleteReminderRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // UUID as string
@@ -279,7 +355,7 @@ type DeleteReminderRequest struct {
 
 func (x *DeleteReminderRequest) Reset() {
 	*x = DeleteReminderRequest{}
-	mi := &file_proto_reminder_proto_msgTypes[4]
+	mi := &file_proto_reminder_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -291,7 +367,7 @@ func (x *DeleteReminderRequest) String() string {
 func (*DeleteReminderRequest) ProtoMessage() {}
 
 func (x *DeleteReminderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_reminder_proto_msgTypes[4]
+	mi := &file_proto_reminder_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -304,7 +380,7 @@ func (x *DeleteReminderRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteReminderRequest.ProtoReflect.Descriptor instead.
 func (*DeleteReminderRequest) Descriptor() ([]byte, []int) {
-	return file_proto_reminder_proto_rawDescGZIP(), []int{4}
+	return file_proto_reminder_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *DeleteReminderRequest) GetUserId() string {
@@ -331,13 +407,14 @@ type ReminderResponse struct {
 	IsSent        bool                   `protobuf:"varint,6,opt,name=is_sent,json=isSent,proto3" json:"is_sent,omitempty"`
 	CreatedAt     string                 `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt     string                 `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Priority      string                 `protobuf:"bytes,9,opt,name=priority,proto3" json:"priority,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ReminderResponse) Reset() {
 	*x = ReminderResponse{}
-	mi := &file_proto_reminder_proto_msgTypes[5]
+	mi := &file_proto_reminder_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -349,7 +426,7 @@ func (x *ReminderResponse) String() string {
 func (*ReminderResponse) ProtoMessage() {}
 
 func (x *ReminderResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_reminder_proto_msgTypes[5]
+	mi := &file_proto_reminder_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -362,7 +439,7 @@ func (x *ReminderResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReminderResponse.ProtoReflect.Descriptor instead.
 func (*ReminderResponse) Descriptor() ([]byte, []int) {
-	return file_proto_reminder_proto_rawDescGZIP(), []int{5}
+	return file_proto_reminder_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ReminderResponse) GetId() string {
@@ -421,6 +498,13 @@ func (x *ReminderResponse) GetUpdatedAt() string {
 	return ""
 }
 
+func (x *ReminderResponse) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
 type GetRemindersResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Reminders     []*ReminderResponse    `protobuf:"bytes,1,rep,name=reminders,proto3" json:"reminders,omitempty"`
@@ -430,7 +514,7 @@ type GetRemindersResponse struct {
 
 func (x *GetRemindersResponse) Reset() {
 	*x = GetRemindersResponse{}
-	mi := &file_proto_reminder_proto_msgTypes[6]
+	mi := &file_proto_reminder_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -442,7 +526,7 @@ func (x *GetRemindersResponse) String() string {
 func (*GetRemindersResponse) ProtoMessage() {}
 
 func (x *GetRemindersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_reminder_proto_msgTypes[6]
+	mi := &file_proto_reminder_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -455,7 +539,7 @@ func (x *GetRemindersResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRemindersResponse.ProtoReflect.Descriptor instead.
 func (*GetRemindersResponse) Descriptor() ([]byte, []int) {
-	return file_proto_reminder_proto_rawDescGZIP(), []int{6}
+	return file_proto_reminder_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GetRemindersResponse) GetReminders() []*ReminderResponse {
@@ -475,7 +559,7 @@ type DeleteReminderResponse struct {
 
 func (x *DeleteReminderResponse) Reset() {
 	*x = DeleteReminderResponse{}
-	mi := &file_proto_reminder_proto_msgTypes[7]
+	mi := &file_proto_reminder_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -487,7 +571,7 @@ func (x *DeleteReminderResponse) String() string {
 func (*DeleteReminderResponse) ProtoMessage() {}
 
 func (x *DeleteReminderResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_reminder_proto_msgTypes[7]
+	mi := &file_proto_reminder_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -500,7 +584,7 @@ func (x *DeleteReminderResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteReminderResponse.ProtoReflect.Descriptor instead.
 func (*DeleteReminderResponse) Descriptor() ([]byte, []int) {
-	return file_proto_reminder_proto_rawDescGZIP(), []int{7}
+	return file_proto_reminder_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *DeleteReminderResponse) GetSuccess() bool {
@@ -521,27 +605,33 @@ var File_proto_reminder_proto protoreflect.FileDescriptor
 
 const file_proto_reminder_proto_rawDesc = "" +
 	"\n" +
-	"\x14proto/reminder.proto\x12\breminder\"\x85\x01\n" +
+	"\x14proto/reminder.proto\x12\breminder\"\xa1\x01\n" +
 	"\x15CreateReminderRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
 	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1b\n" +
-	"\tremind_at\x18\x04 \x01(\tR\bremindAt\"F\n" +
+	"\tremind_at\x18\x04 \x01(\tR\bremindAt\x12\x1a\n" +
+	"\bpriority\x18\x05 \x01(\tR\bpriority\"F\n" +
 	"\x13GetRemindersRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
-	"\x06status\x18\x02 \x01(\tR\x06status\"=\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"Y\n" +
+	"\x1aGetRemindersInRangeRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\"=\n" +
 	"\x12GetReminderRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x0e\n" +
-	"\x02id\x18\x02 \x01(\tR\x02id\"\x95\x01\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\"\xb1\x01\n" +
 	"\x15UpdateReminderRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x0e\n" +
 	"\x02id\x18\x02 \x01(\tR\x02id\x12\x14\n" +
 	"\x05title\x18\x03 \x01(\tR\x05title\x12 \n" +
 	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x1b\n" +
-	"\tremind_at\x18\x05 \x01(\tR\bremindAt\"@\n" +
+	"\tremind_at\x18\x05 \x01(\tR\bremindAt\x12\x1a\n" +
+	"\bpriority\x18\x06 \x01(\tR\bpriority\"@\n" +
 	"\x15DeleteReminderRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x0e\n" +
-	"\x02id\x18\x02 \x01(\tR\x02id\"\xe7\x01\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\"\x83\x02\n" +
 	"\x10ReminderResponse\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x14\n" +
@@ -552,18 +642,20 @@ const file_proto_reminder_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\a \x01(\tR\tcreatedAt\x12\x1d\n" +
 	"\n" +
-	"updated_at\x18\b \x01(\tR\tupdatedAt\"P\n" +
+	"updated_at\x18\b \x01(\tR\tupdatedAt\x12\x1a\n" +
+	"\bpriority\x18\t \x01(\tR\bpriority\"P\n" +
 	"\x14GetRemindersResponse\x128\n" +
 	"\treminders\x18\x01 \x03(\v2\x1a.reminder.ReminderResponseR\treminders\"L\n" +
 	"\x16DeleteReminderResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2\x9c\x03\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage2\xf9\x03\n" +
 	"\x0fReminderService\x12M\n" +
 	"\x0eCreateReminder\x12\x1f.reminder.CreateReminderRequest\x1a\x1a.reminder.ReminderResponse\x12M\n" +
 	"\fGetReminders\x12\x1d.reminder.GetRemindersRequest\x1a\x1e.reminder.GetRemindersResponse\x12G\n" +
 	"\vGetReminder\x12\x1c.reminder.GetReminderRequest\x1a\x1a.reminder.ReminderResponse\x12M\n" +
 	"\x0eUpdateReminder\x12\x1f.reminder.UpdateReminderRequest\x1a\x1a.reminder.ReminderResponse\x12S\n" +
-	"\x0eDeleteReminder\x12\x1f.reminder.DeleteReminderRequest\x1a .reminder.DeleteReminderResponseB:Z8github.com/kiribu/jwt-practice/internal/reminder/grpc/pbb\x06proto3"
+	"\x0eDeleteReminder\x12\x1f.reminder.DeleteReminderRequest\x1a .reminder.DeleteReminderResponse\x12[\n" +
+	"\x13GetRemindersInRange\x12$.reminder.GetRemindersInRangeRequest\x1a\x1e.reminder.GetRemindersResponseB:Z8github.com/kiribu/jwt-practice/internal/reminder/grpc/pbb\x06proto3"
 
 var (
 	file_proto_reminder_proto_rawDescOnce sync.Once
@@ -577,31 +669,34 @@ func file_proto_reminder_proto_rawDescGZIP() []byte {
 	return file_proto_reminder_proto_rawDescData
 }
 
-var file_proto_reminder_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_reminder_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_proto_reminder_proto_goTypes = []any{
-	(*CreateReminderRequest)(nil),  // 0: reminder.CreateReminderRequest
-	(*GetRemindersRequest)(nil),    // 1: reminder.GetRemindersRequest
-	(*GetReminderRequest)(nil),     // 2: reminder.GetReminderRequest
-	(*UpdateReminderRequest)(nil),  // 3: reminder.UpdateReminderRequest
-	(*DeleteReminderRequest)(nil),  // 4: reminder.DeleteReminderRequest
-	(*ReminderResponse)(nil),       // 5: reminder.ReminderResponse
-	(*GetRemindersResponse)(nil),   // 6: reminder.GetRemindersResponse
-	(*DeleteReminderResponse)(nil), // 7: reminder.DeleteReminderResponse
+	(*CreateReminderRequest)(nil),      // 0: reminder.CreateReminderRequest
+	(*GetRemindersRequest)(nil),        // 1: reminder.GetRemindersRequest
+	(*GetRemindersInRangeRequest)(nil), // 2: reminder.GetRemindersInRangeRequest
+	(*GetReminderRequest)(nil),         // 3: reminder.GetReminderRequest
+	(*UpdateReminderRequest)(nil),      // 4: reminder.UpdateReminderRequest
+	(*DeleteReminderRequest)(nil),      // 5: reminder.DeleteReminderRequest
+	(*ReminderResponse)(nil),           // 6: reminder.ReminderResponse
+	(*GetRemindersResponse)(nil),       // 7: reminder.GetRemindersResponse
+	(*DeleteReminderResponse)(nil),     // 8: reminder.DeleteReminderResponse
 }
 var file_proto_reminder_proto_depIdxs = []int32{
-	5, // 0: reminder.GetRemindersResponse.reminders:type_name -> reminder.ReminderResponse
+	6, // 0: reminder.GetRemindersResponse.reminders:type_name -> reminder.ReminderResponse
 	0, // 1: reminder.ReminderService.CreateReminder:input_type -> reminder.CreateReminderRequest
 	1, // 2: reminder.ReminderService.GetReminders:input_type -> reminder.GetRemindersRequest
-	2, // 3: reminder.ReminderService.GetReminder:input_type -> reminder.GetReminderRequest
-	3, // 4: reminder.ReminderService.UpdateReminder:input_type -> reminder.UpdateReminderRequest
-	4, // 5: reminder.ReminderService.DeleteReminder:input_type -> reminder.DeleteReminderRequest
-	5, // 6: reminder.ReminderService.CreateReminder:output_type -> reminder.ReminderResponse
-	6, // 7: reminder.ReminderService.GetReminders:output_type -> reminder.GetRemindersResponse
-	5, // 8: reminder.ReminderService.GetReminder:output_type -> reminder.ReminderResponse
-	5, // 9: reminder.ReminderService.UpdateReminder:output_type -> reminder.ReminderResponse
-	7, // 10: reminder.ReminderService.DeleteReminder:output_type -> reminder.DeleteReminderResponse
-	6, // [6:11] is the sub-list for method output_type
-	1, // [1:6] is the sub-list for method input_type
+	3, // 3: reminder.ReminderService.GetReminder:input_type -> reminder.GetReminderRequest
+	4, // 4: reminder.ReminderService.UpdateReminder:input_type -> reminder.UpdateReminderRequest
+	5, // 5: reminder.ReminderService.DeleteReminder:input_type -> reminder.DeleteReminderRequest
+	2, // 6: reminder.ReminderService.GetRemindersInRange:input_type -> reminder.GetRemindersInRangeRequest
+	6, // 7: reminder.ReminderService.CreateReminder:output_type -> reminder.ReminderResponse
+	7, // 8: reminder.ReminderService.GetReminders:output_type -> reminder.GetRemindersResponse
+	6, // 9: reminder.ReminderService.GetReminder:output_type -> reminder.ReminderResponse
+	6, // 10: reminder.ReminderService.UpdateReminder:output_type -> reminder.ReminderResponse
+	8, // 11: reminder.ReminderService.DeleteReminder:output_type -> reminder.DeleteReminderResponse
+	7, // 12: reminder.ReminderService.GetRemindersInRange:output_type -> reminder.GetRemindersResponse
+	7, // [7:13] is the sub-list for method output_type
+	1, // [1:7] is the sub-list for method input_type
 	1, // [1:1] is the sub-list for extension type_name
 	1, // [1:1] is the sub-list for extension extendee
 	0, // [0:1] is the sub-list for field type_name
@@ -618,7 +713,7 @@ func file_proto_reminder_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_reminder_proto_rawDesc), len(file_proto_reminder_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   8,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
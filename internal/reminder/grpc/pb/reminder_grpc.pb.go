@@ -19,11 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ReminderService_CreateReminder_FullMethodName = "/reminder.ReminderService/CreateReminder"
-	ReminderService_GetReminders_FullMethodName   = "/reminder.ReminderService/GetReminders"
-	ReminderService_GetReminder_FullMethodName    = "/reminder.ReminderService/GetReminder"
-	ReminderService_UpdateReminder_FullMethodName = "/reminder.ReminderService/UpdateReminder"
-	ReminderService_DeleteReminder_FullMethodName = "/reminder.ReminderService/DeleteReminder"
+	ReminderService_CreateReminder_FullMethodName      = "/reminder.ReminderService/CreateReminder"
+	ReminderService_GetReminders_FullMethodName        = "/reminder.ReminderService/GetReminders"
+	ReminderService_GetReminder_FullMethodName         = "/reminder.ReminderService/GetReminder"
+	ReminderService_UpdateReminder_FullMethodName      = "/reminder.ReminderService/UpdateReminder"
+	ReminderService_DeleteReminder_FullMethodName      = "/reminder.ReminderService/DeleteReminder"
+	ReminderService_GetRemindersInRange_FullMethodName = "/reminder.ReminderService/GetRemindersInRange"
 )
 
 // ReminderServiceClient is the client API for ReminderService service.
@@ -35,6 +36,7 @@ type ReminderServiceClient interface {
 	GetReminder(ctx context.Context, in *GetReminderRequest, opts ...grpc.CallOption) (*ReminderResponse, error)
 	UpdateReminder(ctx context.Context, in *UpdateReminderRequest, opts ...grpc.CallOption) (*ReminderResponse, error)
 	DeleteReminder(ctx context.Context, in *DeleteReminderRequest, opts ...grpc.CallOption) (*DeleteReminderResponse, error)
+	GetRemindersInRange(ctx context.Context, in *GetRemindersInRangeRequest, opts ...grpc.CallOption) (*GetRemindersResponse, error)
 }
 
 type reminderServiceClient struct {
@@ -95,6 +97,16 @@ func (c *reminderServiceClient) DeleteReminder(ctx context.Context, in *DeleteRe
 	return out, nil
 }
 
+func (c *reminderServiceClient) GetRemindersInRange(ctx context.Context, in *GetRemindersInRangeRequest, opts ...grpc.CallOption) (*GetRemindersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRemindersResponse)
+	err := c.cc.Invoke(ctx, ReminderService_GetRemindersInRange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ReminderServiceServer is the server API for ReminderService service.
 // All implementations must embed UnimplementedReminderServiceServer
 // for forward compatibility.
@@ -104,6 +116,7 @@ type ReminderServiceServer interface {
 	GetReminder(context.Context, *GetReminderRequest) (*ReminderResponse, error)
 	UpdateReminder(context.Context, *UpdateReminderRequest) (*ReminderResponse, error)
 	DeleteReminder(context.Context, *DeleteReminderRequest) (*DeleteReminderResponse, error)
+	GetRemindersInRange(context.Context, *GetRemindersInRangeRequest) (*GetRemindersResponse, error)
 	mustEmbedUnimplementedReminderServiceServer()
 }
 
@@ -129,6 +142,9 @@ func (UnimplementedReminderServiceServer) UpdateReminder(context.Context, *Updat
 func (UnimplementedReminderServiceServer) DeleteReminder(context.Context, *DeleteReminderRequest) (*DeleteReminderResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method DeleteReminder not implemented")
 }
+func (UnimplementedReminderServiceServer) GetRemindersInRange(context.Context, *GetRemindersInRangeRequest) (*GetRemindersResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetRemindersInRange not implemented")
+}
 func (UnimplementedReminderServiceServer) mustEmbedUnimplementedReminderServiceServer() {}
 func (UnimplementedReminderServiceServer) testEmbeddedByValue()                         {}
 
@@ -240,6 +256,24 @@ func _ReminderService_DeleteReminder_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ReminderService_GetRemindersInRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRemindersInRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReminderServiceServer).GetRemindersInRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReminderService_GetRemindersInRange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReminderServiceServer).GetRemindersInRange(ctx, req.(*GetRemindersInRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ReminderService_ServiceDesc is the grpc.ServiceDesc for ReminderService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -267,6 +301,10 @@ var ReminderService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteReminder",
 			Handler:    _ReminderService_DeleteReminder_Handler,
 		},
+		{
+			MethodName: "GetRemindersInRange",
+			Handler:    _ReminderService_GetRemindersInRange_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/reminder.proto",
@@ -7,6 +7,7 @@ import (
 	"github.com/kiribu/jwt-practice/internal/reminder/grpc/pb"
 	"github.com/kiribu/jwt-practice/internal/reminder/service"
 	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -26,9 +27,9 @@ func (s *ReminderServer) CreateReminder(ctx context.Context, req *pb.CreateRemin
 		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
 	}
 
-	reminder, err := s.service.Create(userID, req.Title, req.Description, req.RemindAt)
+	reminder, err := s.service.Create(userID, req.Title, req.Description, req.Priority, req.RemindAt)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, status.Error(codes.InvalidArgument, localize(ctx, err))
 	}
 
 	return toProtoReminder(reminder), nil
@@ -53,6 +54,25 @@ func (s *ReminderServer) GetReminders(ctx context.Context, req *pb.GetRemindersR
 	return &pb.GetRemindersResponse{Reminders: protoReminders}, nil
 }
 
+func (s *ReminderServer) GetRemindersInRange(ctx context.Context, req *pb.GetRemindersInRangeRequest) (*pb.GetRemindersResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id: %v", err)
+	}
+
+	reminders, err := s.service.GetInRange(userID, req.From, req.To)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, localize(ctx, err))
+	}
+
+	protoReminders := make([]*pb.ReminderResponse, 0, len(reminders))
+	for _, r := range reminders {
+		protoReminders = append(protoReminders, toProtoReminder(&r))
+	}
+
+	return &pb.GetRemindersResponse{Reminders: protoReminders}, nil
+}
+
 func (s *ReminderServer) GetReminder(ctx context.Context, req *pb.GetReminderRequest) (*pb.ReminderResponse, error) {
 	userID, err := uuid.Parse(req.UserId)
 	if err != nil {
@@ -60,12 +80,12 @@ func (s *ReminderServer) GetReminder(ctx context.Context, req *pb.GetReminderReq
 	}
 	id, err := uuid.Parse(req.Id)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid id: %v", err)
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "common.invalid_id"))
 	}
 
 	reminder, err := s.service.GetByID(userID, id)
 	if err != nil {
-		return nil, status.Error(codes.NotFound, err.Error())
+		return nil, status.Error(codes.NotFound, localize(ctx, err))
 	}
 
 	return toProtoReminder(reminder), nil
@@ -78,12 +98,12 @@ func (s *ReminderServer) UpdateReminder(ctx context.Context, req *pb.UpdateRemin
 	}
 	id, err := uuid.Parse(req.Id)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid id: %v", err)
+		return nil, status.Error(codes.InvalidArgument, translate(ctx, "common.invalid_id"))
 	}
 
-	reminder, err := s.service.Update(userID, id, req.Title, req.Description, req.RemindAt)
+	reminder, err := s.service.Update(userID, id, req.Title, req.Description, req.Priority, req.RemindAt)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, status.Error(codes.InvalidArgument, localize(ctx, err))
 	}
 
 	return toProtoReminder(reminder), nil
@@ -101,7 +121,7 @@ func (s *ReminderServer) DeleteReminder(ctx context.Context, req *pb.DeleteRemin
 	if err != nil {
 		return &pb.DeleteReminderResponse{
 			Success: false,
-			Message: "invalid id: " + err.Error(),
+			Message: translate(ctx, "common.invalid_id"),
 		}, nil
 	}
 
@@ -109,13 +129,13 @@ func (s *ReminderServer) DeleteReminder(ctx context.Context, req *pb.DeleteRemin
 	if err != nil {
 		return &pb.DeleteReminderResponse{
 			Success: false,
-			Message: err.Error(),
+			Message: localize(ctx, err),
 		}, nil
 	}
 
 	return &pb.DeleteReminderResponse{
 		Success: true,
-		Message: "Reminder deleted successfully",
+		Message: translate(ctx, "reminder.deleted"),
 	}, nil
 }
 
@@ -129,5 +149,16 @@ func toProtoReminder(r *models.Reminder) *pb.ReminderResponse {
 		IsSent:      r.IsSent,
 		CreatedAt:   r.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:   r.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Priority:    r.Priority,
 	}
 }
+
+// translate renders a catalog message in the caller's language.
+func translate(ctx context.Context, key string, args ...any) string {
+	return i18n.T(i18n.FromContext(ctx), key, args...)
+}
+
+// localize renders err in the caller's language.
+func localize(ctx context.Context, err error) string {
+	return i18n.Message(i18n.FromContext(ctx), err)
+}
@@ -1,59 +0,0 @@
-package kafka
-
-import (
-	"context"
-	"encoding/json"
-	"fmt"
-	"log/slog"
-	"time"
-
-	"github.com/kiribu/jwt-practice/models"
-	"github.com/segmentio/kafka-go"
-)
-
-type Producer struct {
-	writer *kafka.Writer
-}
-
-func NewProducer(brokers []string, topic string) *Producer {
-	writer := &kafka.Writer{
-		Addr:                   kafka.TCP(brokers...),
-		Topic:                  topic,
-		Balancer:               &kafka.LeastBytes{},
-		AllowAutoTopicCreation: true,
-	}
-
-	return &Producer{writer: writer}
-}
-
-func (p *Producer) Close() error {
-	return p.writer.Close()
-}
-
-func (p *Producer) SendNotification(reminder models.Reminder) error {
-	return p.SendEvent(fmt.Sprintf("%d", reminder.UserID), reminder)
-}
-
-func (p *Producer) SendEvent(key string, payload interface{}) error {
-	data, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	msg := kafka.Message{
-		Key:   []byte(key),
-		Value: data,
-		Time:  time.Now(),
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	err = p.writer.WriteMessages(ctx, msg)
-	if err != nil {
-		return fmt.Errorf("failed to write message to kafka: %w", err)
-	}
-
-	slog.Debug("Sent message to Kafka", "topic", p.writer.Topic, "key", key)
-	return nil
-}
@@ -1,12 +1,12 @@
 package service
 
 import (
-	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/kiribu/jwt-practice/internal/reminder/storage"
 	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
 )
 
 type ReminderService struct {
@@ -19,21 +19,26 @@ func NewReminderService(storage storage.ReminderStorage) *ReminderService {
 	}
 }
 
-func (s *ReminderService) Create(userID uuid.UUID, title, description, remindAtStr string) (*models.Reminder, error) {
+func (s *ReminderService) Create(userID uuid.UUID, title, description, priority, remindAtStr string) (*models.Reminder, error) {
 	if title == "" {
-		return nil, errors.New("title is required")
+		return nil, i18n.NewError("reminder.title_required")
+	}
+
+	priority, err := parsePriority(priority)
+	if err != nil {
+		return nil, err
 	}
 
 	remindAt, err := time.Parse(time.RFC3339, remindAtStr)
 	if err != nil {
-		return nil, errors.New("invalid remind_at format, use RFC3339: 2026-01-25T10:00:00+03:00")
+		return nil, i18n.NewError("reminder.invalid_remind_at")
 	}
 
 	if remindAt.Before(time.Now()) {
-		return nil, errors.New("remind_at must be in the future")
+		return nil, i18n.NewError("reminder.remind_at_in_past")
 	}
 
-	reminder, err := s.storage.Create(userID, title, description, remindAt)
+	reminder, err := s.storage.Create(userID, title, description, priority, remindAt)
 	if err != nil {
 		return nil, err
 	}
@@ -45,21 +50,49 @@ func (s *ReminderService) GetByUserID(userID uuid.UUID, status string) ([]models
 	return s.storage.GetByUserID(userID, status)
 }
 
+// maxRange bounds GetInRange, which is meant for digests of a day or a week.
+const maxRange = 31 * 24 * time.Hour
+
+// GetInRange returns the reminders due in [from, to), sent or not.
+func (s *ReminderService) GetInRange(userID uuid.UUID, fromStr, toStr string) ([]models.Reminder, error) {
+	from, err := time.Parse(time.RFC3339, fromStr)
+	if err != nil {
+		return nil, i18n.NewError("reminder.invalid_from")
+	}
+	to, err := time.Parse(time.RFC3339, toStr)
+	if err != nil {
+		return nil, i18n.NewError("reminder.invalid_to")
+	}
+	if !from.Before(to) {
+		return nil, i18n.NewError("reminder.invalid_range")
+	}
+	if to.Sub(from) > maxRange {
+		return nil, i18n.NewError("reminder.range_too_long", int(maxRange/(24*time.Hour)))
+	}
+
+	return s.storage.GetByUserIDInRange(userID, from, to)
+}
+
 func (s *ReminderService) GetByID(userID, id uuid.UUID) (*models.Reminder, error) {
 	return s.storage.GetByID(userID, id)
 }
 
-func (s *ReminderService) Update(userID, id uuid.UUID, title, description, remindAtStr string) (*models.Reminder, error) {
+func (s *ReminderService) Update(userID, id uuid.UUID, title, description, priority, remindAtStr string) (*models.Reminder, error) {
 	if title == "" {
-		return nil, errors.New("title is required")
+		return nil, i18n.NewError("reminder.title_required")
+	}
+
+	priority, err := parsePriority(priority)
+	if err != nil {
+		return nil, err
 	}
 
 	remindAt, err := time.Parse(time.RFC3339, remindAtStr)
 	if err != nil {
-		return nil, errors.New("invalid remind_at format, use RFC3339: 2026-01-25T10:00:00+03:00")
+		return nil, i18n.NewError("reminder.invalid_remind_at")
 	}
 
-	reminder, err := s.storage.Update(userID, id, title, description, remindAt)
+	reminder, err := s.storage.Update(userID, id, title, description, priority, remindAt)
 	if err != nil {
 		return nil, err
 	}
@@ -70,3 +103,14 @@ func (s *ReminderService) Update(userID, id uuid.UUID, title, description, remin
 func (s *ReminderService) Delete(userID, id uuid.UUID) error {
 	return s.storage.Delete(userID, id)
 }
+
+// parsePriority defaults an empty priority to normal.
+func parsePriority(priority string) (string, error) {
+	if priority == "" {
+		return models.PriorityNormal, nil
+	}
+	if !models.ValidPriority(priority) {
+		return "", i18n.NewError("reminder.invalid_priority")
+	}
+	return priority, nil
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// OutboxNotifyChannel is the Postgres channel signalled on every outbox insert.
+const OutboxNotifyChannel = "reminders_outbox"
+
+// OutboxListener holds a dedicated connection that LISTENs for outbox inserts.
+type OutboxListener struct {
+	connString     string
+	reconnectDelay time.Duration
+}
+
+func NewOutboxListener(connString string) *OutboxListener {
+	return &OutboxListener{
+		connString:     connString,
+		reconnectDelay: 5 * time.Second,
+	}
+}
+
+// Listen signals wake on every notification until ctx is done. It also signals
+// after each (re)connect, because inserts made while disconnected were missed.
+func (l *OutboxListener) Listen(ctx context.Context, wake chan<- struct{}) {
+	for {
+		if err := l.listen(ctx, wake); err != nil && ctx.Err() == nil {
+			slog.Error("Outbox listener disconnected", "error", err, "retry_in", l.reconnectDelay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(l.reconnectDelay):
+		}
+	}
+}
+
+func (l *OutboxListener) listen(ctx context.Context, wake chan<- struct{}) error {
+	conn, err := pgx.Connect(ctx, l.connString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{OutboxNotifyChannel}.Sanitize()); err != nil {
+		return err
+	}
+
+	slog.Info("Listening for outbox notifications", "channel", OutboxNotifyChannel)
+	wakeUp(wake)
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		wakeUp(wake)
+	}
+}
+
+func wakeUp(wake chan<- struct{}) {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
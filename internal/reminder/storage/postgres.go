@@ -1,28 +1,38 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"github.com/kiribu/jwt-practice/pkg/i18n"
+)
+
+var (
+	ErrNotFound       = i18n.NewError("reminder.not_found")
+	ErrNotFoundOrSent = i18n.NewError("reminder.not_found_or_sent")
 )
 
 type ReminderStorage interface {
-	Create(userID uuid.UUID, title, description string, remindAt time.Time) (*models.Reminder, error)
+	Create(userID uuid.UUID, title, description, priority string, remindAt time.Time) (*models.Reminder, error)
 	GetByUserID(userID uuid.UUID, status string) ([]models.Reminder, error)
+	GetByUserIDInRange(userID uuid.UUID, from, to time.Time) ([]models.Reminder, error)
 	GetByID(userID, id uuid.UUID) (*models.Reminder, error)
-	Update(userID, id uuid.UUID, title, description string, remindAt time.Time) (*models.Reminder, error)
+	Update(userID, id uuid.UUID, title, description, priority string, remindAt time.Time) (*models.Reminder, error)
 	Delete(userID, id uuid.UUID) error
 	GetPending() ([]models.Reminder, error)
 	MarkAsSent(id uuid.UUID) error
 	// Outbox methods
-	GetPendingOutboxEvents(limit int) ([]OutboxEvent, error)
-	MarkOutboxEventAsSent(id uuid.UUID) error
-	IncrementOutboxRetryCount(id uuid.UUID, errMsg string) error
+	ClaimOutboxEvents(limit int, lease time.Duration) ([]OutboxEvent, error)
+	MarkOutboxEventsAsSent(ids []uuid.UUID) error
+	RecordOutboxError(id uuid.UUID, errMsg string) error
+	MarkOutboxEventFailed(id uuid.UUID, errMsg string) error
 	CreateNotificationEventsAndMarkSent(reminder models.Reminder) error
 }
 
@@ -33,6 +43,7 @@ type OutboxEvent struct {
 	UserID      uuid.UUID       `db:"user_id"`
 	Payload     json.RawMessage `db:"payload"`
 	RetryCount  int             `db:"retry_count"`
+	CreatedAt   time.Time       `db:"created_at"`
 }
 
 type PostgresStorage struct {
@@ -55,10 +66,21 @@ func (s *PostgresStorage) createOutboxEvent(tx *sqlx.Tx, eventType string, userI
 		VALUES ($1, $2, $3, $4, $5)`,
 		outboxID, eventType, aggregateID, userID, payloadJSON,
 	)
+	if err != nil {
+		return err
+	}
+
+	return notifyOutbox(tx)
+}
+
+// notifyOutbox wakes the outbox relay once the transaction commits.
+// Postgres folds repeated notifications of one transaction into one.
+func notifyOutbox(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`SELECT pg_notify($1, '')`, OutboxNotifyChannel)
 	return err
 }
 
-func (s *PostgresStorage) Create(userID uuid.UUID, title, description string, remindAt time.Time) (*models.Reminder, error) {
+func (s *PostgresStorage) Create(userID uuid.UUID, title, description, priority string, remindAt time.Time) (*models.Reminder, error) {
 	tx, err := s.db.Beginx()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -68,10 +90,10 @@ func (s *PostgresStorage) Create(userID uuid.UUID, title, description string, re
 	reminderID := uuid.Must(uuid.NewV7())
 	var reminder models.Reminder
 	err = tx.QueryRowx(`
-		INSERT INTO reminders (id, user_id, title, description, remind_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, user_id, title, description, remind_at, is_sent, created_at, updated_at`,
-		reminderID, userID, title, description, remindAt,
+		INSERT INTO reminders (id, user_id, title, description, remind_at, priority)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, user_id, title, description, remind_at, priority, is_sent, created_at, updated_at`,
+		reminderID, userID, title, description, remindAt, priority,
 	).StructScan(&reminder)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert reminder: %w", err)
@@ -79,11 +101,11 @@ func (s *PostgresStorage) Create(userID uuid.UUID, title, description string, re
 
 	event := models.LifecycleEvent{
 		EventID:    uuid.Must(uuid.NewV7()),
-		EventType:  "created",
+		EventType:  events.TypeReminderCreated,
 		ReminderID: reminder.ID,
 		UserID:     reminder.UserID,
 		Timestamp:  time.Now(),
-		Payload:    reminder,
+		Payload:    &reminder,
 	}
 	if err := s.createOutboxEvent(tx, "created", reminder.UserID, reminder.ID, event); err != nil {
 		return nil, fmt.Errorf("failed to create outbox event: %w", err)
@@ -101,7 +123,7 @@ func (s *PostgresStorage) GetByUserID(userID uuid.UUID, status string) ([]models
 	var query string
 	var args []interface{}
 
-	baseQuery := `SELECT id, user_id, title, description, remind_at, is_sent, created_at, updated_at
+	baseQuery := `SELECT id, user_id, title, description, remind_at, priority, is_sent, created_at, updated_at
 		 FROM reminders WHERE user_id = $1`
 
 	switch status {
@@ -124,21 +146,37 @@ func (s *PostgresStorage) GetByUserID(userID uuid.UUID, status string) ([]models
 	return reminders, nil
 }
 
+func (s *PostgresStorage) GetByUserIDInRange(userID uuid.UUID, from, to time.Time) ([]models.Reminder, error) {
+	reminders := []models.Reminder{}
+	err := s.db.Select(&reminders, `
+		SELECT id, user_id, title, description, remind_at, priority, is_sent, created_at, updated_at
+		FROM reminders
+		WHERE user_id = $1 AND remind_at >= $2 AND remind_at < $3
+		ORDER BY remind_at ASC`,
+		userID, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
 func (s *PostgresStorage) GetByID(userID, id uuid.UUID) (*models.Reminder, error) {
 	var reminder models.Reminder
 	err := s.db.Get(&reminder,
-		`SELECT id, user_id, title, description, remind_at, is_sent, created_at, updated_at
+		`SELECT id, user_id, title, description, remind_at, priority, is_sent, created_at, updated_at
 		 FROM reminders WHERE user_id = $1 AND id = $2`,
 		userID, id,
 	)
 	if err != nil {
-		return nil, errors.New("reminder not found")
+		return nil, ErrNotFound
 	}
 
 	return &reminder, nil
 }
 
-func (s *PostgresStorage) Update(userID, id uuid.UUID, title, description string, remindAt time.Time) (*models.Reminder, error) {
+func (s *PostgresStorage) Update(userID, id uuid.UUID, title, description, priority string, remindAt time.Time) (*models.Reminder, error) {
 	tx, err := s.db.Beginx()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -148,22 +186,22 @@ func (s *PostgresStorage) Update(userID, id uuid.UUID, title, description string
 	var reminder models.Reminder
 	err = tx.QueryRowx(`
 		UPDATE reminders
-		 SET title = $1, description = $2, remind_at = $3, updated_at = NOW()
-		 WHERE user_id = $4 AND id = $5 AND is_sent = FALSE
-		 RETURNING id, user_id, title, description, remind_at, is_sent, created_at, updated_at`,
-		title, description, remindAt, userID, id,
+		 SET title = $1, description = $2, remind_at = $3, priority = $4, updated_at = NOW()
+		 WHERE user_id = $5 AND id = $6 AND is_sent = FALSE
+		 RETURNING id, user_id, title, description, remind_at, priority, is_sent, created_at, updated_at`,
+		title, description, remindAt, priority, userID, id,
 	).StructScan(&reminder)
 	if err != nil {
-		return nil, errors.New("reminder not found or already sent")
+		return nil, ErrNotFoundOrSent
 	}
 
 	event := models.LifecycleEvent{
 		EventID:    uuid.Must(uuid.NewV7()),
-		EventType:  "updated",
+		EventType:  events.TypeReminderUpdated,
 		ReminderID: reminder.ID,
 		UserID:     reminder.UserID,
 		Timestamp:  time.Now(),
-		Payload:    reminder,
+		Payload:    &reminder,
 	}
 	if err := s.createOutboxEvent(tx, "updated", reminder.UserID, reminder.ID, event); err != nil {
 		return nil, fmt.Errorf("failed to create outbox event: %w", err)
@@ -193,12 +231,12 @@ func (s *PostgresStorage) Delete(userID, id uuid.UUID) error {
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return errors.New("reminder not found or already sent")
+		return ErrNotFoundOrSent
 	}
 
 	event := models.LifecycleEvent{
 		EventID:    uuid.Must(uuid.NewV7()),
-		EventType:  "deleted",
+		EventType:  events.TypeReminderDeleted,
 		ReminderID: id,
 		UserID:     userID,
 		Timestamp:  time.Now(),
@@ -218,7 +256,7 @@ func (s *PostgresStorage) Delete(userID, id uuid.UUID) error {
 func (s *PostgresStorage) GetPending() ([]models.Reminder, error) {
 	var reminders []models.Reminder
 	err := s.db.Select(&reminders,
-		`SELECT id, user_id, title, description, remind_at, is_sent, created_at, updated_at
+		`SELECT id, user_id, title, description, remind_at, priority, is_sent, created_at, updated_at
 		 FROM reminders 
 		 WHERE is_sent = FALSE AND remind_at <= NOW()`,
 	)
@@ -236,36 +274,90 @@ func (s *PostgresStorage) MarkAsSent(id uuid.UUID) error {
 	return err
 }
 
-func (s *PostgresStorage) GetPendingOutboxEvents(limit int) ([]OutboxEvent, error) {
+// ClaimOutboxEvents claims pending events for publishing, at most one per
+// aggregate: the oldest one not yet sent. The next event of an aggregate
+// only becomes visible once its predecessor is sent or given up on, so a
+// retried event is never overtaken by its successors.
+//
+// Claimed events are hidden from other relays for lease, which must cover
+// publishing them and marking them sent; if the relay dies meanwhile, they
+// are claimed again once the lease runs out.
+func (s *PostgresStorage) ClaimOutboxEvents(limit int, lease time.Duration) ([]OutboxEvent, error) {
 	var events []OutboxEvent
 	err := s.db.Select(&events, `
-		SELECT id, event_type, aggregate_id, user_id, payload, retry_count
-		FROM reminders_outbox
-		WHERE status = 'PENDING' AND retry_count < 5
-		ORDER BY created_at ASC
-		LIMIT $1
-		FOR UPDATE SKIP LOCKED`,
-		limit,
+		UPDATE reminders_outbox
+		SET locked_until = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT o.id
+			FROM reminders_outbox o
+			WHERE o.status = 'PENDING'
+			  AND (o.locked_until IS NULL OR o.locked_until < NOW())
+			  AND NOT EXISTS (
+				SELECT 1 FROM reminders_outbox b
+				WHERE b.aggregate_id = o.aggregate_id
+				  AND b.status = 'PENDING'
+				  AND (b.created_at, b.id) < (o.created_at, o.id)
+			  )
+			ORDER BY o.created_at ASC, o.id ASC
+			LIMIT $1
+			FOR UPDATE OF o SKIP LOCKED
+		)
+		RETURNING id, event_type, aggregate_id, user_id, payload, retry_count, created_at`,
+		limit, lease.Milliseconds(),
 	)
-	return events, err
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING has no order of its own
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.Before(events[j].CreatedAt)
+		}
+		return bytes.Compare(events[i].ID[:], events[j].ID[:]) < 0
+	})
+	return events, nil
 }
 
-func (s *PostgresStorage) MarkOutboxEventAsSent(id uuid.UUID) error {
+func (s *PostgresStorage) MarkOutboxEventsAsSent(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	idStrings := make([]string, len(ids))
+	for i, id := range ids {
+		idStrings[i] = id.String()
+	}
+
 	_, err := s.db.Exec(`
 		UPDATE reminders_outbox
 		SET status = 'SENT', processed_at = NOW()
+		WHERE id = ANY($1::uuid[])`,
+		idStrings,
+	)
+	return err
+}
+
+// RecordOutboxError notes a failed publish attempt and releases the claim.
+// The event stays pending: broker outages are retried for as long as they
+// last.
+func (s *PostgresStorage) RecordOutboxError(id uuid.UUID, errMsg string) error {
+	_, err := s.db.Exec(`
+		UPDATE reminders_outbox
+		SET retry_count = retry_count + 1, error_message = $2, locked_until = NULL
 		WHERE id = $1`,
-		id,
+		id, errMsg,
 	)
 	return err
 }
 
-func (s *PostgresStorage) IncrementOutboxRetryCount(id uuid.UUID, errMsg string) error {
+// MarkOutboxEventFailed gives up on an event that can never be published,
+// such as one whose payload cannot be decoded. Later events of the same
+// aggregate are released.
+func (s *PostgresStorage) MarkOutboxEventFailed(id uuid.UUID, errMsg string) error {
 	_, err := s.db.Exec(`
 		UPDATE reminders_outbox
-		SET retry_count = retry_count + 1,
-		    error_message = $2,
-		    status = CASE WHEN retry_count + 1 >= 5 THEN 'FAILED' ELSE status END
+		SET status = 'FAILED', processed_at = NOW(), error_message = $2
 		WHERE id = $1`,
 		id, errMsg,
 	)
@@ -299,11 +391,11 @@ func (s *PostgresStorage) CreateNotificationEventsAndMarkSent(reminder models.Re
 	// notification_sent: LifecycleEvent for analytics-service
 	lifecycleEvent := models.LifecycleEvent{
 		EventID:    uuid.Must(uuid.NewV7()),
-		EventType:  "notification_sent",
+		EventType:  events.TypeReminderNotificationSent,
 		ReminderID: reminder.ID,
 		UserID:     reminder.UserID,
 		Timestamp:  time.Now(),
-		Payload:    reminder,
+		Payload:    &reminder,
 	}
 	lifecycleEventJSON, err := json.Marshal(lifecycleEvent)
 	if err != nil {
@@ -320,6 +412,10 @@ func (s *PostgresStorage) CreateNotificationEventsAndMarkSent(reminder models.Re
 		return fmt.Errorf("failed to create notification_sent event: %w", err)
 	}
 
+	if err := notifyOutbox(tx); err != nil {
+		return fmt.Errorf("failed to notify outbox listeners: %w", err)
+	}
+
 	_, err = tx.Exec(`
 		UPDATE reminders 
 		SET is_sent = TRUE, updated_at = NOW() 
@@ -7,31 +7,56 @@ import (
 	"log/slog"
 	"time"
 
-	"github.com/kiribu/jwt-practice/internal/reminder/kafka"
+	"github.com/google/uuid"
 	"github.com/kiribu/jwt-practice/internal/reminder/storage"
 	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+	"github.com/kiribu/jwt-practice/pkg/events/pb"
+	"google.golang.org/protobuf/proto"
 )
 
+// eventSource identifies this service in the envelope of published events.
+const eventSource = "/reminder-service"
+
 type OutboxWorker struct {
-	storage              storage.ReminderStorage
-	lifecycleProducer    *kafka.Producer
-	notificationProducer *kafka.Producer
-	interval             time.Duration
-	batchSize            int
+	storage           storage.ReminderStorage
+	listener          *storage.OutboxListener
+	publisher         broker.Publisher
+	lifecycleTopic    string
+	notificationTopic string
+	contentType       string
+	interval          time.Duration
+	batchSize         int
+	sendTimeout       time.Duration
+	lease             time.Duration // how long claimed events stay hidden from other replicas
+	wake              chan struct{}
 }
 
+// NewOutboxWorker creates a relay that drains the outbox whenever listener
+// reports an insert. interval is only a fallback poll for lost notifications;
+// a nil listener leaves the worker polling at that interval alone.
 func NewOutboxWorker(
 	storage storage.ReminderStorage,
-	lifecycleProducer *kafka.Producer,
-	notificationProducer *kafka.Producer,
+	listener *storage.OutboxListener,
+	publisher broker.Publisher,
+	lifecycleTopic string,
+	notificationTopic string,
+	contentType string,
 	interval time.Duration,
 ) *OutboxWorker {
 	return &OutboxWorker{
-		storage:              storage,
-		lifecycleProducer:    lifecycleProducer,
-		notificationProducer: notificationProducer,
-		interval:             interval,
-		batchSize:            50,
+		storage:           storage,
+		listener:          listener,
+		publisher:         publisher,
+		lifecycleTopic:    lifecycleTopic,
+		notificationTopic: notificationTopic,
+		contentType:       contentType,
+		interval:          interval,
+		batchSize:         50,
+		sendTimeout:       10 * time.Second,
+		lease:             30 * time.Second,
+		wake:              make(chan struct{}, 1),
 	}
 }
 
@@ -39,77 +64,160 @@ func (w *OutboxWorker) Start(ctx context.Context) {
 	ticker := time.NewTicker(w.interval)
 	defer ticker.Stop()
 
-	slog.Info("Outbox Worker started", "interval", w.interval)
+	if w.listener != nil {
+		go w.listener.Listen(ctx, w.wake)
+	}
+
+	slog.Info("Outbox Worker started", "fallback_interval", w.interval)
+
+	w.drain()
 
 	for {
 		select {
 		case <-ctx.Done():
 			slog.Info("Stopping Outbox Worker...")
 			return
+		case <-w.wake:
+			w.drain()
 		case <-ticker.C:
-			w.processOutbox()
+			w.drain()
 		}
 	}
 }
 
-func (w *OutboxWorker) processOutbox() {
-	events, err := w.storage.GetPendingOutboxEvents(w.batchSize)
+// drain keeps relaying batches until the outbox is empty or a batch makes
+// no progress. Each batch holds only the oldest pending event of every
+// aggregate, so an aggregate with several events takes several batches.
+func (w *OutboxWorker) drain() {
+	for w.processOutbox() {
+	}
+}
+
+// processOutbox relays one batch and reports whether it made progress, in
+// which case more events may be waiting behind the ones it handled.
+func (w *OutboxWorker) processOutbox() bool {
+	events, err := w.storage.ClaimOutboxEvents(w.batchSize, w.lease)
 	if err != nil {
 		slog.Error("Error fetching outbox events", "error", err)
-		return
+		return false
 	}
 
-	if len(events) > 0 {
-		slog.Info("Processing outbox events", "count", len(events))
+	if len(events) == 0 {
+		return false
 	}
 
-	for _, event := range events {
-		if err := w.processEvent(event); err != nil {
-			slog.Error("Error processing outbox event", "event_id", event.ID, "error", err)
+	slog.Info("Processing outbox events", "count", len(events))
 
-			if err := w.storage.IncrementOutboxRetryCount(event.ID, err.Error()); err != nil {
-				slog.Error("Failed to update retry count", "event_id", event.ID, "error", err)
+	progress := false
+	batch := make([]storage.OutboxEvent, 0, len(events))
+	msgs := make([]broker.Message, 0, len(events))
+	for _, event := range events {
+		msg, err := w.buildMessage(event)
+		if err != nil {
+			// Encoding fails the same way every time: retrying would hold up
+			// the aggregate's later events forever
+			if w.markFailed(event, err) {
+				progress = true
 			}
 			continue
 		}
 
-		if err := w.storage.MarkOutboxEventAsSent(event.ID); err != nil {
-			slog.Error("Failed to mark event as sent", "event_id", event.ID, "error", err)
+		batch = append(batch, event)
+		msgs = append(msgs, msg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.sendTimeout)
+	defer cancel()
+
+	// Every event in the write belongs to a different aggregate, so a
+	// failure cannot let a later event of the same aggregate through
+	errs := broker.PerMessage(w.publisher.Publish(ctx, msgs...), len(msgs))
+
+	sent := make([]uuid.UUID, 0, len(batch))
+	for i, event := range batch {
+		if errs[i] != nil {
+			w.recordError(event, errs[i])
+			continue
 		}
+		sent = append(sent, event.ID)
+		slog.Debug("Sent outbox event", "type", event.EventType, "event_id", event.ID, "reminder_id", event.AggregateID)
+	}
+
+	if err := w.storage.MarkOutboxEventsAsSent(sent); err != nil {
+		slog.Error("Failed to mark events as sent", "count", len(sent), "error", err)
+		return false
 	}
+
+	return progress || len(sent) > 0
+}
+
+// recordError keeps an event that could not be published for the next run.
+func (w *OutboxWorker) recordError(event storage.OutboxEvent, err error) {
+	slog.Warn("Failed to publish outbox event, will retry", "event_id", event.ID, "attempt", event.RetryCount+1, "error", err)
+
+	if err := w.storage.RecordOutboxError(event.ID, err.Error()); err != nil {
+		slog.Error("Failed to record outbox error", "event_id", event.ID, "error", err)
+	}
+}
+
+// markFailed gives up on an event that can never be published and reports
+// whether that was recorded.
+func (w *OutboxWorker) markFailed(event storage.OutboxEvent, err error) bool {
+	slog.Error("Dropping outbox event that cannot be published", "event_id", event.ID, "type", event.EventType, "reminder_id", event.AggregateID, "error", err)
+
+	if err := w.storage.MarkOutboxEventFailed(event.ID, err.Error()); err != nil {
+		slog.Error("Failed to mark outbox event as failed", "event_id", event.ID, "error", err)
+		return false
+	}
+	return true
 }
 
-func (w *OutboxWorker) processEvent(event storage.OutboxEvent) error {
-	key := fmt.Sprintf("%d", event.UserID)
+// buildMessage wraps the stored payload in an event envelope and routes it to its topic.
+func (w *OutboxWorker) buildMessage(event storage.OutboxEvent) (broker.Message, error) {
+	var (
+		env   events.Envelope
+		data  proto.Message
+		topic string
+	)
 
 	switch event.EventType {
 	case "created", "updated", "deleted", "notification_sent":
 		var lifecycleEvent models.LifecycleEvent
 		if err := json.Unmarshal(event.Payload, &lifecycleEvent); err != nil {
-			return fmt.Errorf("failed to unmarshal lifecycle event: %w", err)
-		}
-
-		if err := w.lifecycleProducer.SendEvent(key, lifecycleEvent); err != nil {
-			return fmt.Errorf("failed to send to lifecycle topic: %w", err)
+			return broker.Message{}, fmt.Errorf("failed to unmarshal lifecycle event: %w", err)
 		}
 
-		slog.Debug("Sent event to reminder_lifecycle", "type", event.EventType, "event_id", event.ID, "reminder_id", event.AggregateID)
+		eventType, _ := events.LifecycleType(event.EventType)
+		// The lifecycle event ID is what consumers deduplicate on, keep it stable across retries
+		env = events.New(lifecycleEvent.EventID.String(), eventType, eventSource, event.AggregateID.String(), lifecycleEvent.Timestamp)
+		data = events.LifecycleToProto(lifecycleEvent)
+		topic = w.lifecycleTopic
 
 	case "notification_trigger":
 		var reminder models.Reminder
 		if err := json.Unmarshal(event.Payload, &reminder); err != nil {
-			return fmt.Errorf("failed to unmarshal reminder: %w", err)
-		}
-
-		if err := w.notificationProducer.SendEvent(key, reminder); err != nil {
-			return fmt.Errorf("failed to send to notifications topic: %w", err)
+			return broker.Message{}, fmt.Errorf("failed to unmarshal reminder: %w", err)
 		}
 
-		slog.Debug("Sent notification_trigger event", "event_id", event.ID, "reminder_id", event.AggregateID)
+		env = events.New(event.ID.String(), events.TypeNotificationRequested, eventSource, event.AggregateID.String(), event.CreatedAt)
+		data = &pb.NotificationRequested{Reminder: events.ReminderToProto(reminder)}
+		topic = w.notificationTopic
 
 	default:
-		return fmt.Errorf("unknown event type: %s", event.EventType)
+		return broker.Message{}, fmt.Errorf("unknown event type: %s", event.EventType)
+	}
+
+	env.ContentType = w.contentType
+	value, headers, err := events.Encode(env, data)
+	if err != nil {
+		return broker.Message{}, fmt.Errorf("failed to encode event: %w", err)
 	}
 
-	return nil
+	return broker.Message{
+		Topic:   topic,
+		Key:     event.UserID.String(),
+		Value:   value,
+		Headers: headers,
+		Time:    time.Now(),
+	}, nil
 }
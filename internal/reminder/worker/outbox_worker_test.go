@@ -0,0 +1,253 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/internal/reminder/storage"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/broker"
+	"github.com/kiribu/jwt-practice/pkg/events"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// fakeOutbox is the outbox part of storage.ReminderStorage kept in memory.
+// roundTrip stands in for the latency of one database query.
+type fakeOutbox struct {
+	storage.ReminderStorage
+
+	mu        sync.Mutex
+	events    []storage.OutboxEvent
+	status    map[uuid.UUID]string
+	roundTrip time.Duration
+}
+
+func newFakeOutbox(roundTrip time.Duration) *fakeOutbox {
+	return &fakeOutbox{status: make(map[uuid.UUID]string), roundTrip: roundTrip}
+}
+
+func (f *fakeOutbox) add(eventType string, userID, aggregateID uuid.UUID, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		panic(err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := uuid.Must(uuid.NewV7())
+	f.events = append(f.events, storage.OutboxEvent{
+		ID:          id,
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		UserID:      userID,
+		Payload:     data,
+		CreatedAt:   time.Now(),
+	})
+	f.status[id] = "PENDING"
+}
+
+func (f *fakeOutbox) addLifecycle(eventType string, reminder models.Reminder) {
+	envType, _ := events.LifecycleType(eventType)
+	f.add(eventType, reminder.UserID, reminder.ID, models.LifecycleEvent{
+		EventID:    uuid.Must(uuid.NewV7()),
+		EventType:  envType,
+		ReminderID: reminder.ID,
+		UserID:     reminder.UserID,
+		Timestamp:  time.Now(),
+		Payload:    &reminder,
+	})
+}
+
+func (f *fakeOutbox) wait() {
+	if f.roundTrip > 0 {
+		time.Sleep(f.roundTrip)
+	}
+}
+
+func (f *fakeOutbox) ClaimOutboxEvents(limit int, lease time.Duration) ([]storage.OutboxEvent, error) {
+	f.wait()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var claimed []storage.OutboxEvent
+	seen := make(map[uuid.UUID]bool)
+	for _, e := range f.events {
+		if len(claimed) == limit {
+			break
+		}
+		if f.status[e.ID] != "PENDING" || seen[e.AggregateID] {
+			continue
+		}
+		seen[e.AggregateID] = true
+		claimed = append(claimed, e)
+	}
+	return claimed, nil
+}
+
+func (f *fakeOutbox) MarkOutboxEventsAsSent(ids []uuid.UUID) error {
+	f.wait()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, id := range ids {
+		f.status[id] = "SENT"
+	}
+	return nil
+}
+
+func (f *fakeOutbox) RecordOutboxError(id uuid.UUID, errMsg string) error {
+	f.wait()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.events {
+		if f.events[i].ID == id {
+			f.events[i].RetryCount++
+		}
+	}
+	return nil
+}
+
+func (f *fakeOutbox) MarkOutboxEventFailed(id uuid.UUID, errMsg string) error {
+	f.wait()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.status[id] = "FAILED"
+	return nil
+}
+
+// flakyPublisher fails the first failures calls, then passes through.
+// latency stands in for the broker acknowledging a write.
+type flakyPublisher struct {
+	broker.Publisher
+	failures int
+	latency  time.Duration
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, msgs ...broker.Message) error {
+	if p.latency > 0 {
+		time.Sleep(p.latency)
+	}
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	return p.Publisher.Publish(ctx, msgs...)
+}
+
+func newTestWorker(store storage.ReminderStorage, publisher broker.Publisher, batchSize int) *OutboxWorker {
+	w := NewOutboxWorker(store, nil, publisher, "lifecycle", "notifications", events.ContentTypeProtobuf, time.Minute)
+	w.batchSize = batchSize
+	return w
+}
+
+func lifecycleTypes(t *testing.T, msgs []broker.Message) []string {
+	t.Helper()
+
+	types := make([]string, len(msgs))
+	for i, msg := range msgs {
+		env, err := events.Parse(msg.Headers)
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		types[i] = env.Type
+	}
+	return types
+}
+
+func TestOutboxKeepsAggregateOrderAcrossFailures(t *testing.T) {
+	store := newFakeOutbox(0)
+	reminder := models.Reminder{ID: uuid.New(), UserID: uuid.New(), Title: "call mom"}
+	store.addLifecycle("created", reminder)
+	store.addLifecycle("updated", reminder)
+	store.addLifecycle("deleted", reminder)
+
+	mem := broker.NewMemory()
+	publisher := &flakyPublisher{Publisher: mem.Publisher(), failures: 1}
+	w := newTestWorker(store, publisher, 50)
+
+	// The first write fails: nothing may reach the topic out of order
+	w.drain()
+	if got := mem.Messages("lifecycle"); len(got) != 0 {
+		t.Fatalf("published %d messages during the outage", len(got))
+	}
+
+	w.drain()
+	got := lifecycleTypes(t, mem.Messages("lifecycle"))
+	want := []string{events.TypeReminderCreated, events.TypeReminderUpdated, events.TypeReminderDeleted}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	if store.events[0].RetryCount != 1 {
+		t.Errorf("retry count = %d, want 1", store.events[0].RetryCount)
+	}
+}
+
+func TestOutboxUndecodableEventDoesNotBlockAggregate(t *testing.T) {
+	store := newFakeOutbox(0)
+	reminder := models.Reminder{ID: uuid.New(), UserID: uuid.New(), Title: "water plants"}
+	store.addLifecycle("created", reminder)
+	store.add("unknown", reminder.UserID, reminder.ID, map[string]string{})
+	store.addLifecycle("updated", reminder)
+
+	mem := broker.NewMemory()
+	newTestWorker(store, mem.Publisher(), 50).drain()
+
+	got := lifecycleTypes(t, mem.Messages("lifecycle"))
+	want := []string{events.TypeReminderCreated, events.TypeReminderUpdated}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	if status := store.status[store.events[1].ID]; status != "FAILED" {
+		t.Errorf("undecodable event is %s, want FAILED", status)
+	}
+}
+
+// benchmarkOutbox relays events of distinct reminders with a simulated
+// database and broker round trip, batchSize events per write.
+func benchmarkOutbox(b *testing.B, batchSize int) {
+	const (
+		eventCount = 200
+		roundTrip  = 200 * time.Microsecond
+		ackLatency = 500 * time.Microsecond
+	)
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		store := newFakeOutbox(roundTrip)
+		for range eventCount {
+			store.addLifecycle("created", models.Reminder{ID: uuid.New(), UserID: uuid.New(), Title: "benchmark"})
+		}
+		mem := broker.NewMemory()
+		w := newTestWorker(store, &flakyPublisher{Publisher: mem.Publisher(), latency: ackLatency}, batchSize)
+		b.StartTimer()
+
+		w.drain()
+
+		b.StopTimer()
+		if n := len(mem.Messages("lifecycle")); n != eventCount {
+			b.Fatalf("published %d events, want %d", n, eventCount)
+		}
+		b.StartTimer()
+	}
+	b.ReportMetric(float64(eventCount*b.N)/b.Elapsed().Seconds(), "events/s")
+}
+
+func BenchmarkOutboxPerEvent(b *testing.B) { benchmarkOutbox(b, 1) }
+
+func BenchmarkOutboxBatch(b *testing.B) { benchmarkOutbox(b, 50) }
@@ -0,0 +1,3 @@
+-- Index for per-aggregate ordering checks in the outbox relay
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate_unsent ON reminders_outbox(aggregate_id, created_at, id)
+WHERE status <> 'SENT';
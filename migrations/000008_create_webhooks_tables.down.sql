@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS notification.webhook_deliveries;
+DROP TABLE IF EXISTS notification.webhooks;
+DROP SCHEMA IF EXISTS notification;
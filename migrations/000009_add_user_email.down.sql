@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_email_verifications_user_id;
+DROP TABLE IF EXISTS email_verifications;
+DROP INDEX IF EXISTS idx_users_email;
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified;
+ALTER TABLE users DROP COLUMN IF EXISTS email;
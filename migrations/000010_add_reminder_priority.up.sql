@@ -0,0 +1 @@
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS priority VARCHAR(10) NOT NULL DEFAULT 'normal';
@@ -0,0 +1,10 @@
+-- A missing row means the service defaults apply
+CREATE TABLE IF NOT EXISTS notification.preferences (
+    user_id UUID PRIMARY KEY,
+    enabled_channels JSONB NOT NULL DEFAULT '[]',
+    priority_channels JSONB NOT NULL DEFAULT '{}',
+    locale VARCHAR(10) NOT NULL DEFAULT 'en',
+    digest_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
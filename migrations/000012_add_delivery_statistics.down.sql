@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS analytics.delivered_reminders;
+
+ALTER TABLE analytics.user_statistics
+    DROP COLUMN IF EXISTS max_delivery_latency_ms,
+    DROP COLUMN IF EXISTS avg_delivery_latency_ms,
+    DROP COLUMN IF EXISTS total_delivery_latency_ms,
+    DROP COLUMN IF EXISTS total_notifications_failed,
+    DROP COLUMN IF EXISTS total_notifications_delivered;
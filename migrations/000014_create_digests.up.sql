@@ -0,0 +1,8 @@
+-- One row per digest sent; period is the date it was due in the user's timezone
+CREATE TABLE IF NOT EXISTS notification.digests (
+    user_id UUID NOT NULL,
+    kind VARCHAR(10) NOT NULL,
+    period DATE NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, kind, period)
+);
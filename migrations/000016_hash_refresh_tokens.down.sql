@@ -0,0 +1,8 @@
+-- Digests cannot be turned back into tokens
+DELETE FROM refresh_tokens;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_expires_at;
+ALTER TABLE refresh_tokens ALTER COLUMN token_hash TYPE VARCHAR(512);
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_token ON refresh_tokens(token);
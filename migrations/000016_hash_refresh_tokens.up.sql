@@ -0,0 +1,10 @@
+-- Refresh tokens are stored as SHA-256 digests from now on. Existing rows hold
+-- plaintext tokens that can no longer be looked up, so they are dropped and
+-- every user has to log in again
+DELETE FROM refresh_tokens;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_token;
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+ALTER TABLE refresh_tokens ALTER COLUMN token_hash TYPE VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
@@ -0,0 +1,25 @@
+-- A session is one login on one device. Its id is the family id of the
+-- refresh tokens rotated from that login, and access tokens carry it in the
+-- sid claim
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+
+-- Families issued before sessions existed become sessions of unknown devices
+INSERT INTO sessions (id, user_id, created_at, last_used_at)
+SELECT DISTINCT ON (family_id) family_id, user_id, created_at, created_at
+FROM refresh_tokens
+ORDER BY family_id, created_at
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT fk_refresh_tokens_session
+    FOREIGN KEY (family_id) REFERENCES sessions(id) ON DELETE CASCADE;
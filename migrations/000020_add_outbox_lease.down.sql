@@ -0,0 +1 @@
+ALTER TABLE reminders_outbox DROP COLUMN IF EXISTS locked_until;
@@ -0,0 +1,3 @@
+-- A relay claims outbox rows until locked_until, so that replicas never
+-- publish the same event at once
+ALTER TABLE reminders_outbox ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate_unsent ON reminders_outbox(aggregate_id, created_at, id)
+WHERE status <> 'SENT';
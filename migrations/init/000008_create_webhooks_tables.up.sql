@@ -0,0 +1,31 @@
+CREATE SCHEMA IF NOT EXISTS notification;
+
+CREATE TABLE IF NOT EXISTS notification.webhooks (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    disabled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON notification.webhooks(user_id);
+
+CREATE TABLE IF NOT EXISTS notification.webhook_deliveries (
+    id UUID PRIMARY KEY,
+    delivery_id UUID NOT NULL,
+    webhook_id UUID NOT NULL REFERENCES notification.webhooks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    attempt INT NOT NULL,
+    success BOOLEAN NOT NULL,
+    response_status INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON notification.webhook_deliveries(webhook_id, created_at DESC);
@@ -0,0 +1,12 @@
+ALTER TABLE analytics.user_statistics
+    ADD COLUMN IF NOT EXISTS total_notifications_delivered INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS total_notifications_failed INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS total_delivery_latency_ms BIGINT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS avg_delivery_latency_ms BIGINT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS max_delivery_latency_ms BIGINT DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS analytics.delivered_reminders (
+    reminder_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    first_delivered_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS notification.inbox (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    reminder_id UUID NOT NULL,
+    occurrence TIMESTAMPTZ NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    priority VARCHAR(10) NOT NULL DEFAULT 'normal',
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, reminder_id, occurrence)
+);
+
+CREATE INDEX IF NOT EXISTS idx_inbox_user_id ON notification.inbox(user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_inbox_unread ON notification.inbox(user_id) WHERE read_at IS NULL;
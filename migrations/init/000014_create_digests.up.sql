@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS notification.digests (
+    user_id UUID NOT NULL,
+    kind VARCHAR(10) NOT NULL,
+    period DATE NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, kind, period)
+);
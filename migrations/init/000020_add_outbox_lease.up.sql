@@ -0,0 +1 @@
+ALTER TABLE reminders_outbox ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
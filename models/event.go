@@ -7,10 +7,22 @@ import (
 )
 
 type LifecycleEvent struct {
-	EventID    uuid.UUID   `json:"event_id"`   // Unique ID for idempotency
-	EventType  string      `json:"event_type"` // "created", "updated", "deleted", "notification_sent"
-	ReminderID uuid.UUID   `json:"reminder_id"`
-	UserID     uuid.UUID   `json:"user_id"`
-	Timestamp  time.Time   `json:"timestamp"`
-	Payload    interface{} `json:"payload,omitempty"` // Reminder snapshot or nil
+	EventID    uuid.UUID `json:"event_id"`   // Unique ID for idempotency
+	EventType  string    `json:"event_type"` // events.TypeReminder* envelope type
+	ReminderID uuid.UUID `json:"reminder_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Payload    *Reminder `json:"payload,omitempty"` // Reminder snapshot, nil for deleted
+	// Delivery is set for notification_delivered and notification_failed only
+	Delivery *DeliveryReceipt `json:"delivery,omitempty"`
+}
+
+// DeliveryReceipt is the outcome of sending a reminder over one channel.
+type DeliveryReceipt struct {
+	NotificationID string `json:"notification_id"` // trigger event the delivery belongs to
+	Channel        string `json:"channel"`
+	Attempt        int    `json:"attempt"`
+	LatencyMs      int64  `json:"latency_ms"`  // from the reminder's due time to the end of the send
+	DurationMs     int64  `json:"duration_ms"` // time spent in the channel
+	Error          string `json:"error,omitempty"`
 }
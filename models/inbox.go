@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InboxItem is a delivered reminder kept in the user's in-app inbox.
+type InboxItem struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	UserID     uuid.UUID  `db:"user_id" json:"user_id"`
+	ReminderID uuid.UUID  `db:"reminder_id" json:"reminder_id"`
+	Occurrence time.Time  `db:"occurrence" json:"occurrence"` // remind_at of the delivered occurrence
+	Title      string     `db:"title" json:"title"`
+	Body       string     `db:"body" json:"body"`
+	Priority   string     `db:"priority" json:"priority"`
+	ReadAt     *time.Time `db:"read_at" json:"read_at,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationPreferences is how a user wants to be notified.
+type NotificationPreferences struct {
+	UserID          uuid.UUID   `db:"user_id" json:"user_id"`
+	EnabledChannels ChannelList `db:"enabled_channels" json:"enabled_channels"`
+	// PriorityChannels maps a reminder priority to the one channel used for it;
+	// priorities without an entry go to all enabled channels
+	PriorityChannels PriorityChannels `db:"priority_channels" json:"priority_channels"`
+	Locale           string           `db:"locale" json:"locale"`
+	DigestEnabled    bool             `db:"digest_enabled" json:"digest_enabled"`
+	UpdatedAt        time.Time        `db:"updated_at" json:"updated_at"`
+}
+
+// ChannelList is a list of channel names stored as a JSONB array.
+type ChannelList []string
+
+func (l ChannelList) Value() (driver.Value, error) {
+	if l == nil {
+		l = ChannelList{}
+	}
+	return json.Marshal([]string(l))
+}
+
+func (l *ChannelList) Scan(src any) error {
+	return scanJSON(src, l)
+}
+
+// Contains reports whether the list has the channel name.
+func (l ChannelList) Contains(name string) bool {
+	for _, n := range l {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PriorityChannels maps reminder priorities to channel names, stored as a JSONB object.
+type PriorityChannels map[string]string
+
+func (m PriorityChannels) Value() (driver.Value, error) {
+	if m == nil {
+		m = PriorityChannels{}
+	}
+	return json.Marshal(map[string]string(m))
+}
+
+func (m *PriorityChannels) Scan(src any) error {
+	return scanJSON(src, m)
+}
+
+func scanJSON(src any, dst any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, dst)
+	}
+}
@@ -12,7 +12,20 @@ type Reminder struct {
 	Title       string    `db:"title" json:"title"`
 	Description string    `db:"description" json:"description"`
 	RemindAt    time.Time `db:"remind_at" json:"remind_at"`
+	Priority    string    `db:"priority" json:"priority"`
 	IsSent      bool      `db:"is_sent" json:"is_sent"`
 	CreatedAt   time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
 }
+
+// Reminder priorities. Users pick a default notification channel per priority.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// ValidPriority reports whether p is one of the known priorities.
+func ValidPriority(p string) bool {
+	return p == PriorityLow || p == PriorityNormal || p == PriorityHigh
+}
@@ -13,6 +13,11 @@ type UserStatistics struct {
 	TotalRemindersDeleted   int64      `db:"total_reminders_deleted" json:"total_reminders_deleted"`
 	ActiveReminders         int64      `db:"active_reminders" json:"active_reminders"`
 	CompletionRate          float64    `db:"completion_rate" json:"completion_rate"`
+	NotificationsDelivered  int64      `db:"total_notifications_delivered" json:"total_notifications_delivered"`
+	NotificationsFailed     int64      `db:"total_notifications_failed" json:"total_notifications_failed"`
+	TotalDeliveryLatencyMs  int64      `db:"total_delivery_latency_ms" json:"-"`
+	AvgDeliveryLatencyMs    int64      `db:"avg_delivery_latency_ms" json:"avg_delivery_latency_ms"`
+	MaxDeliveryLatencyMs    int64      `db:"max_delivery_latency_ms" json:"max_delivery_latency_ms"`
 	FirstReminderAt         *time.Time `db:"first_reminder_at" json:"first_reminder_at"`
 	LastActivityAt          *time.Time `db:"last_activity_at" json:"last_activity_at"`
 	CreatedAt               time.Time  `db:"created_at" json:"created_at"`
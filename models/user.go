@@ -7,18 +7,51 @@ import (
 )
 
 type User struct {
-	ID           uuid.UUID `db:"id" json:"id"`
-	Username     string    `db:"username" json:"username"`
-	PasswordHash string    `db:"password_hash" json:"-"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	ID            uuid.UUID `db:"id" json:"id"`
+	Username      string    `db:"username" json:"username"`
+	PasswordHash  string    `db:"password_hash" json:"-"`
+	Email         string    `db:"email" json:"email"`
+	EmailVerified bool      `db:"email_verified" json:"email_verified"`
+	Timezone      string    `db:"timezone" json:"timezone"` // IANA name, e.g. "Europe/Moscow"
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
 }
 
+// RefreshToken is one link of a rotation chain. Every token exchanged since
+// a login shares the login's FamilyID; RotatedAt is set once a token has
+// been exchanged for the next one.
 type RefreshToken struct {
-	ID        uuid.UUID `db:"id" json:"id"`
-	Token     string    `db:"token" json:"token"`
-	UserID    uuid.UUID `db:"user_id" json:"user_id"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	ID        uuid.UUID  `db:"id" json:"id"`
+	FamilyID  uuid.UUID  `db:"family_id" json:"family_id"`
+	TokenHash string     `db:"token_hash" json:"-"`
+	UserID    uuid.UUID  `db:"user_id" json:"user_id"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	RotatedAt *time.Time `db:"rotated_at" json:"rotated_at,omitempty"`
+	RevokedAt *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+}
+
+// Session is one login on one device. Its ID is the FamilyID of the refresh
+// tokens rotated from that login; LastUsedAt, UserAgent and IP are updated
+// on every refresh.
+type Session struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	UserID     uuid.UUID  `db:"user_id" json:"user_id"`
+	UserAgent  string     `db:"user_agent" json:"user_agent"`
+	IP         string     `db:"ip" json:"ip"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	LastUsedAt time.Time  `db:"last_used_at" json:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+}
+
+// TOTP is the secret shared with a user's authenticator app. Two-factor
+// authentication is on once ConfirmedAt is set; LastUsedStep is the time
+// step of the last accepted code.
+type TOTP struct {
+	UserID       uuid.UUID  `db:"user_id" json:"user_id"`
+	Secret       string     `db:"secret" json:"-"`
+	LastUsedStep int64      `db:"last_used_step" json:"-"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	ConfirmedAt  *time.Time `db:"confirmed_at" json:"confirmed_at,omitempty"`
 }
 
 type Credentials struct {
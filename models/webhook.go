@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Webhook struct {
+	ID                  uuid.UUID  `db:"id" json:"id"`
+	UserID              uuid.UUID  `db:"user_id" json:"user_id"`
+	URL                 string     `db:"url" json:"url"`
+	Secret              string     `db:"secret" json:"-"`
+	Enabled             bool       `db:"enabled" json:"enabled"`
+	ConsecutiveFailures int        `db:"consecutive_failures" json:"consecutive_failures"`
+	DisabledAt          *time.Time `db:"disabled_at" json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// WebhookDelivery is one attempt to POST an event to a webhook.
+type WebhookDelivery struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	DeliveryID     uuid.UUID `db:"delivery_id" json:"delivery_id"` // same for all attempts of one event
+	WebhookID      uuid.UUID `db:"webhook_id" json:"webhook_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+	EventType      string    `db:"event_type" json:"event_type"`
+	Attempt        int       `db:"attempt" json:"attempt"`
+	Success        bool      `db:"success" json:"success"`
+	ResponseStatus int       `db:"response_status" json:"response_status"`
+	Error          string    `db:"error" json:"error,omitempty"`
+	DurationMs     int64     `db:"duration_ms" json:"duration_ms"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
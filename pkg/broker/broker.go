@@ -0,0 +1,111 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message is a record exchanged through a broker.
+type Message struct {
+	Topic   string
+	Key     string
+	Value   []byte
+	Headers map[string]string
+	Time    time.Time
+
+	// Filled in by subscribers; they identify the record on Commit.
+	Partition int
+	Offset    int64
+	ID        string
+}
+
+// Publisher writes messages to topics.
+type Publisher interface {
+	// Publish writes all msgs in one request. When only some of them fail,
+	// the error is a PublishErrors aligned with msgs.
+	Publish(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// Subscriber reads one topic as a member of a consumer group.
+type Subscriber interface {
+	// Fetch blocks until a message is available or ctx is done.
+	Fetch(ctx context.Context) (Message, error)
+	// Commit acknowledges fetched messages so the group does not see them again.
+	Commit(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// PublishErrors holds the per-message outcome of a partially failed Publish.
+type PublishErrors []error
+
+func (e PublishErrors) Error() string {
+	failed := 0
+	for _, err := range e {
+		if err != nil {
+			failed++
+		}
+	}
+	return fmt.Sprintf("failed to publish %d of %d messages", failed, len(e))
+}
+
+// PerMessage spreads the error of a Publish call over its n messages.
+func PerMessage(err error, n int) []error {
+	errs := make([]error, n)
+	if err == nil {
+		return errs
+	}
+
+	var publishErrs PublishErrors
+	if errors.As(err, &publishErrs) && len(publishErrs) == n {
+		copy(errs, publishErrs)
+		return errs
+	}
+
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+const (
+	DriverKafka  = "kafka"
+	DriverRedis  = "redis"
+	DriverMemory = "memory"
+)
+
+type Config struct {
+	Driver        string
+	KafkaBrokers  []string
+	RedisAddr     string
+	RedisPassword string
+}
+
+func NewPublisher(cfg Config) (Publisher, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case DriverKafka, "":
+		return NewKafkaPublisher(cfg.KafkaBrokers), nil
+	case DriverRedis:
+		return NewRedisPublisher(cfg.RedisAddr, cfg.RedisPassword)
+	case DriverMemory:
+		return defaultMemory.Publisher(), nil
+	default:
+		return nil, fmt.Errorf("unknown broker driver: %s", cfg.Driver)
+	}
+}
+
+func NewSubscriber(cfg Config, topic, group string) (Subscriber, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case DriverKafka, "":
+		return NewKafkaSubscriber(cfg.KafkaBrokers, topic, group), nil
+	case DriverRedis:
+		return NewRedisSubscriber(cfg.RedisAddr, cfg.RedisPassword, topic, group)
+	case DriverMemory:
+		return defaultMemory.Subscriber(topic, group), nil
+	default:
+		return nil, fmt.Errorf("unknown broker driver: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,120 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(brokers []string) *KafkaPublisher {
+	writer := &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Balancer:               &kafka.Hash{},
+		AllowAutoTopicCreation: true,
+		// Callers hand over whole batches, so there is nothing to wait for.
+		BatchTimeout: 10 * time.Millisecond,
+	}
+
+	return &KafkaPublisher{writer: writer}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, msgs ...Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	kafkaMsgs := make([]kafka.Message, len(msgs))
+	for i, m := range msgs {
+		kafkaMsgs[i] = kafka.Message{
+			Topic:   m.Topic,
+			Key:     []byte(m.Key),
+			Value:   m.Value,
+			Headers: toKafkaHeaders(m.Headers),
+			Time:    m.Time,
+		}
+	}
+
+	err := p.writer.WriteMessages(ctx, kafkaMsgs...)
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		return PublishErrors(writeErrs)
+	}
+	return err
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
+
+type KafkaSubscriber struct {
+	reader *kafka.Reader
+}
+
+func NewKafkaSubscriber(brokers []string, topic, group string) *KafkaSubscriber {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       topic,
+		GroupID:     group,
+		MinBytes:    10e3, // 10KB
+		MaxBytes:    10e6, // 10MB
+		StartOffset: kafka.FirstOffset,
+	})
+
+	return &KafkaSubscriber{reader: reader}
+}
+
+func (s *KafkaSubscriber) Fetch(ctx context.Context) (Message, error) {
+	m, err := s.reader.FetchMessage(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+
+	headers := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+
+	return Message{
+		Topic:     m.Topic,
+		Key:       string(m.Key),
+		Value:     m.Value,
+		Headers:   headers,
+		Time:      m.Time,
+		Partition: m.Partition,
+		Offset:    m.Offset,
+	}, nil
+}
+
+func (s *KafkaSubscriber) Commit(ctx context.Context, msgs ...Message) error {
+	kafkaMsgs := make([]kafka.Message, len(msgs))
+	for i, m := range msgs {
+		kafkaMsgs[i] = kafka.Message{
+			Topic:     m.Topic,
+			Partition: m.Partition,
+			Offset:    m.Offset,
+		}
+	}
+	return s.reader.CommitMessages(ctx, kafkaMsgs...)
+}
+
+func (s *KafkaSubscriber) Close() error {
+	return s.reader.Close()
+}
+
+func toKafkaHeaders(headers map[string]string) []kafka.Header {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	out := make([]kafka.Header, 0, len(headers))
+	for k, v := range headers {
+		out = append(out, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return out
+}
@@ -0,0 +1,170 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var defaultMemory = NewMemory()
+
+// Memory is an in-process broker for tests and single-process setups.
+// Every topic is one ordered log; each consumer group keeps its own cursor,
+// and a group that is subscribed again resumes after its last commit.
+type Memory struct {
+	mu     sync.Mutex
+	topics map[string]*memoryTopic
+}
+
+type memoryTopic struct {
+	log     []Message
+	groups  map[string]*memoryGroup
+	changed chan struct{}
+}
+
+type memoryGroup struct {
+	next      int64
+	committed int64
+	members   int
+}
+
+func NewMemory() *Memory {
+	return &Memory{topics: make(map[string]*memoryTopic)}
+}
+
+func (m *Memory) topic(name string) *memoryTopic {
+	t, ok := m.topics[name]
+	if !ok {
+		t = &memoryTopic{
+			groups:  make(map[string]*memoryGroup),
+			changed: make(chan struct{}),
+		}
+		m.topics[name] = t
+	}
+	return t
+}
+
+// Messages returns a copy of everything published to topic so far.
+func (m *Memory) Messages(topic string) []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.topic(topic).log...)
+}
+
+func (m *Memory) Publisher() Publisher {
+	return &memoryPublisher{memory: m}
+}
+
+func (m *Memory) Subscriber(topic, group string) Subscriber {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.topic(topic)
+	g, ok := t.groups[group]
+	if !ok {
+		g = &memoryGroup{}
+		t.groups[group] = g
+	}
+	if g.members == 0 {
+		g.next = g.committed
+	}
+	g.members++
+
+	return &memorySubscriber{memory: m, topic: topic, group: g}
+}
+
+type memoryPublisher struct {
+	memory *Memory
+}
+
+func (p *memoryPublisher) Publish(ctx context.Context, msgs ...Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m := p.memory
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, msg := range msgs {
+		if msg.Topic == "" {
+			return errors.New("message without topic")
+		}
+		if msg.Time.IsZero() {
+			msg.Time = time.Now()
+		}
+
+		t := m.topic(msg.Topic)
+		msg.Offset = int64(len(t.log))
+		t.log = append(t.log, msg)
+
+		close(t.changed)
+		t.changed = make(chan struct{})
+	}
+	return nil
+}
+
+func (p *memoryPublisher) Close() error {
+	return nil
+}
+
+type memorySubscriber struct {
+	memory *Memory
+	topic  string
+	group  *memoryGroup
+	closed bool
+}
+
+func (s *memorySubscriber) Fetch(ctx context.Context) (Message, error) {
+	m := s.memory
+	for {
+		m.mu.Lock()
+		if s.closed {
+			m.mu.Unlock()
+			return Message{}, errors.New("subscriber is closed")
+		}
+
+		t := m.topic(s.topic)
+		if s.group.next < int64(len(t.log)) {
+			msg := t.log[s.group.next]
+			s.group.next++
+			m.mu.Unlock()
+			return msg, nil
+		}
+		changed := t.changed
+		m.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (s *memorySubscriber) Commit(ctx context.Context, msgs ...Message) error {
+	m := s.memory
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, msg := range msgs {
+		if msg.Offset+1 > s.group.committed {
+			s.group.committed = msg.Offset + 1
+		}
+	}
+	return nil
+}
+
+func (s *memorySubscriber) Close() error {
+	m := s.memory
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		s.group.members--
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func publish(t *testing.T, p Publisher, topic string, keys ...string) {
+	t.Helper()
+
+	msgs := make([]Message, len(keys))
+	for i, key := range keys {
+		msgs[i] = Message{Topic: topic, Key: key, Value: []byte(key)}
+	}
+	if err := p.Publish(context.Background(), msgs...); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+}
+
+func fetch(t *testing.T, s Subscriber) Message {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	msg, err := s.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	return msg
+}
+
+func TestMemoryGroupsHaveOwnCursors(t *testing.T) {
+	m := NewMemory()
+	publish(t, m.Publisher(), "events", "a", "b")
+
+	first := m.Subscriber("events", "first")
+	second := m.Subscriber("events", "second")
+
+	for _, want := range []string{"a", "b"} {
+		if got := fetch(t, first).Key; got != want {
+			t.Fatalf("first group got %q, want %q", got, want)
+		}
+	}
+	if got := fetch(t, second).Key; got != "a" {
+		t.Fatalf("second group got %q, want %q", got, "a")
+	}
+}
+
+func TestMemoryResumesAfterLastCommit(t *testing.T) {
+	m := NewMemory()
+	publish(t, m.Publisher(), "events", "a", "b", "c")
+
+	s := m.Subscriber("events", "group")
+	a := fetch(t, s)
+	fetch(t, s)
+	if err := s.Commit(context.Background(), a); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	s.Close()
+
+	// b was fetched but never committed, so the group sees it again
+	s = m.Subscriber("events", "group")
+	defer s.Close()
+	for _, want := range []string{"b", "c"} {
+		if got := fetch(t, s).Key; got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestMemoryFetchWaitsForPublish(t *testing.T) {
+	m := NewMemory()
+	s := m.Subscriber("events", "group")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		publish(t, m.Publisher(), "events", "late")
+	}()
+	if got := fetch(t, s).Key; got != "late" {
+		t.Fatalf("got %q, want %q", got, "late")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Fetch(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("fetch on an empty topic returned %v, want deadline exceeded", err)
+	}
+}
+
+func TestMemoryPublishAssignsOffsets(t *testing.T) {
+	m := NewMemory()
+	publish(t, m.Publisher(), "events", "a", "b")
+
+	for i, msg := range m.Messages("events") {
+		if msg.Offset != int64(i) {
+			t.Errorf("message %d has offset %d", i, msg.Offset)
+		}
+		if msg.Time.IsZero() {
+			t.Errorf("message %d has no time", i)
+		}
+	}
+
+	if err := m.Publisher().Publish(context.Background(), Message{Key: "x"}); err == nil {
+		t.Fatal("publishing without a topic succeeded")
+	}
+}
+
+func TestPerMessage(t *testing.T) {
+	errA := errors.New("a failed")
+	errAll := errors.New("broker down")
+
+	tests := []struct {
+		name string
+		err  error
+		want []error
+	}{
+		{"success", nil, []error{nil, nil, nil}},
+		{"whole call failed", errAll, []error{errAll, errAll, errAll}},
+		{"partial failure", PublishErrors{errA, nil, nil}, []error{errA, nil, nil}},
+		{"misaligned partial failure", PublishErrors{errA}, []error{PublishErrors{errA}, PublishErrors{errA}, PublishErrors{errA}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PerMessage(tt.err, 3)
+			if len(got) != 3 {
+				t.Fatalf("got %d errors, want 3", len(got))
+			}
+			for i := range got {
+				if (got[i] == nil) != (tt.want[i] == nil) || (got[i] != nil && got[i].Error() != tt.want[i].Error()) {
+					t.Errorf("message %d: got %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPublishErrorsCountsFailures(t *testing.T) {
+	err := PublishErrors{nil, errors.New("x"), errors.New("y")}
+	if got, want := err.Error(), "failed to publish 2 of 3 messages"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,208 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	redisclient "github.com/kiribu/jwt-practice/pkg/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis Streams backend: a topic is a stream, a group is a consumer group.
+const (
+	redisFieldKey     = "key"
+	redisFieldValue   = "value"
+	redisFieldHeaders = "headers"
+	redisFieldTime    = "time"
+)
+
+type RedisPublisher struct {
+	client *redis.Client
+}
+
+func NewRedisPublisher(addr, password string) (*RedisPublisher, error) {
+	client, err := redisclient.NewRedisClient(addr, password)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisPublisher{client: client}, nil
+}
+
+func (p *RedisPublisher) Publish(ctx context.Context, msgs ...Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	pipe := p.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(msgs))
+	for i, m := range msgs {
+		headers, err := json.Marshal(m.Headers)
+		if err != nil {
+			return fmt.Errorf("failed to marshal headers: %w", err)
+		}
+		ts := m.Time
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+
+		cmds[i] = pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: m.Topic,
+			Values: map[string]interface{}{
+				redisFieldKey:     m.Key,
+				redisFieldValue:   m.Value,
+				redisFieldHeaders: headers,
+				redisFieldTime:    ts.UnixMilli(),
+			},
+		})
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		errs := make(PublishErrors, len(cmds))
+		failed := 0
+		for i, cmd := range cmds {
+			if errs[i] = cmd.Err(); errs[i] != nil {
+				failed++
+			}
+		}
+		if failed == len(cmds) {
+			return err
+		}
+		return errs
+	}
+	return nil
+}
+
+func (p *RedisPublisher) Close() error {
+	return p.client.Close()
+}
+
+type RedisSubscriber struct {
+	client   *redis.Client
+	stream   string
+	group    string
+	consumer string
+	// Entries delivered to this consumer before a restart but never acked
+	// are read again, starting after pendingCursor, before new ones (">").
+	readPending   bool
+	pendingCursor string
+	buffered      []Message
+}
+
+func NewRedisSubscriber(addr, password, topic, group string) (*RedisSubscriber, error) {
+	client, err := redisclient.NewRedisClient(addr, password)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = client.XGroupCreateMkStream(ctx, topic, group, "0").Err()
+	if err != nil && !isBusyGroup(err) {
+		client.Close()
+		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	return &RedisSubscriber{
+		client:        client,
+		stream:        topic,
+		group:         group,
+		consumer:      hostname + "-" + strconv.Itoa(os.Getpid()),
+		readPending:   true,
+		pendingCursor: "0",
+	}, nil
+}
+
+func (s *RedisSubscriber) Fetch(ctx context.Context) (Message, error) {
+	for len(s.buffered) == 0 {
+		if err := ctx.Err(); err != nil {
+			return Message{}, err
+		}
+
+		id := ">"
+		if s.readPending {
+			id = s.pendingCursor
+		}
+
+		streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.group,
+			Consumer: s.consumer,
+			Streams:  []string{s.stream, id},
+			Count:    100,
+			Block:    5 * time.Second,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return Message{}, err
+		}
+
+		for _, stream := range streams {
+			for _, entry := range stream.Messages {
+				s.buffered = append(s.buffered, s.toMessage(entry))
+			}
+		}
+		if s.readPending {
+			if len(s.buffered) == 0 {
+				s.readPending = false
+			} else {
+				s.pendingCursor = s.buffered[len(s.buffered)-1].ID
+			}
+		}
+	}
+
+	msg := s.buffered[0]
+	s.buffered = s.buffered[1:]
+	return msg, nil
+}
+
+func (s *RedisSubscriber) toMessage(entry redis.XMessage) Message {
+	msg := Message{
+		Topic: s.stream,
+		ID:    entry.ID,
+	}
+
+	if v, ok := entry.Values[redisFieldKey].(string); ok {
+		msg.Key = v
+	}
+	if v, ok := entry.Values[redisFieldValue].(string); ok {
+		msg.Value = []byte(v)
+	}
+	if v, ok := entry.Values[redisFieldHeaders].(string); ok {
+		_ = json.Unmarshal([]byte(v), &msg.Headers)
+	}
+	if v, ok := entry.Values[redisFieldTime].(string); ok {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			msg.Time = time.UnixMilli(ms)
+		}
+	}
+	return msg
+}
+
+func (s *RedisSubscriber) Commit(ctx context.Context, msgs ...Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+	return s.client.XAck(ctx, s.stream, s.group, ids...).Err()
+}
+
+func (s *RedisSubscriber) Close() error {
+	return s.client.Close()
+}
+
+func isBusyGroup(err error) bool {
+	return strings.HasPrefix(err.Error(), "BUSYGROUP")
+}
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/events/pb"
+)
+
+func DeliveryToProto(event models.LifecycleEvent) *pb.NotificationDelivery {
+	data := &pb.NotificationDelivery{
+		ReminderId: event.ReminderID.String(),
+		UserId:     event.UserID.String(),
+	}
+	if d := event.Delivery; d != nil {
+		data.NotificationId = d.NotificationID
+		data.Channel = d.Channel
+		data.Attempt = int32(d.Attempt)
+		data.LatencyMs = d.LatencyMs
+		data.DurationMs = d.DurationMs
+		data.Error = d.Error
+	}
+	return data
+}
+
+// DeliveryFromProto rebuilds the models.LifecycleEvent of a delivery receipt.
+func DeliveryFromProto(env Envelope, data *pb.NotificationDelivery) (models.LifecycleEvent, error) {
+	eventID, err := uuid.Parse(env.ID)
+	if err != nil {
+		return models.LifecycleEvent{}, fmt.Errorf("invalid event id: %w", err)
+	}
+	reminderID, err := uuid.Parse(data.GetReminderId())
+	if err != nil {
+		return models.LifecycleEvent{}, fmt.Errorf("invalid reminder id: %w", err)
+	}
+	userID, err := uuid.Parse(data.GetUserId())
+	if err != nil {
+		return models.LifecycleEvent{}, fmt.Errorf("invalid user id: %w", err)
+	}
+
+	return models.LifecycleEvent{
+		EventID:    eventID,
+		EventType:  env.Type,
+		ReminderID: reminderID,
+		UserID:     userID,
+		Timestamp:  env.Time,
+		Delivery: &models.DeliveryReceipt{
+			NotificationID: data.GetNotificationId(),
+			Channel:        data.GetChannel(),
+			Attempt:        int(data.GetAttempt()),
+			LatencyMs:      data.GetLatencyMs(),
+			DurationMs:     data.GetDurationMs(),
+			Error:          data.GetError(),
+		},
+	}, nil
+}
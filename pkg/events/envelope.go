@@ -0,0 +1,212 @@
+// Package events defines the envelope shared by all published events.
+//
+// The envelope follows CloudEvents 1.0 in the Kafka binary content mode:
+// attributes travel as "ce_" headers, the payload is the message value and
+// its encoding is named by the content-type header.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	SpecVersion = "1.0"
+
+	// SchemaVersion is the payload schema this build writes. Consumers accept
+	// any minor version of the same major and reject everything else.
+	SchemaVersion = "1.0"
+
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/protobuf"
+)
+
+// Event types
+const (
+	TypeReminderCreated          = "reminder.created"
+	TypeReminderUpdated          = "reminder.updated"
+	TypeReminderDeleted          = "reminder.deleted"
+	TypeReminderNotificationSent = "reminder.notification_sent"
+	TypeNotificationRequested    = "notification.requested"
+	TypePreferencesUpdated       = "notification.preferences_updated"
+
+	TypeReminderNotificationDelivered = "reminder.notification_delivered"
+	TypeReminderNotificationFailed    = "reminder.notification_failed"
+
+	TypeEmailVerificationRequested = "auth.email_verification_requested"
+	TypePasswordResetRequested     = "auth.password_reset_requested"
+)
+
+const (
+	headerSpecVersion   = "ce_specversion"
+	headerID            = "ce_id"
+	headerType          = "ce_type"
+	headerSource        = "ce_source"
+	headerSubject       = "ce_subject"
+	headerTime          = "ce_time"
+	headerSchemaVersion = "ce_schemaversion"
+	headerTraceParent   = "ce_traceparent"
+	headerTraceState    = "ce_tracestate"
+	headerContentType   = "content-type"
+)
+
+var (
+	ErrUnsupportedVersion     = errors.New("unsupported event version")
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+	ErrMissingAttribute       = errors.New("missing required event attribute")
+)
+
+type Envelope struct {
+	ID            string
+	Type          string
+	Source        string
+	Subject       string
+	SchemaVersion string
+	Time          time.Time
+	ContentType   string
+	TraceParent   string
+	TraceState    string
+}
+
+// New creates an envelope for the current schema version with a fresh trace.
+func New(id, eventType, source, subject string, at time.Time) Envelope {
+	return Envelope{
+		ID:            id,
+		Type:          eventType,
+		Source:        source,
+		Subject:       subject,
+		SchemaVersion: SchemaVersion,
+		Time:          at,
+		ContentType:   ContentTypeJSON,
+		TraceParent:   NewTraceParent(),
+	}
+}
+
+// Encode serializes data with the envelope's content type and returns the
+// message value together with the headers describing it.
+func Encode(env Envelope, data proto.Message) ([]byte, map[string]string, error) {
+	value, err := marshal(env.ContentType, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headers := map[string]string{
+		headerSpecVersion:   SpecVersion,
+		headerID:            env.ID,
+		headerType:          env.Type,
+		headerSource:        env.Source,
+		headerTime:          env.Time.UTC().Format(time.RFC3339Nano),
+		headerSchemaVersion: env.SchemaVersion,
+		headerContentType:   env.ContentType,
+	}
+	if env.Subject != "" {
+		headers[headerSubject] = env.Subject
+	}
+	if env.TraceParent != "" {
+		headers[headerTraceParent] = env.TraceParent
+	}
+	if env.TraceState != "" {
+		headers[headerTraceState] = env.TraceState
+	}
+
+	return value, headers, nil
+}
+
+// Parse reads the envelope from message headers. It fails with
+// ErrUnsupportedVersion when the spec or schema major version is unknown.
+func Parse(headers map[string]string) (Envelope, error) {
+	if spec := headers[headerSpecVersion]; spec != SpecVersion {
+		return Envelope{}, fmt.Errorf("%w: specversion %q", ErrUnsupportedVersion, spec)
+	}
+
+	env := Envelope{
+		ID:            headers[headerID],
+		Type:          headers[headerType],
+		Source:        headers[headerSource],
+		Subject:       headers[headerSubject],
+		SchemaVersion: headers[headerSchemaVersion],
+		ContentType:   headers[headerContentType],
+		TraceParent:   headers[headerTraceParent],
+		TraceState:    headers[headerTraceState],
+	}
+	if env.ID == "" || env.Type == "" || env.Source == "" {
+		return Envelope{}, ErrMissingAttribute
+	}
+
+	if major(env.SchemaVersion) != major(SchemaVersion) {
+		return Envelope{}, fmt.Errorf("%w: %s schema %q", ErrUnsupportedVersion, env.Type, env.SchemaVersion)
+	}
+
+	if t := headers[headerTime]; t != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return Envelope{}, fmt.Errorf("invalid event time: %w", err)
+		}
+		env.Time = parsed
+	}
+
+	if env.ContentType == "" {
+		env.ContentType = ContentTypeJSON
+	}
+
+	return env, nil
+}
+
+// Unmarshal decodes the message value into data according to the envelope's content type.
+func (e Envelope) Unmarshal(value []byte, data proto.Message) error {
+	switch e.ContentType {
+	case ContentTypeJSON:
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(value, data)
+	case ContentTypeProtobuf:
+		return proto.Unmarshal(value, data)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedContentType, e.ContentType)
+	}
+}
+
+// ParseContentType validates a configured content type, defaulting to JSON.
+func ParseContentType(contentType string) (string, error) {
+	switch contentType {
+	case "", "json", ContentTypeJSON:
+		return ContentTypeJSON, nil
+	case "protobuf", ContentTypeProtobuf:
+		return ContentTypeProtobuf, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+}
+
+func marshal(contentType string, data proto.Message) ([]byte, error) {
+	switch contentType {
+	case ContentTypeJSON:
+		return protojson.MarshalOptions{UseProtoNames: true}.Marshal(data)
+	case ContentTypeProtobuf:
+		return proto.Marshal(data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+}
+
+func major(version string) int {
+	head, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(head)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// NewTraceParent starts a new W3C trace context.
+func NewTraceParent() string {
+	var ids [24]byte
+	_, _ = rand.Read(ids[:])
+	return "00-" + hex.EncodeToString(ids[:16]) + "-" + hex.EncodeToString(ids[16:]) + "-01"
+}
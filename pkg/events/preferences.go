@@ -0,0 +1,37 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/events/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func PreferencesToProto(p models.NotificationPreferences) *pb.NotificationPreferences {
+	return &pb.NotificationPreferences{
+		UserId:           p.UserID.String(),
+		EnabledChannels:  p.EnabledChannels,
+		PriorityChannels: p.PriorityChannels,
+		Locale:           p.Locale,
+		DigestEnabled:    p.DigestEnabled,
+		UpdatedAt:        timestamppb.New(p.UpdatedAt),
+	}
+}
+
+func PreferencesFromProto(p *pb.NotificationPreferences) (models.NotificationPreferences, error) {
+	userID, err := uuid.Parse(p.GetUserId())
+	if err != nil {
+		return models.NotificationPreferences{}, fmt.Errorf("invalid user id: %w", err)
+	}
+
+	return models.NotificationPreferences{
+		UserID:           userID,
+		EnabledChannels:  p.GetEnabledChannels(),
+		PriorityChannels: p.GetPriorityChannels(),
+		Locale:           p.GetLocale(),
+		DigestEnabled:    p.GetDigestEnabled(),
+		UpdatedAt:        p.GetUpdatedAt().AsTime(),
+	}, nil
+}
@@ -0,0 +1,106 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/models"
+	"github.com/kiribu/jwt-practice/pkg/events/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func ReminderToProto(r models.Reminder) *pb.Reminder {
+	return &pb.Reminder{
+		Id:          r.ID.String(),
+		UserId:      r.UserID.String(),
+		Title:       r.Title,
+		Description: r.Description,
+		RemindAt:    timestamppb.New(r.RemindAt),
+		Priority:    r.Priority,
+		IsSent:      r.IsSent,
+		CreatedAt:   timestamppb.New(r.CreatedAt),
+		UpdatedAt:   timestamppb.New(r.UpdatedAt),
+	}
+}
+
+func ReminderFromProto(r *pb.Reminder) (models.Reminder, error) {
+	id, err := uuid.Parse(r.GetId())
+	if err != nil {
+		return models.Reminder{}, fmt.Errorf("invalid reminder id: %w", err)
+	}
+	userID, err := uuid.Parse(r.GetUserId())
+	if err != nil {
+		return models.Reminder{}, fmt.Errorf("invalid user id: %w", err)
+	}
+
+	return models.Reminder{
+		ID:          id,
+		UserID:      userID,
+		Title:       r.GetTitle(),
+		Description: r.GetDescription(),
+		RemindAt:    r.GetRemindAt().AsTime(),
+		Priority:    r.GetPriority(),
+		IsSent:      r.GetIsSent(),
+		CreatedAt:   r.GetCreatedAt().AsTime(),
+		UpdatedAt:   r.GetUpdatedAt().AsTime(),
+	}, nil
+}
+
+// LifecycleType maps a models.LifecycleEvent type to its envelope type.
+func LifecycleType(eventType string) (string, bool) {
+	switch eventType {
+	case "created":
+		return TypeReminderCreated, true
+	case "updated":
+		return TypeReminderUpdated, true
+	case "deleted":
+		return TypeReminderDeleted, true
+	case "notification_sent":
+		return TypeReminderNotificationSent, true
+	default:
+		return "", false
+	}
+}
+
+func LifecycleToProto(event models.LifecycleEvent) *pb.ReminderLifecycle {
+	data := &pb.ReminderLifecycle{
+		ReminderId: event.ReminderID.String(),
+		UserId:     event.UserID.String(),
+	}
+	if event.Payload != nil {
+		data.Reminder = ReminderToProto(*event.Payload)
+	}
+	return data
+}
+
+// LifecycleFromProto rebuilds a models.LifecycleEvent from an envelope and its data.
+func LifecycleFromProto(env Envelope, data *pb.ReminderLifecycle) (models.LifecycleEvent, error) {
+	eventID, err := uuid.Parse(env.ID)
+	if err != nil {
+		return models.LifecycleEvent{}, fmt.Errorf("invalid event id: %w", err)
+	}
+	reminderID, err := uuid.Parse(data.GetReminderId())
+	if err != nil {
+		return models.LifecycleEvent{}, fmt.Errorf("invalid reminder id: %w", err)
+	}
+	userID, err := uuid.Parse(data.GetUserId())
+	if err != nil {
+		return models.LifecycleEvent{}, fmt.Errorf("invalid user id: %w", err)
+	}
+
+	event := models.LifecycleEvent{
+		EventID:    eventID,
+		EventType:  env.Type,
+		ReminderID: reminderID,
+		UserID:     userID,
+		Timestamp:  env.Time,
+	}
+	if data.GetReminder() != nil {
+		reminder, err := ReminderFromProto(data.GetReminder())
+		if err != nil {
+			return models.LifecycleEvent{}, err
+		}
+		event.Payload = &reminder
+	}
+	return event, nil
+}
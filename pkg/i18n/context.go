@@ -0,0 +1,52 @@
+package i18n
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataKey carries the caller's locale in gRPC metadata, so that a
+// request keeps its language on every hop between services.
+const MetadataKey = "x-locale"
+
+type contextKey struct{}
+
+// WithLocale returns a context carrying l.
+func WithLocale(ctx context.Context, l Locale) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the locale of ctx, or Default when it carries none.
+func FromContext(ctx context.Context) Locale {
+	if l, ok := ctx.Value(contextKey{}).(Locale); ok {
+		return l
+	}
+	return Default
+}
+
+// UnaryClientInterceptor forwards the locale of the call's context to the server.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if l, ok := ctx.Value(contextKey{}).(Locale); ok {
+			ctx = metadata.AppendToOutgoingContext(ctx, MetadataKey, string(l))
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// UnaryServerInterceptor puts the locale sent by the client into the
+// handler's context.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(MetadataKey); len(values) > 0 {
+				if l, ok := Parse(values[0]); ok {
+					ctx = WithLocale(ctx, l)
+				}
+			}
+		}
+		return handler(ctx, req)
+	}
+}
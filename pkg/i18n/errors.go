@@ -0,0 +1,41 @@
+package i18n
+
+import "errors"
+
+// Error is an error whose message comes from the catalog. Services return
+// it without knowing who is asking; the layer that knows the caller's
+// locale renders it with Message. Error() renders it in Default, for logs.
+type Error struct {
+	Key  string
+	Args []any
+	// err is what the error wraps, for errors.Is and errors.As
+	err error
+}
+
+// NewError creates an error rendered from key and args.
+func NewError(key string, args ...any) *Error {
+	return &Error{Key: key, Args: args}
+}
+
+// Wrap is NewError for an error that should still match err.
+func Wrap(err error, key string, args ...any) *Error {
+	return &Error{Key: key, Args: args, err: err}
+}
+
+func (e *Error) Error() string {
+	return T(Default, e.Key, e.Args...)
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Message renders err for a user of l. Errors that are not from the
+// catalog keep their own text.
+func Message(l Locale, err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return T(l, e.Key, e.Args...)
+	}
+	return err.Error()
+}
@@ -0,0 +1,37 @@
+package i18n
+
+import (
+	"fmt"
+	"time"
+)
+
+var russianWeekdays = [...]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+
+// Month names in the genitive case, as they are used in dates
+var russianMonths = [...]string{
+	"января", "февраля", "марта", "апреля", "мая", "июня",
+	"июля", "августа", "сентября", "октября", "ноября", "декабря",
+}
+
+// FormatDate renders the calendar day of t in its own location, e.g.
+// "Mon, 02 Jan 2026" or "пн, 2 января 2026".
+func FormatDate(l Locale, t time.Time) string {
+	switch l {
+	case Russian:
+		return fmt.Sprintf("%s, %d %s %d", russianWeekdays[t.Weekday()], t.Day(), russianMonths[t.Month()-1], t.Year())
+	default:
+		return t.Format("Mon, 02 Jan 2006")
+	}
+}
+
+// FormatDateTime renders t in its own location down to the minute, with
+// the zone abbreviation, e.g. "Mon, 02 Jan 2026 15:04 MSK" or
+// "пн, 2 января 2026, 15:04 MSK".
+func FormatDateTime(l Locale, t time.Time) string {
+	switch l {
+	case Russian:
+		return FormatDate(l, t) + ", " + t.Format("15:04 MST")
+	default:
+		return t.Format("Mon, 02 Jan 2006 15:04 MST")
+	}
+}
@@ -0,0 +1,166 @@
+// Package i18n renders user-facing text in the user's language. Messages
+// live in a catalog of one JSON file per locale, keyed by dotted names such
+// as "reminder.title_required"; a message missing from a locale falls back
+// to English, and a key missing everywhere renders as the key itself.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale is a supported language, named by its ISO 639-1 code.
+type Locale string
+
+const (
+	English Locale = "en"
+	Russian Locale = "ru"
+
+	// Default is used when nothing better is known about the user.
+	Default = English
+)
+
+// Supported lists the locales that have a catalog.
+var Supported = []Locale{English, Russian}
+
+//go:embed locales/*.json
+var catalogFiles embed.FS
+
+var catalog = mustLoadCatalog()
+
+func mustLoadCatalog() map[Locale]map[string]string {
+	c := make(map[Locale]map[string]string, len(Supported))
+	for _, l := range Supported {
+		content, err := catalogFiles.ReadFile("locales/" + string(l) + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %s: %v", l, err))
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(content, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog for %s: %v", l, err))
+		}
+		c[l] = messages
+	}
+	return c
+}
+
+// Parse accepts a locale name with an optional region ("ru", "ru-RU",
+// "en_US") and reports whether it names a supported locale.
+func Parse(name string) (Locale, bool) {
+	base, _, _ := strings.Cut(strings.ReplaceAll(name, "_", "-"), "-")
+	l := Locale(strings.ToLower(strings.TrimSpace(base)))
+	if _, ok := catalog[l]; !ok {
+		return Default, false
+	}
+	return l, true
+}
+
+// Negotiate picks the supported locale the client prefers most from an
+// Accept-Language header, or Default when it names none of them.
+func Negotiate(acceptLanguage string) Locale {
+	type candidate struct {
+		locale Locale
+		q      float64
+		order  int
+	}
+
+	var candidates []candidate
+	for i, part := range strings.Split(acceptLanguage, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		l, ok := Parse(name)
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{locale: l, q: q, order: i})
+	}
+
+	if len(candidates) == 0 {
+		return Default
+	}
+	// Equal weights keep the client's order
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].locale
+}
+
+// T renders the message key in l, formatting args into it with fmt verbs.
+// Arguments that are errors are rendered in l too.
+func T(l Locale, key string, args ...any) string {
+	format, ok := lookup(l, key)
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+
+	localized := make([]any, len(args))
+	for i, arg := range args {
+		if err, ok := arg.(error); ok {
+			arg = Message(l, err)
+		}
+		localized[i] = arg
+	}
+	return fmt.Sprintf(format, localized...)
+}
+
+// Plural renders the form of key that agrees with n, e.g. "1 reminder" or
+// "5 напоминаний". The catalog holds the forms as key.one, key.few, key.many
+// and key.other, each formatted with n and then args.
+func Plural(l Locale, key string, n int, args ...any) string {
+	form := pluralForm(l, n)
+	if _, ok := lookup(l, key+"."+form); !ok {
+		form = "other"
+	}
+	return T(l, key+"."+form, append([]any{n}, args...)...)
+}
+
+// pluralForm follows the CLDR cardinal rules for integers.
+func pluralForm(l Locale, n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	switch l {
+	case Russian:
+		mod10, mod100 := n%10, n%100
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return "one"
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	default:
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
+
+func lookup(l Locale, key string) (string, bool) {
+	if msg, ok := catalog[l][key]; ok {
+		return msg, true
+	}
+	msg, ok := catalog[Default][key]
+	return msg, ok
+}
@@ -0,0 +1,98 @@
+package jwtkeys
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const pemExt = ".pem"
+
+// LoadDir reads every <kid>.pem file of dir. A key's creation time is the
+// modification time of its file; a file dated in the future is published
+// right away but only signs from that time on, which gives verifiers time
+// to fetch it first.
+func LoadDir(dir string) ([]*Key, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*Key
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, pemExt) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		k, err := ParsePEM(strings.TrimSuffix(name, pemExt), data, info.ModTime())
+		if err != nil {
+			return nil, &KeyFileError{Path: filepath.Join(dir, name), Err: err}
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// WriteKey saves k to dir as <kid>.pem. The file is written under a
+// temporary name and renamed, so that a concurrent LoadDir never sees it
+// half-written.
+func WriteKey(dir string, k *Key) error {
+	data, err := k.MarshalPEM()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".key-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmp.Name(), k.CreatedAt, k.CreatedAt); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, k.ID+pemExt))
+}
+
+// RemoveKey deletes the file of the key named id from dir.
+func RemoveKey(dir, id string) error {
+	err := os.Remove(filepath.Join(dir, id+pemExt))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// KeyFileError reports a key file that could not be parsed.
+type KeyFileError struct {
+	Path string
+	Err  error
+}
+
+func (e *KeyFileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *KeyFileError) Unwrap() error {
+	return e.Err
+}
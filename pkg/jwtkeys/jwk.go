@@ -0,0 +1,86 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK is the public half of a key as published in a JWKS (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// OKP keys (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK returns the public half of k.
+func (k *Key) JWK() JWK {
+	j := JWK{Kid: k.ID, Alg: k.Algorithm, Use: "sig"}
+	switch pub := k.public.(type) {
+	case ed25519.PublicKey:
+		j.Kty = "OKP"
+		j.Crv = "Ed25519"
+		j.X = base64.RawURLEncoding.EncodeToString(pub)
+	case *rsa.PublicKey:
+		j.Kty = "RSA"
+		j.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		j.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	}
+	return j
+}
+
+// JWKS returns the public halves of all keys in the set.
+func (ks *Keyset) JWKS() JWKS {
+	keys := ks.Keys()
+	doc := JWKS{Keys: make([]JWK, len(keys))}
+	for i, k := range keys {
+		doc.Keys[i] = k.JWK()
+	}
+	return doc
+}
+
+// ParseJWK turns a published key into one that can verify tokens.
+func ParseJWK(j JWK) (*Key, error) {
+	if j.Kid == "" {
+		return nil, errors.New("key without kid")
+	}
+
+	// Published keys carry no creation time; none of them can sign anyway
+	k := &Key{ID: j.Kid, Algorithm: j.Alg}
+	switch {
+	case j.Kty == "OKP" && j.Crv == "Ed25519" && j.Alg == EdDSA:
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %q: invalid Ed25519 public key", j.Kid)
+		}
+		k.public = ed25519.PublicKey(x)
+	case j.Kty == "RSA" && j.Alg == RS256:
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid RSA modulus", j.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("key %q: invalid RSA exponent", j.Kid)
+		}
+		k.public = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	default:
+		return nil, fmt.Errorf("key %q: unsupported %s key for %s", j.Kid, j.Kty, j.Alg)
+	}
+	return k, nil
+}
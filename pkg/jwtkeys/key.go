@@ -0,0 +1,139 @@
+// Package jwtkeys manages the asymmetric keys access tokens are signed with.
+// The auth service signs with the newest key of its Keyset and keeps retired
+// keys for an overlap window, so tokens signed just before a rotation still
+// verify; the public halves are published as a JWKS for anyone verifying
+// tokens on their own.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algorithms, named as in the JWT alg header.
+const (
+	EdDSA = "EdDSA"
+	RS256 = "RS256"
+)
+
+const rsaKeyBits = 2048
+
+// Key is one signing key. Keys read from a JWKS have no private half and
+// can only verify.
+type Key struct {
+	ID        string
+	Algorithm string
+	// CreatedAt orders keys: the newest private key signs
+	CreatedAt time.Time
+
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// Generate creates a key for algorithm with an ID derived from its public half.
+func Generate(algorithm string) (*Key, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case EdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case RS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newKey("", private, time.Now())
+}
+
+// ParsePEM reads a PKCS#8 private key, as written by MarshalPEM or
+// `openssl genpkey`. An empty id is replaced by one derived from the key.
+func ParsePEM(id string, data []byte, createdAt time.Time) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+	return newKey(id, private, createdAt)
+}
+
+func newKey(id string, private crypto.Signer, createdAt time.Time) (*Key, error) {
+	k := &Key{ID: id, CreatedAt: createdAt, private: private, public: private.Public()}
+	switch pub := k.public.(type) {
+	case ed25519.PublicKey:
+		k.Algorithm = EdDSA
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < rsaKeyBits {
+			return nil, fmt.Errorf("RSA key of %d bits is too short", pub.N.BitLen())
+		}
+		k.Algorithm = RS256
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", pub)
+	}
+
+	if k.ID == "" {
+		id, err := thumbprint(k.public)
+		if err != nil {
+			return nil, err
+		}
+		k.ID = id
+	}
+	return k, nil
+}
+
+// MarshalPEM encodes the private half as PKCS#8.
+func (k *Key) MarshalPEM() ([]byte, error) {
+	if k.private == nil {
+		return nil, errors.New("key has no private half")
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(k.private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// CanSign reports whether the key has its private half.
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// Method is the jwt signing method of the key's algorithm.
+func (k *Key) Method() jwt.SigningMethod {
+	if k.Algorithm == EdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// thumbprint names a key by a digest of its public half, so that the same
+// key always gets the same ID.
+func thumbprint(public crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:8]), nil
+}
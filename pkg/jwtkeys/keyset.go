@@ -0,0 +1,132 @@
+package jwtkeys
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no signing key available")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// Keyset is the set of keys currently in use. It is safe for concurrent use
+// and can be replaced as a whole while tokens are being signed and verified.
+type Keyset struct {
+	mu sync.RWMutex
+	// newest first
+	keys []*Key
+}
+
+func NewKeyset(keys ...*Key) *Keyset {
+	ks := &Keyset{}
+	ks.Replace(keys)
+	return ks
+}
+
+// Replace swaps in a new set of keys.
+func (ks *Keyset) Replace(keys []*Key) {
+	sorted := append([]*Key(nil), keys...)
+	sortNewestFirst(sorted)
+
+	ks.mu.Lock()
+	ks.keys = sorted
+	ks.mu.Unlock()
+}
+
+// Keys returns the keys, newest first.
+func (ks *Keyset) Keys() []*Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return append([]*Key(nil), ks.keys...)
+}
+
+// SigningKey returns the newest key with a private half that is not dated
+// in the future.
+func (ks *Keyset) SigningKey() (*Key, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := time.Now()
+	for _, k := range ks.keys {
+		if k.CanSign() && !k.CreatedAt.After(now) {
+			return k, nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+// Lookup finds a key by its ID.
+func (ks *Keyset) Lookup(id string) (*Key, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	for _, k := range ks.keys {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// Sign signs claims with the signing key and names it in the kid header.
+func (ks *Keyset) Sign(claims jwt.Claims) (string, error) {
+	k, err := ks.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(k.Method(), claims)
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.private)
+}
+
+// Keyfunc resolves the verification key of a token for jwt.Parse. The
+// token's alg has to be the one of the key it names, so that a token cannot
+// pick how it is verified.
+func (ks *Keyset) Keyfunc(token *jwt.Token) (any, error) {
+	id, _ := token.Header["kid"].(string)
+	k, ok := ks.Lookup(id)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	if token.Method.Alg() != k.Algorithm {
+		return nil, fmt.Errorf("key %q is for %s, not %s", k.ID, k.Algorithm, token.Method.Alg())
+	}
+	return k.public, nil
+}
+
+// Active splits keys into the ones still in use and the expired ones. The
+// newest signing key is in use, and so is every key superseded by a newer
+// one less than overlap ago: tokens it signed may still be valid. Keys dated
+// after now are in use too, as they are about to sign.
+func Active(keys []*Key, now time.Time, overlap time.Duration) (active, expired []*Key) {
+	sorted := append([]*Key(nil), keys...)
+	sortNewestFirst(sorted)
+
+	var supersededAt time.Time
+	for _, k := range sorted {
+		if supersededAt.IsZero() || now.Sub(supersededAt) < overlap {
+			active = append(active, k)
+		} else {
+			expired = append(expired, k)
+		}
+		// Verification-only and future keys do not supersede anything
+		if k.CanSign() && !k.CreatedAt.After(now) {
+			supersededAt = k.CreatedAt
+		}
+	}
+	return active, expired
+}
+
+func sortNewestFirst(keys []*Key) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+}
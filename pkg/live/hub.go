@@ -0,0 +1,151 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrInvalidID = errors.New("invalid live message id")
+
+// Hub delivers announced messages to the clients connected to this replica.
+type Hub struct {
+	client *redis.Client
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*subscription]struct{}
+}
+
+type subscription struct {
+	ch chan Message
+}
+
+func NewHub(client *redis.Client) *Hub {
+	return &Hub{
+		client:      client,
+		subscribers: make(map[uuid.UUID]map[*subscription]struct{}),
+	}
+}
+
+// Run listens on the pub/sub channel until ctx is done.
+func (h *Hub) Run(ctx context.Context) {
+	pubsub := h.client.Subscribe(ctx, Channel)
+	defer pubsub.Close()
+
+	slog.Info("Live notification hub started", "channel", Channel)
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			var msg Message
+			if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+				slog.Error("Invalid live message", "error", err)
+				continue
+			}
+			h.dispatch(msg)
+		}
+	}
+}
+
+// Subscribe registers a client of userID. The returned function must be
+// called when the client goes away. The channel is closed if the client
+// falls behind, it should then reconnect and replay what it missed.
+func (h *Hub) Subscribe(userID uuid.UUID) (<-chan Message, func()) {
+	sub := &subscription{ch: make(chan Message, 32)}
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*subscription]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub.ch, func() {
+		h.mu.Lock()
+		h.remove(userID, sub)
+		h.mu.Unlock()
+	}
+}
+
+// Replay returns userID's messages after lastID, oldest first.
+func (h *Hub) Replay(ctx context.Context, userID uuid.UUID, lastID string) ([]Message, error) {
+	if _, _, err := splitID(lastID); err != nil {
+		return nil, err
+	}
+
+	entries, err := h.client.XRange(ctx, StreamKey(userID), "("+lastID, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]Message, 0, len(entries))
+	for _, e := range entries {
+		event, _ := e.Values["event"].(string)
+		data, _ := e.Values["data"].(string)
+		msgs = append(msgs, Message{UserID: userID, ID: e.ID, Event: event, Data: json.RawMessage(data)})
+	}
+	return msgs, nil
+}
+
+// dispatch never blocks on a slow client. Such a client is disconnected
+// instead, so it catches up from the stream rather than silently missing
+// messages.
+func (h *Hub) dispatch(msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[msg.UserID] {
+		select {
+		case sub.ch <- msg:
+		default:
+			slog.Warn("Live client is too slow, disconnecting", "user_id", msg.UserID, "id", msg.ID)
+			h.remove(msg.UserID, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// remove must be called with mu held.
+func (h *Hub) remove(userID uuid.UUID, sub *subscription) {
+	delete(h.subscribers[userID], sub)
+	if len(h.subscribers[userID]) == 0 {
+		delete(h.subscribers, userID)
+	}
+}
+
+// After reports whether stream entry ID a comes after b.
+func After(a, b string) bool {
+	ams, aseq, _ := splitID(a)
+	bms, bseq, _ := splitID(b)
+	if ams != bms {
+		return ams > bms
+	}
+	return aseq > bseq
+}
+
+func splitID(id string) (uint64, uint64, error) {
+	ms, seq, _ := strings.Cut(id, "-")
+	m, err := strconv.ParseUint(ms, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidID
+	}
+	s, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidID
+	}
+	return m, s, nil
+}
@@ -0,0 +1,37 @@
+// Package live pushes notifications to connected clients across gateway
+// replicas. Every message is appended to a capped per-user Redis Stream,
+// which lets reconnecting clients replay what they missed, and announced
+// on a single pub/sub channel that every replica listens on.
+package live
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Channel is the pub/sub channel announcing new messages for all users.
+	Channel = "live:notifications"
+
+	streamPrefix = "live:stream:"
+	// MaxBacklog is roughly how many messages a user's stream keeps for replay.
+	MaxBacklog = 200
+	// Retention is how long an idle user's stream is kept.
+	Retention = 24 * time.Hour
+)
+
+// Message is one event for a user. ID is the Redis Stream entry ID, which
+// orders messages and serves as the SSE event ID.
+type Message struct {
+	UserID uuid.UUID       `json:"user_id"`
+	ID     string          `json:"id"`
+	Event  string          `json:"event"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// StreamKey is the Redis Stream holding userID's recent messages.
+func StreamKey(userID uuid.UUID) string {
+	return streamPrefix + userID.String()
+}
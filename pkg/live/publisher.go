@@ -0,0 +1,49 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type Publisher struct {
+	client *redis.Client
+}
+
+func NewPublisher(client *redis.Client) *Publisher {
+	return &Publisher{client: client}
+}
+
+// Publish stores data in userID's stream and announces it to the gateways.
+// Clients that are offline get it when they reconnect.
+func (p *Publisher) Publish(ctx context.Context, userID uuid.UUID, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal live message: %w", err)
+	}
+
+	key := StreamKey(userID)
+	id, err := p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: MaxBacklog,
+		Approx: true,
+		Values: map[string]any{"event": event, "data": payload},
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to append live message: %w", err)
+	}
+
+	msg, err := json.Marshal(Message{UserID: userID, ID: id, Event: event, Data: payload})
+	if err != nil {
+		return err
+	}
+
+	pipe := p.client.TxPipeline()
+	pipe.Expire(ctx, key, Retention)
+	pipe.Publish(ctx, Channel, msg)
+	_, err = pipe.Exec(ctx)
+	return err
+}
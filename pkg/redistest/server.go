@@ -0,0 +1,140 @@
+// Package redistest runs a stand-in for Redis in tests. It answers the
+// commands the revocation list and check send, with no revocations on
+// record: SUBSCRIBE, SCAN, MGET and PING.
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Server struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	stalled bool
+}
+
+// NewServer starts a server that is stopped when the test ends.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatalf("listen: %v", err)
+	}
+	s := &Server{listener: listener}
+	go s.serve()
+	tb.Cleanup(func() { listener.Close() })
+	return s
+}
+
+// Client returns a client of s, closed when the test ends. readTimeout is
+// also how long the revocation list waits on a quiet feed; zero keeps the
+// go-redis default.
+func (s *Server) Client(tb testing.TB, readTimeout time.Duration) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr:            s.listener.Addr().String(),
+		Protocol:        2,
+		DisableIdentity: true,
+		ReadTimeout:     readTimeout,
+	})
+	tb.Cleanup(func() { client.Close() })
+	return client
+}
+
+// Stall makes s stop answering while keeping connections open, like a
+// connection that has gone half-open.
+func (s *Server) Stall() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stalled = true
+}
+
+func (s *Server) isStalled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stalled
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	subscribed := false
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if s.isStalled() {
+			continue
+		}
+
+		switch strings.ToUpper(args[0]) {
+		case "SUBSCRIBE":
+			subscribed = true
+			for i, channel := range args[1:] {
+				fmt.Fprintf(conn, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:%d\r\n", len(channel), channel, i+1)
+			}
+		case "SCAN":
+			io.WriteString(conn, "*2\r\n$1\r\n0\r\n*0\r\n")
+		case "MGET":
+			io.WriteString(conn, "*"+strconv.Itoa(len(args)-1)+"\r\n"+strings.Repeat("$-1\r\n", len(args)-1))
+		case "PING":
+			if subscribed {
+				io.WriteString(conn, "*2\r\n$4\r\npong\r\n$0\r\n\r\n")
+			} else {
+				io.WriteString(conn, "+PONG\r\n")
+			}
+		default:
+			io.WriteString(conn, "-ERR unknown command\r\n")
+		}
+	}
+}
+
+// readCommand reads one command in the RESP array form clients send.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("unexpected command %q", line)
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
@@ -0,0 +1,229 @@
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// resubscribeDelay paces retries while Redis is unreachable.
+	resubscribeDelay = time.Second
+	// defaultQuietPeriod applies when the client has no read timeout.
+	defaultQuietPeriod = 3 * time.Second
+)
+
+// errFeedStalled means Redis did not answer a ping on the subscription, so
+// announcements may be getting lost without the connection failing.
+var errFeedStalled = errors.New("revocation feed did not answer ping")
+
+// List is a local copy of the revocations. It is only trustworthy while
+// Synced: announcements sent while the subscription was down are lost, so
+// the list is reloaded from Redis every time it (re)subscribes.
+type List struct {
+	client *redis.Client
+
+	mu     sync.RWMutex
+	events map[string]Event
+	synced bool
+	// changed is closed and replaced whenever revocations are added
+	changed chan struct{}
+}
+
+func NewList(client *redis.Client) *List {
+	return &List{
+		client:  client,
+		events:  make(map[string]Event),
+		changed: make(chan struct{}),
+	}
+}
+
+// Run keeps the list in sync until ctx is done.
+func (l *List) Run(ctx context.Context) {
+	slog.Info("Revocation list started", "channel", Channel)
+
+	for {
+		err := l.follow(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if l.setSynced(false) {
+			slog.Warn("Revocation feed lost, tokens are verified by the auth service until it resumes", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+// follow subscribes to the announcements and applies them until the
+// subscription fails. A half-open connection never fails by itself, so a
+// feed that stays quiet for the client's read timeout is pinged, and one that
+// does not answer within another period counts as failed.
+func (l *List) follow(ctx context.Context) error {
+	pubsub := l.client.Subscribe(ctx, Channel)
+	defer pubsub.Close()
+
+	quiet := l.client.Options().ReadTimeout
+	if quiet <= 0 {
+		quiet = defaultQuietPeriod
+	}
+
+	pinged := false
+	for {
+		msg, err := pubsub.ReceiveTimeout(ctx, quiet)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil {
+			if pinged {
+				return errFeedStalled
+			}
+			if err := pubsub.Ping(ctx); err != nil {
+				return err
+			}
+			pinged = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		pinged = false
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			// Confirms every subscription, including the one made after a
+			// reconnect; announcements published before it are only in Redis
+			if m.Kind != "subscribe" {
+				continue
+			}
+			if err := l.load(ctx); err != nil {
+				slog.Error("Failed to load revocations", "error", err)
+				return err
+			}
+			if !l.setSynced(true) {
+				slog.Info("Revocation list in sync")
+			}
+		case *redis.Message:
+			var event Event
+			if err := json.Unmarshal([]byte(m.Payload), &event); err != nil {
+				slog.Error("Invalid revocation event", "error", err)
+				continue
+			}
+			l.add(event)
+		}
+	}
+}
+
+// Synced reports whether the list can be relied on.
+func (l *List) Synced() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.synced
+}
+
+// Changed returns a channel that is closed the next time revocations are
+// added, for holders of long-lived connections to recheck their tokens.
+func (l *List) Changed() <-chan struct{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.changed
+}
+
+// IsRevoked reports whether the list revokes t.
+func (l *List) IsRevoked(t Token) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := time.Now()
+	for _, k := range []string{key(KindToken, t.ID), key(KindSession, t.SessionID), key(KindUser, t.UserID)} {
+		if e, ok := l.events[k]; ok && now.Before(e.ExpiresAt) && e.revokes(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// setSynced returns the previous state.
+func (l *List) setSynced(synced bool) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	was := l.synced
+	l.synced = synced
+	return was
+}
+
+func (l *List) add(event Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for k, e := range l.events {
+		if !now.Before(e.ExpiresAt) {
+			delete(l.events, k)
+		}
+	}
+
+	k := key(event.Kind, event.ID)
+	// A later revocation of a user covers everything the earlier one did
+	if prev, ok := l.events[k]; ok && prev.Before.After(event.Before) {
+		return
+	}
+	l.events[k] = event
+	l.notifyLocked()
+}
+
+// notifyLocked wakes everyone waiting on Changed. Must be called with mu held.
+func (l *List) notifyLocked() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// load replaces the list with the records in Redis.
+func (l *List) load(ctx context.Context) error {
+	var keys []string
+	iter := l.client.Scan(ctx, 0, keyPrefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	events := make(map[string]Event, len(keys))
+	for start := 0; start < len(keys); start += 1000 {
+		batch := keys[start:min(start+1000, len(keys))]
+		values, err := l.client.MGet(ctx, batch...).Result()
+		if err != nil {
+			return err
+		}
+		for i, v := range values {
+			// Keys that expired meanwhile come back empty
+			s, ok := v.(string)
+			if !ok {
+				continue
+			}
+			var e Event
+			if err := json.Unmarshal([]byte(s), &e); err != nil {
+				slog.Warn("Skipping invalid revocation record", "key", batch[i], "error", err)
+				continue
+			}
+			events[batch[i]] = e
+		}
+	}
+
+	l.mu.Lock()
+	l.events = events
+	l.notifyLocked()
+	l.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,89 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kiribu/jwt-practice/pkg/redistest"
+)
+
+func TestListChangedOnRevocation(t *testing.T) {
+	l := NewList(nil)
+	changed := l.Changed()
+
+	l.add(Event{Kind: KindSession, ID: "session-1", ExpiresAt: time.Now().Add(time.Minute)})
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Changed was not closed by a revocation")
+	}
+
+	if !l.IsRevoked(Token{ID: "jti-1", SessionID: "session-1", UserID: "user-1"}) {
+		t.Error("token of a revoked session is not revoked")
+	}
+	if l.IsRevoked(Token{ID: "jti-2", SessionID: "session-2", UserID: "user-1"}) {
+		t.Error("token of another session is revoked")
+	}
+
+	// Each change gets a fresh channel
+	select {
+	case <-l.Changed():
+		t.Fatal("Changed is closed before the next revocation")
+	default:
+	}
+}
+
+func TestListRevokesUserTokensIssuedBefore(t *testing.T) {
+	l := NewList(nil)
+	revokedAt := time.Now()
+	l.add(Event{Kind: KindUser, ID: "user-1", Before: revokedAt, ExpiresAt: revokedAt.Add(time.Minute)})
+
+	if !l.IsRevoked(Token{UserID: "user-1", IssuedAt: revokedAt.Add(-time.Second)}) {
+		t.Error("token issued before the revocation is not revoked")
+	}
+	if l.IsRevoked(Token{UserID: "user-1", IssuedAt: revokedAt.Add(time.Second)}) {
+		t.Error("token issued after the revocation is revoked")
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestListStaysSyncedOnQuietFeed(t *testing.T) {
+	server := redistest.NewServer(t)
+	l := NewList(server.Client(t, 20*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Run(ctx)
+	waitFor(t, "sync", l.Synced)
+
+	// Several quiet periods, each answered ping keeps the feed trusted
+	time.Sleep(200 * time.Millisecond)
+	if !l.Synced() {
+		t.Fatal("list lost sync on a quiet but healthy feed")
+	}
+}
+
+func TestListLosesSyncWhenFeedStalls(t *testing.T) {
+	server := redistest.NewServer(t)
+	l := NewList(server.Client(t, 20*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Run(ctx)
+	waitFor(t, "sync", l.Synced)
+
+	server.Stall()
+	waitFor(t, "the stalled feed to be noticed", func() bool { return !l.Synced() })
+}
@@ -0,0 +1,141 @@
+// Package revocation tells gateways which access tokens were revoked before
+// they expire. The auth service records each revocation in Redis for as
+// long as it matters and announces it on a pub/sub channel; every gateway
+// keeps a List of the records, loaded once and then fed by the
+// announcements.
+//
+// A revocation covers one token (by its jti), every token of a session, or
+// every token of a user issued up to some time.
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Channel announces revocations to all gateways.
+	Channel = "auth:revocations"
+
+	keyPrefix = "revoked:"
+)
+
+type Kind string
+
+const (
+	KindToken   Kind = "token"
+	KindSession Kind = "session"
+	KindUser    Kind = "user"
+)
+
+// Event announces a revocation. ID is a jti, session ID or user ID
+// depending on Kind; for KindUser, tokens issued before Before are
+// revoked. ExpiresAt is when the last affected token expires, after which
+// the revocation no longer matters.
+type Event struct {
+	Kind      Kind      `json:"kind"`
+	ID        string    `json:"id"`
+	Before    time.Time `json:"before,omitzero"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Token is what a revocation check needs to know about an access token.
+type Token struct {
+	ID        string
+	SessionID string
+	UserID    string
+	IssuedAt  time.Time
+}
+
+func key(kind Kind, id string) string {
+	return keyPrefix + string(kind) + ":" + id
+}
+
+// RevokeToken revokes one token until it expires.
+func RevokeToken(ctx context.Context, client *redis.Client, jti string, expiresAt time.Time) error {
+	return publish(ctx, client, Event{Kind: KindToken, ID: jti, ExpiresAt: expiresAt})
+}
+
+// RevokeSessions revokes every token of the sessions. ttl is the longest a
+// token issued to them can still be valid.
+func RevokeSessions(ctx context.Context, client *redis.Client, ttl time.Duration, sessionIDs ...string) error {
+	expiresAt := time.Now().Add(ttl)
+	events := make([]Event, len(sessionIDs))
+	for i, id := range sessionIDs {
+		events[i] = Event{Kind: KindSession, ID: id, ExpiresAt: expiresAt}
+	}
+	return publish(ctx, client, events...)
+}
+
+// RevokeUser revokes every token of the user issued before before. Tokens
+// carry their issue time in whole seconds, so before is truncated to the
+// second: a token issued within that second, such as one from a login right
+// after a password change, stays valid. Callers revoke the sessions in
+// question as well to cover older tokens issued in the same second. ttl is
+// the longest a revoked token can still be valid.
+func RevokeUser(ctx context.Context, client *redis.Client, userID string, before time.Time, ttl time.Duration) error {
+	before = before.Truncate(time.Second)
+	return publish(ctx, client, Event{Kind: KindUser, ID: userID, Before: before, ExpiresAt: time.Now().Add(ttl)})
+}
+
+func publish(ctx context.Context, client *redis.Client, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	pipe := client.TxPipeline()
+	for _, e := range events {
+		ttl := time.Until(e.ExpiresAt)
+		if ttl <= 0 {
+			continue
+		}
+		msg, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, key(e.Kind, e.ID), msg, ttl)
+		pipe.Publish(ctx, Channel, msg)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IsRevoked looks the token up in Redis itself.
+func IsRevoked(ctx context.Context, client *redis.Client, t Token) (bool, error) {
+	keys := []string{key(KindToken, t.ID), key(KindSession, t.SessionID), key(KindUser, t.UserID)}
+	values, err := client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return false, err
+	}
+
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(s), &e); err != nil {
+			return false, errors.New("invalid revocation record")
+		}
+		if e.revokes(t) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (e Event) revokes(t Token) bool {
+	switch e.Kind {
+	case KindToken:
+		return t.ID != "" && e.ID == t.ID
+	case KindSession:
+		return t.SessionID != "" && e.ID == t.SessionID
+	case KindUser:
+		return e.ID == t.UserID && t.IssuedAt.Before(e.Before)
+	}
+	return false
+}
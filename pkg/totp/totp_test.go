@@ -0,0 +1,164 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the ASCII key "12345678901234567890" of the RFC test
+// vectors, base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 4226, Appendix D.
+func TestHOTPVectors(t *testing.T) {
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+	for counter, code := range want {
+		got, err := Code(rfcSecret, int64(counter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != code {
+			t.Errorf("counter %d: code = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+// RFC 6238, Appendix B, SHA1 rows. The RFC lists 8 digits; the last 6 are
+// what a 6 digit code is, as both truncate the same value.
+func TestTOTPVectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		at := time.Unix(tt.unix, 0)
+		want := tt.code[len(tt.code)-Digits:]
+
+		got, err := Code(rfcSecret, Step(at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("T=%d: code = %s, want %s", tt.unix, got, want)
+		}
+
+		step, ok := Validate(rfcSecret, want, at)
+		if !ok || step != Step(at) {
+			t.Errorf("T=%d: Validate = %d, %v; want %d, true", tt.unix, step, ok, Step(at))
+		}
+	}
+}
+
+func TestValidateSkewWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	tests := []struct {
+		offset int64
+		ok     bool
+	}{
+		{-2, false},
+		{-1, true},
+		{0, true},
+		{1, true},
+		{2, false},
+	}
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, current+tt.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		step, ok := Validate(rfcSecret, code, now)
+		if ok != tt.ok {
+			t.Errorf("step %+d: accepted = %v, want %v", tt.offset, ok, tt.ok)
+		}
+		if ok && step != current+tt.offset {
+			t.Errorf("step %+d: matched step %d, want %d", tt.offset, step, current+tt.offset)
+		}
+	}
+}
+
+// The window is in steps, not seconds: the first and last second of a step
+// accept the same codes.
+func TestValidateStepBoundaries(t *testing.T) {
+	start := time.Unix(Step(time.Unix(1234567890, 0))*30, 0)
+	end := start.Add(Period - time.Second)
+
+	code, err := Code(rfcSecret, Step(start)+Skew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, at := range []time.Time{start, end} {
+		if _, ok := Validate(rfcSecret, code, at); !ok {
+			t.Errorf("code of step %d rejected at %d", Step(start)+Skew, at.Unix())
+		}
+	}
+	if _, ok := Validate(rfcSecret, code, start.Add(-time.Second)); ok {
+		t.Error("code accepted two steps early")
+	}
+}
+
+func TestValidateRejectsMalformedInput(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, err := Code(rfcSecret, Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, secret, code string
+	}{
+		{"short code", rfcSecret, code[1:]},
+		{"long code", rfcSecret, code + "0"},
+		{"8 digit code", rfcSecret, "14050471"},
+		{"empty code", rfcSecret, ""},
+		{"invalid secret", "not base32!", code},
+	}
+	for _, tt := range tests {
+		if _, ok := Validate(tt.secret, tt.code, now); ok {
+			t.Errorf("%s accepted", tt.name)
+		}
+	}
+}
+
+// Secrets are accepted the way people copy them.
+func TestSecretFormatting(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, err := Code(rfcSecret, Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spaced := strings.ToLower(rfcSecret[:8] + " " + rfcSecret[8:16] + " " + rfcSecret[16:])
+	if _, ok := Validate(spaced, code, now); !ok {
+		t.Error("lower case secret with spaces rejected")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != secretSize {
+		t.Errorf("secret is %d bytes, want %d", len(key), secretSize)
+	}
+	if strings.Contains(secret, "=") {
+		t.Errorf("secret %q is padded", secret)
+	}
+}
@@ -4,16 +4,12 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
-	"os"
-	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-)
-
-var (
-	accessSecret []byte
-	once         sync.Once
+	"github.com/google/uuid"
+	"github.com/kiribu/jwt-practice/pkg/jwtkeys"
+	"github.com/kiribu/jwt-practice/pkg/revocation"
 )
 
 const (
@@ -24,29 +20,36 @@ const (
 type Claims struct {
 	Username string `json:"username"`
 	UserID   string `json:"user_id"` // UUID as string
+	// SessionID names the login the token was issued to, so that revoking
+	// the session also rejects its tokens. Empty in tokens issued before
+	// sessions existed.
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// getAccessSecret returns JWT secret with lazy initialization
-func getAccessSecret() []byte {
-	once.Do(func() {
-		accessSecret = getJWTSecret()
-	})
-	return accessSecret
-}
-
-func GenerateAccessToken(username, userID string) (string, error) {
+// GenerateAccessToken signs a token with the newest key of keys.
+func GenerateAccessToken(keys *jwtkeys.Keyset, username, userID, sessionID string) (string, error) {
 	claims := &Claims{
-		Username: username,
-		UserID:   userID, // Store UUID as string
+		Username:  username,
+		UserID:    userID, // Store UUID as string
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.Must(uuid.NewV7()).String(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(getAccessSecret())
+	return keys.Sign(claims)
+}
+
+// Revocation is what revocation checks need to know about the token.
+func (c *Claims) Revocation() revocation.Token {
+	t := revocation.Token{ID: c.ID, SessionID: c.SessionID, UserID: c.UserID}
+	if c.IssuedAt != nil {
+		t.IssuedAt = c.IssuedAt.Time
+	}
+	return t
 }
 
 func GenerateRefreshToken() (string, error) {
@@ -58,16 +61,17 @@ func GenerateRefreshToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
-func ValidateAccessToken(tokenString string) (*Claims, error) {
+// ValidateAccessToken verifies a token against the key of keys named in its
+// kid header.
+func ValidateAccessToken(keys *jwtkeys.Keyset, tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return getAccessSecret(), nil
-	})
-
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc,
+		jwt.WithValidMethods([]string{jwtkeys.EdDSA, jwtkeys.RS256}),
+		// Revocation relies on both
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -78,11 +82,3 @@ func ValidateAccessToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
-
-func getJWTSecret() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		panic("JWT_SECRET environment variable is required but not set")
-	}
-	return []byte(secret)
-}